@@ -1,13 +1,21 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/mrumyantsev/currency-converter-app/internal/app/server"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/version"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
 )
 
 func init() {
@@ -19,29 +27,281 @@ func init() {
 	log.Logger = log.Output(conWrt)
 }
 
+const (
+	commandServe    = "serve"
+	commandFetch    = "fetch"
+	commandConvert  = "convert"
+	commandExport   = "export"
+	commandImport   = "import"
+	commandBackfill = "backfill"
+	commandGaps     = "gaps"
+	commandMigrate  = "migrate"
+	commandOnce     = "once"
+	commandConfig   = "config"
+	commandHealth   = "healthcheck"
+	commandVersion  = "version"
+)
+
+const subcommandPrint = "print"
+
+// exitCodeUpdateFailed is the exit code of the once command, when the
+// update has failed, as opposed to the failures of initialization.
+const exitCodeUpdateFailed = 2
+
+// A command is run with the application and the arguments, that follow
+// the name of the command.
+type command struct {
+	name        string
+	description string
+	run         func(app *server.App, args []string) error
+	failure     string
+	exitCode    int
+}
+
+var commands = []command{
+	{commandServe, "Run the server (the default command)", serve, "failed to run application", 1},
+	{commandFetch, "Save currencies from the source to the file", fetch, "failed to save currencies to file", 1},
+	{commandConvert, "Convert an amount, e.g. convert 100 USD EUR", convert, "failed to convert", 1},
+	{commandExport, "Export currency history (see export -h)", export, "failed to export currency history", 1},
+	{commandImport, "Import currency history from CSV (see import -h)", importHistory, "failed to import currency history", 1},
+	{commandBackfill, "Store the rates of past days (see backfill -h)", backfill, "failed to backfill currencies", 1},
+	{commandGaps, "Report the missing rates of past days (see gaps -h)", gaps, "failed to check currency history", 1},
+	{commandMigrate, "Apply database schema migrations", migrate, "failed to migrate database schema", 1},
+	{commandOnce, "Update currencies once and exit (see once -h)", runOnce, "failed to update currencies", exitCodeUpdateFailed},
+	{commandHealth, "Exit with 0, if the local server is healthy (see healthcheck -h)", healthCheck, "health check failed", 1},
+	{commandConfig, "Print the effective configuration (config print)", printConfig, "failed to print configuration", 1},
+}
+
 func main() {
-	app, err := server.New()
+	flags := new(config.Flags)
+	flags.Register(flag.CommandLine)
+
+	flag.Usage = usage
+
+	flag.Parse()
+
+	name := flag.Arg(0)
+	if name == "" {
+		name = commandServe
+	}
+
+	// The version is printed without the configuration, which may be
+	// invalid.
+	if name == commandVersion {
+		fmt.Println(version.Get())
+		return
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintln(flag.CommandLine.Output(), "unknown command: "+name)
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	app, err := server.New(flags)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to initialize application")
 	}
 
-	if isUserWantSave() {
-		if err = app.SaveCurrencyDataToFile(); err != nil {
-			log.Fatal().Err(err).Msg("failed to save currencies to file")
+	var args []string
+
+	if flag.NArg() > 0 {
+		args = flag.Args()[1:]
+	}
+
+	if err = cmd.run(app, args); err != nil {
+		log.WithLevel(zerolog.FatalLevel).Err(err).Msg(cmd.failure)
+		os.Exit(cmd.exitCode)
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
 		}
+	}
 
-		return
+	return command{}, false
+}
+
+func serve(app *server.App, _ []string) error {
+	return app.Run()
+}
+
+// fetch saves the currencies from the source to the file, that is read
+// instead of the source, when READ_CURRENCIES_FROM_FILE is set.
+func fetch(app *server.App, _ []string) error {
+	return app.SaveCurrencyDataToFile()
+}
+
+// convert converts the amount of the arguments between the currencies
+// and prints the result, so no running server is needed.
+func convert(app *server.App, args []string) error {
+	if len(args) != 3 {
+		return errors.New("expected arguments: amount from to, e.g. 100 USD EUR")
 	}
 
-	if err = app.Run(); err != nil {
-		log.Fatal().Err(err).Msg("failed to run application")
+	amount, err := decimal.NewFromString(strings.Replace(args[0], ",", ".", 1))
+	if err != nil {
+		return errors.New("invalid amount: " + args[0])
 	}
+
+	from, to := strings.ToUpper(args[1]), strings.ToUpper(args[2])
+
+	converted, rateDate, err := app.Convert(amount, from, to)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s = %s %s (rates of %s)\n",
+		amount, from,
+		converted, to,
+		rateDate,
+	)
+
+	return nil
 }
 
-func isUserWantSave() bool {
-	f := flag.Bool("s", false, "Save currency data to a local file")
+func migrate(app *server.App, _ []string) error {
+	return app.Migrate()
+}
 
-	flag.Parse()
+// backfill parses the arguments of the backfill command and stores the
+// rates of the days of the period.
+func backfill(app *server.App, args []string) error {
+	flags := flag.NewFlagSet(commandBackfill, flag.ExitOnError)
+
+	today := time.Now().Format(models.RateDateLayout)
+
+	fromDate := flags.String("from", today, "First rate date to store (YYYY-MM-DD)")
+	toDate := flags.String("to", today, "Last rate date to store (YYYY-MM-DD)")
+
+	_ = flags.Parse(args)
+
+	return app.Backfill(*fromDate, *toDate)
+}
+
+// gaps parses the arguments of the gaps command and prints the rate
+// dates of the period, that the rates are missing on.
+func gaps(app *server.App, args []string) error {
+	flags := flag.NewFlagSet(commandGaps, flag.ExitOnError)
+
+	today := time.Now()
+
+	fromDate := flags.String("from", today.AddDate(0, 0, -30).Format(models.RateDateLayout), "First rate date to check (YYYY-MM-DD)")
+	toDate := flags.String("to", today.Format(models.RateDateLayout), "Last rate date to check (YYYY-MM-DD)")
+	isBackfill := flags.Bool("backfill", false, "Store the missing rates from the source")
+
+	_ = flags.Parse(args)
+
+	return app.CheckGaps(*fromDate, *toDate, *isBackfill, os.Stdout)
+}
+
+// export parses the arguments of the export command and writes the
+// currency history to the output file or to stdout.
+func export(app *server.App, args []string) error {
+	flags := flag.NewFlagSet(commandExport, flag.ExitOnError)
+
+	fromDate := flags.String("from", "1970-01-01", "First rate date of the history (YYYY-MM-DD)")
+	toDate := flags.String("to", time.Now().Format(models.RateDateLayout), "Last rate date of the history (YYYY-MM-DD)")
+	format := flags.String("format", "csv", "Format of the history: csv, json or ndjson")
+	output := flags.String("output", "", "Output file (stdout, if not set)")
+
+	_ = flags.Parse(args)
+
+	var w io.Writer = os.Stdout
+
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+
+		w = file
+	}
+
+	return app.Export(*fromDate, *toDate, *format, w)
+}
+
+// importHistory parses the arguments of the import command and loads
+// the currency history from the input file or from stdin.
+func importHistory(app *server.App, args []string) error {
+	flags := flag.NewFlagSet(commandImport, flag.ExitOnError)
+
+	input := flags.String("input", "", "Input CSV file (stdin, if not set)")
+
+	_ = flags.Parse(args)
+
+	var r io.Reader = os.Stdin
+
+	if *input != "" {
+		file, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+
+		r = file
+	}
+
+	return app.Import(r)
+}
+
+// runOnce parses the arguments of the once command and updates the
+// currencies, so the command can be run by cron instead of the daemon.
+func runOnce(app *server.App, args []string) error {
+	flags := flag.NewFlagSet(commandOnce, flag.ExitOnError)
+
+	isForce := flags.Bool("force", false, "Get currencies from the source, even if they are up to date")
+
+	_ = flags.Parse(args)
+
+	return app.RunOnce(*isForce)
+}
+
+// healthCheck parses the arguments of the healthcheck command and
+// checks the health of the local server, so the container health can
+// be checked without curl in the image.
+func healthCheck(app *server.App, args []string) error {
+	flags := flag.NewFlagSet(commandHealth, flag.ExitOnError)
+
+	timeout := flags.Duration("timeout", 5*time.Second, "Timeout of the health request")
+
+	_ = flags.Parse(args)
+
+	return app.HealthCheck(*timeout)
+}
+
+// printConfig runs the subcommand of the config command.
+func printConfig(app *server.App, args []string) error {
+	if (len(args) != 1) || (args[0] != subcommandPrint) {
+		return errors.New("unknown config subcommand, expected: config print")
+	}
+
+	return app.PrintConfig(os.Stdout)
+}
+
+// usage prints the help of the commands and the flags.
+func usage() {
+	out := flag.CommandLine.Output()
+
+	fmt.Fprintf(out, "Usage: %s [flags] [command] [command flags]\n\nCommands:\n", os.Args[0])
+
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-12s %s\n", cmd.name, cmd.description)
+	}
+
+	fmt.Fprintf(out, "  %-12s %s\n", commandVersion, "Print the version")
+
+	fmt.Fprint(out, `
+The flags take precedence over the environment variables, which take
+precedence over the config file.
+
+Flags:
+`)
 
-	return *f
+	flag.PrintDefaults()
 }
@@ -2,300 +2,1931 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/xml"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"errors"
+	"io"
+	mathrand "math/rand"
+	"net"
+	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/alerting"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/anomaly"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/audit"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/backup"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
-	"github.com/mrumyantsev/currency-converter-app/internal/pkg/database"
+	currencyfilter "github.com/mrumyantsev/currency-converter-app/internal/pkg/currency-filter"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/endpoint"
 	fsops "github.com/mrumyantsev/currency-converter-app/internal/pkg/fs-ops"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/health"
+	historyio "github.com/mrumyantsev/currency-converter-app/internal/pkg/history-io"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/idempotency"
+	ipfilter "github.com/mrumyantsev/currency-converter-app/internal/pkg/ip-filter"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/iso4217"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/maintenance"
 	memcache "github.com/mrumyantsev/currency-converter-app/internal/pkg/mem-cache"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/metrics"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
-	"github.com/mrumyantsev/currency-converter-app/internal/pkg/repository"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/oidc"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/parser"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/quota"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/reconciler"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/server"
-	"github.com/mrumyantsev/currency-converter-app/internal/pkg/service"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/signer"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
 	timechecks "github.com/mrumyantsev/currency-converter-app/internal/pkg/time-checks"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/validator"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/version"
 	xmlparser "github.com/mrumyantsev/currency-converter-app/internal/pkg/xml-parser"
 	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+	"golang.org/x/sync/errgroup"
 )
 
+// wakeCheckInterval is the longest time, the work loop sleeps without
+// checking the wall clock.
+const wakeCheckInterval = time.Minute
+
+// shutdownTimeout is the longest time, the requests in progress are
+// waited for on shutdown.
+const shutdownTimeout = 5 * time.Second
+
+// ErrNoCurrencies is returned, when the update has no currencies, e.g.
+// the currencies have not been inserted after the update datetime.
+var ErrNoCurrencies = errors.New("no currencies")
+
+// ErrUnchangedData is returned, when the raw currency data from the
+// source are byte-identical to the ones of the latest stored update.
+var ErrUnchangedData = errors.New("currency data are unchanged")
+
+// ErrAnomalousData is returned, when the rates of the currency data
+// from the source have changed too much since the stored ones, so the
+// data are held back until the admin accepts them.
+var ErrAnomalousData = errors.New("currency data are anomalous")
+
+// ErrMissingRateDates is returned, when the rates of some dates are
+// missing in the storage after the check of the history.
+var ErrMissingRateDates = errors.New("rate dates are missing")
+
+// gapLookaheadDays is how far after the last date of the period the
+// stored rate dates are looked for, as the rates of the last days may
+// be stored under the later dates, e.g. before the holidays.
+const gapLookaheadDays = 31
+
 type App struct {
-	config     *config.Config
-	fsOps      *fsops.FsOps
-	xmlParser  *xmlparser.XmlParser
-	timeChecks *timechecks.TimeChecks
-	memCache   *memcache.MemCache
-	database   *database.Database
-	service    *service.Service
-	endpoint   *endpoint.Endpoint
-	server     *server.Server
-}
-
-func New() (*App, error) {
+	config         *config.Config
+	flags          *config.Flags
+	fsOps          *fsops.FsOps
+	xmlParser      *xmlparser.XmlParser
+	parser         parser.Parser
+	timeChecks     *timechecks.TimeChecks
+	memCache       *memcache.MemCache
+	currencyFilter *currencyfilter.Filter
+	baskets        []rates.Basket
+	backup         *backup.Backup
+	reconciler     *reconciler.Reconciler
+	extraParsers   []parser.Parser
+	storage        storage.Storage
+	health         *health.Monitor
+	maintenance    *maintenance.Mode
+	anomalies      *anomaly.Detector
+	alerter        *alerting.Alerter
+	audit          *audit.Log
+	endpoint       *endpoint.Endpoint
+	server         *server.Server
+	clock          clock.Clock
+	logger         zerolog.Logger
+	instanceId     string
+	refresh        chan struct{}
+
+	// The checksum of the raw currency data of the stored update by the
+	// id, so the same data are not parsed and stored again. It is only
+	// accessed by the update cycles, which never run at once.
+	dataChecksum   string
+	dataChecksumId int
+}
+
+// New creates the application with the configuration, that the flags,
+// which may be nil, take precedence in. The dependencies, that are not
+// set by the options, are created by the configuration.
+func New(flags *config.Flags, opts ...Option) (*App, error) {
 	cfg := config.New()
 
-	if err := cfg.Init(); err != nil {
-		return nil, errlib.Wrap(err, "could not initialize configuration")
+	if err := cfg.Init(flags); err != nil {
+		return nil, errlib.Wrap(err, "could not initialize configuration")
+	}
+
+	// The level is validated by the config.
+	logLevel, _ := zerolog.ParseLevel(cfg.LogLevel)
+
+	zerolog.SetGlobalLevel(logLevel)
+
+	log.Logger = newLogger(cfg)
+
+	a := &App{
+		config:         cfg,
+		flags:          flags,
+		fsOps:          fsops.New(cfg),
+		xmlParser:      xmlparser.New(cfg),
+		memCache:       memcache.New(cfg),
+		currencyFilter: currencyfilter.New(cfg),
+		reconciler:     reconciler.New(cfg),
+		clock:          clock.System{},
+		logger:         log.Logger,
+		instanceId:     newId(),
+		refresh:        make(chan struct{}, 1),
+	}
+
+	var deps dependencies
+
+	for _, opt := range opts {
+		opt(&deps)
+	}
+
+	if deps.clock != nil {
+		a.clock = deps.clock
+	}
+
+	if deps.logger != nil {
+		a.logger = *deps.logger
+	}
+
+	a.backup = backup.New(cfg, a.clock)
+
+	// The messages of the requests and the update cycles are logged
+	// with the fields of them by the logger of the context, which is
+	// the one of the application, unless the fields are added.
+	zerolog.DefaultContextLogger = &a.logger
+
+	a.parser = deps.parser
+
+	if a.parser == nil {
+		parser, err := parser.New(cfg)
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not create parser")
+		}
+
+		a.parser = parser
+	}
+
+	// The extra sources may be of the other formats than the main one,
+	// so each of them has the parser of its own.
+	for i := range cfg.CurrencyExtraSourceUrls {
+		extraParser, err := parser.NewOfFormat(cfg, cfg.ExtraSourceFormat(i))
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not create parser of extra source")
+		}
+
+		a.extraParsers = append(a.extraParsers, extraParser)
+	}
+
+	st := deps.storage
+
+	if st == nil {
+		switch cfg.DbDriver {
+		case config.DbDriverNone:
+			st = storage.NewFileStorage(cfg, a.fsOps)
+		case config.DbDriverBolt:
+			st = storage.NewBoltStorage(cfg)
+		default:
+			st = storage.NewDbStorage(cfg)
+		}
+	}
+
+	st = storage.NewInstrumentedStorage(cfg, st)
+
+	if cfg.IsEnableRedisCache {
+		st = storage.NewRedisCache(cfg, st)
+	}
+
+	a.storage = st
+	a.timeChecks = timechecks.New(cfg, a.clock)
+	a.health = health.New(cfg, st)
+	a.maintenance = maintenance.New(cfg, a.clock)
+
+	if cfg.AnomalyThresholdPercent > 0 {
+		a.anomalies = anomaly.New(cfg, a.clock)
+	}
+
+	for _, text := range cfg.CurrencyBaskets {
+		basket, err := rates.ParseBasket(text)
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not parse currency basket")
+		}
+
+		a.baskets = append(a.baskets, basket)
+	}
+
+	if cfg.IsEnableAlerts {
+		alerter, err := alerting.New(cfg)
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not create alerter")
+		}
+
+		a.alerter = alerter
+	}
+
+	if auditor, ok := st.(storage.Auditor); ok {
+		a.audit = audit.New(cfg, auditor, a.clock)
+	}
+
+	a.endpoint = endpoint.New(cfg, a.memCache, st, a.fsOps, a.health, a.maintenance, a.alerter, a.audit, a.clock)
+
+	if deps.source != nil {
+		a.endpoint.CurrenciesFromSource = deps.source
+	}
+
+	// The addresses of the clients are checked before anything else, so
+	// the denied clients get nothing but the refusal.
+	if apiFilter := ipfilter.New(cfg.ApiIpAllowlist, cfg.ApiIpDenylist); apiFilter.IsEnabled() {
+		a.endpoint.ApiMiddleware = append(a.endpoint.ApiMiddleware, server.IpFilter(apiFilter))
+	}
+
+	if adminFilter := ipfilter.New(cfg.AdminIpAllowlist, cfg.AdminIpDenylist); adminFilter.IsEnabled() {
+		a.endpoint.AdminMiddleware = append(a.endpoint.AdminMiddleware, server.IpFilter(adminFilter))
+	}
+
+	if cfg.ResponseSigningKey != "" {
+		// The key is validated by the config.
+		key, _ := signer.ParseKey(cfg.ResponseSigningKey)
+
+		s := signer.New(key)
+
+		a.endpoint.ApiMiddleware = append(a.endpoint.ApiMiddleware, server.SignResponses(s))
+		a.endpoint.SigningKey = endpoint.NewSigningKeyEndpoint(s)
+	}
+
+	// The maintenance mode goes before the authentication, so the clients
+	// are told to retry later, whatever the keys.
+	a.endpoint.ApiMiddleware = append(a.endpoint.ApiMiddleware,
+		server.Maintenance(a.maintenance, cfg.MaintenanceRetryAfter))
+
+	if err := a.initApiKeys(); err != nil {
+		return nil, errlib.Wrap(err, "could not initialize api keys")
+	}
+
+	// The idempotency keys are of the actors, so they go after the
+	// authentication. Only the admin endpoints change anything.
+	a.endpoint.AdminMiddleware = append(a.endpoint.AdminMiddleware,
+		server.Idempotency(idempotency.New(cfg, a.clock), cfg.IdempotencyMaxBodySize))
+
+	mwCors := middleware.CORS()
+
+	a.server = server.New(cfg, a.endpoint,
+		middleware.RequestID(),
+		server.RequestLogger(a.logger),
+		server.TraceContext(),
+		server.Recover(),
+		mwCors,
+	)
+
+	return a, nil
+}
+
+// initApiKeys requires the API keys for the API, when they are set, and
+// counts the requests made with them, when the quotas are set or the
+// usage can be reported by the admin endpoints. The admin endpoints are
+// enabled by the admin key, by the API keys with the admin scope or by
+// the OIDC provider.
+func (a *App) initApiKeys() error {
+	isAdmin := (a.config.AdminApiKey != "") || (a.config.OidcIssuerUrl != "")
+
+	for _, scope := range a.config.ApiKeyScopes {
+		if scope == config.ScopeAdmin {
+			isAdmin = true
+		}
+	}
+
+	if (len(a.config.ApiKeys) == 0) && !isAdmin {
+		return nil
+	}
+
+	tracker, ok := a.storage.(storage.UsageTracker)
+	if !ok {
+		return storage.ErrNoUsage
+	}
+
+	q := quota.New(a.config, tracker, a.clock)
+
+	if len(a.config.ApiKeys) > 0 {
+		a.endpoint.ApiMiddleware = append(a.endpoint.ApiMiddleware, server.ApiKeys(a.config.ApiKeys, q))
+	}
+
+	if isAdmin {
+		if a.config.OidcIssuerUrl != "" {
+			a.endpoint.AdminMiddleware = append(a.endpoint.AdminMiddleware, server.Oidc(oidc.New(a.config, a.clock)))
+		}
+
+		a.endpoint.AdminMiddleware = append(a.endpoint.AdminMiddleware,
+			server.AdminKey(a.config.AdminApiKey, a.config.ApiKeys, a.config.ApiKeyScopes),
+			server.RequireScope(config.ScopeAdmin))
+		a.endpoint.Usage = endpoint.NewUsageEndpoint(a.config, q)
+		a.endpoint.Maintenance = endpoint.NewMaintenanceEndpoint(a.maintenance, a.audit)
+		a.endpoint.Gaps = endpoint.NewGapsEndpoint(a, a.clock)
+
+		if a.anomalies != nil {
+			a.endpoint.Anomalies = endpoint.NewAnomaliesEndpoint(a.anomalies, a.audit, a)
+		}
+
+		if a.alerter != nil {
+			a.endpoint.AlertRules = endpoint.NewAlertsEndpoint(a.alerter, a.audit)
+		}
+
+		if a.audit != nil {
+			a.endpoint.Admin = endpoint.NewAdminEndpoint(a.config, a.audit, a)
+		}
+	}
+
+	return nil
+}
+
+func (a *App) Run() error {
+	a.logger.Info().Str("version", version.Version).Str("commit", version.Commit).Msg("service started")
+
+	err := a.storage.Connect()
+	if err != nil {
+		return errlib.Wrap(err, "could not connect to database")
+	}
+
+	a.logger.Debug().Msg("database connection opened")
+
+	// The context is canceled on shutdown to abort work in progress.
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	defer cancelRun()
+
+	if a.config.IsMigrateOnStartup {
+		if err = a.storage.Migrate(runCtx); err != nil {
+			return errors.Join(
+				errlib.Wrap(err, "could not migrate database schema"),
+				a.storage.Disconnect(),
+			)
+		}
+	}
+
+	// Both the server and the work loop may fail, and none of them
+	// must block on sending the error.
+	goErr := make(chan error, 2)
+
+	go func() {
+		if err := a.server.Start(); err != nil {
+			goErr <- errlib.Wrap(err, "http server failed")
+		}
+	}()
+
+	go a.health.Run(runCtx)
+
+	go func() {
+		if err := a.workLoop(runCtx); err != nil {
+			goErr <- errlib.Wrap(err, "could not proceed work loop")
+		}
+	}()
+
+	if a.config.RetentionDays > 0 {
+		go a.pruneLoop(runCtx)
+	}
+
+	if a.config.IsNotifyUpdates {
+		go a.listenUpdates(runCtx)
+	}
+
+	go a.refreshOnSignal(runCtx)
+
+	if (a.flags != nil) && (a.flags.ConfigFile != "") {
+		go a.reloadOnChange(runCtx)
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	// The failed service is shut down the same as the stopped one, so
+	// the database connection is not left open.
+	var runErr error
+
+	select {
+	case runErr = <-goErr:
+	case <-quit:
+		a.logger.Info().Msg("shutdown signal read")
+	}
+
+	cancelRun()
+
+	if err = a.shutdown(); err != nil {
+		return errors.Join(runErr, err)
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+
+	a.logger.Info().Msg("service gracefully shut down")
+
+	return nil
+}
+
+// shutdown stops the http server and disconnects from the database.
+// The database is disconnected, even if the server fails to stop.
+func (a *App) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	// The clients, that wait for the updates, would hold the server
+	// until the timeouts of them.
+	a.endpoint.Wait.Close()
+
+	if err := a.server.Shutdown(ctx); err != nil {
+		errs = append(errs, errlib.Wrap(err, "could not shutdown http server"))
+	} else {
+		a.logger.Debug().Msg("http server shut down")
+	}
+
+	if err := a.storage.Disconnect(); err != nil {
+		errs = append(errs, errlib.Wrap(err, "could not disconnect from database"))
+	} else {
+		a.logger.Debug().Msg("database connection closed")
+	}
+
+	return errors.Join(errs...)
+}
+
+// RunOnce performs a single update of the currencies in the storage
+// and exits. The forced update gets the currencies from the source,
+// even if they are up to date.
+func (a *App) RunOnce(isForceUpdate bool) error {
+	if err := a.storage.Connect(); err != nil {
+		return errlib.Wrap(err, "could not connect to database")
+	}
+	defer func() { _ = a.storage.Disconnect() }()
+
+	ctx := context.Background()
+
+	if a.config.IsMigrateOnStartup {
+		if err := a.storage.Migrate(ctx); err != nil {
+			return errlib.Wrap(err, "could not migrate database schema")
+		}
+	}
+
+	if a.config.CatchUpMaxDays > 0 {
+		if err := a.catchUp(ctx); err != nil {
+			a.logger.Warn().Err(err).Msg("missed days are not caught up")
+		}
+	}
+
+	if err := a.updateCurrencyDataInStorages(ctx, isForceUpdate); err != nil {
+		return errlib.Wrap(err, "could not update currency data in storages")
+	}
+
+	return nil
+}
+
+// Backfill gets the rates of the days from the first date to the last
+// one inclusive from the source and stores them, replacing the stored
+// rates of the same dates.
+func (a *App) Backfill(fromDate string, toDate string) error {
+	from, err := time.Parse(models.RateDateLayout, fromDate)
+	if err != nil {
+		return errlib.Wrap(err, "could not parse first date")
+	}
+
+	to, err := time.Parse(models.RateDateLayout, toDate)
+	if err != nil {
+		return errlib.Wrap(err, "could not parse last date")
+	}
+
+	if err = a.storage.Connect(); err != nil {
+		return errlib.Wrap(err, "could not connect to database")
+	}
+	defer func() { _ = a.storage.Disconnect() }()
+
+	inserted, err := a.backfill(context.Background(), from, to.AddDate(0, 0, 1), "")
+	if err != nil {
+		return errlib.Wrap(err, "could not backfill currencies")
+	}
+
+	a.logger.Info().Msg("backfilled snapshots: " + strconv.Itoa(inserted))
+
+	return nil
+}
+
+// CheckGaps writes the rate dates of the period, that the rates are
+// expected on, but are missing in the storage, to the writer, a date per
+// line, and exits. The missing rates are got from the source and stored
+// first, if the backfill is requested, so only those, that can not be
+// got, are written. ErrMissingRateDates is returned, if any are written.
+func (a *App) CheckGaps(fromDate string, toDate string, isBackfill bool, w io.Writer) error {
+	if err := a.storage.Connect(); err != nil {
+		return errlib.Wrap(err, "could not connect to database")
+	}
+	defer func() { _ = a.storage.Disconnect() }()
+
+	ctx := context.Background()
+
+	missing, err := a.MissingRateDates(ctx, fromDate, toDate)
+	if err != nil {
+		return err
+	}
+
+	a.logger.Info().Msg("missing rate dates: " + strconv.Itoa(len(missing)))
+
+	if isBackfill && (len(missing) > 0) {
+		inserted := 0
+
+		for _, rateDate := range missing {
+			// The date is formatted by the time checks.
+			date, _ := time.Parse(models.RateDateLayout, rateDate)
+
+			n, err := a.backfill(ctx, date, date.AddDate(0, 0, 1), "")
+
+			inserted += n
+
+			if err != nil {
+				a.logger.Error().Err(err).Msg("could not backfill " + rateDate)
+			}
+		}
+
+		a.logger.Info().Msg("backfilled snapshots: " + strconv.Itoa(inserted))
+
+		if missing, err = a.MissingRateDates(ctx, fromDate, toDate); err != nil {
+			return err
+		}
+	}
+
+	for _, rateDate := range missing {
+		if _, err = io.WriteString(w, rateDate+"\n"); err != nil {
+			return errlib.Wrap(err, "could not write missing rate dates")
+		}
+	}
+
+	if len(missing) > 0 {
+		return errlib.Wrap(ErrMissingRateDates, strconv.Itoa(len(missing))+" of period")
+	}
+
+	return nil
+}
+
+// MissingRateDates returns the rate dates of the period from the first
+// date to the last one inclusive, that the rates are expected on, but
+// are missing in the storage. The period ends today at the latest, as
+// the rates of the later dates are not published yet.
+func (a *App) MissingRateDates(ctx context.Context, fromDate string, toDate string) ([]string, error) {
+	from, err := time.Parse(models.RateDateLayout, fromDate)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not parse first date")
+	}
+
+	to, err := time.Parse(models.RateDateLayout, toDate)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not parse last date")
+	}
+
+	today, _ := time.Parse(models.RateDateLayout, a.clock.Now().Format(models.RateDateLayout))
+
+	if to.After(today) {
+		to = today
+	}
+
+	updateDatetimes, err := a.storage.GetUpdateDatetimes(ctx, fromDate,
+		to.AddDate(0, 0, gapLookaheadDays).Format(models.RateDateLayout))
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not get update datetimes")
+	}
+
+	rateDates := make([]string, 0, len(updateDatetimes))
+
+	for _, updateDatetime := range updateDatetimes {
+		rateDate := updateDatetime.RateDate
+
+		// The updates, that are stored before the rate dates are, have
+		// the dates of the updates only.
+		if (rateDate == "") && (len(updateDatetime.UpdateDatetime) >= len(models.RateDateLayout)) {
+			rateDate = updateDatetime.UpdateDatetime[:len(models.RateDateLayout)]
+		}
+
+		rateDates = append(rateDates, rateDate)
+	}
+
+	return a.timeChecks.MissingRateDates(from, to, rateDates), nil
+}
+
+// Convert converts the amount between the currencies by the latest
+// rates, that are read from the currency data file, if the currencies
+// are read from it, or from the storage otherwise. It returns the
+// converted amount, that is rounded by the configured precision and
+// rounding mode, and the date, the rates are effective on.
+func (a *App) Convert(amount decimal.Decimal, from string, to string) (decimal.Decimal, string, error) {
+	currencies, err := a.latestCurrencies()
+	if err != nil {
+		return decimal.Zero, "", errlib.Wrap(err, "could not get latest currencies")
+	}
+
+	latestRates, err := rates.Of(currencies)
+	if err != nil {
+		return decimal.Zero, "", errlib.Wrap(err, "could not get rates of currencies")
+	}
+
+	converted, err := latestRates.Convert(amount, from, to)
+	if err != nil {
+		return decimal.Zero, "", errlib.Wrap(err, "could not convert")
+	}
+
+	// The rounding mode is validated by the config.
+	converted, _ = rates.Round(converted, int32(a.config.ConvertPrecision), a.config.ConvertRounding)
+
+	return converted, currencies.RateDateString(), nil
+}
+
+// latestCurrencies returns the currencies of the currency data file or
+// the latest ones of the storage.
+func (a *App) latestCurrencies() (models.Currencies, error) {
+	ctx := context.Background()
+
+	if a.config.IsReadCurrencyDataFromFile {
+		data, err := a.fsOps.CurrencyData()
+		if err != nil {
+			return models.Currencies{}, errlib.Wrap(err, "could not get currencies from file")
+		}
+
+		currencies, _, err := a.parsedData(ctx, data)
+
+		return currencies, err
+	}
+
+	if err := a.storage.Connect(); err != nil {
+		return models.Currencies{}, errlib.Wrap(err, "could not connect to database")
+	}
+	defer func() { _ = a.storage.Disconnect() }()
+
+	updateDatetime, err := a.storage.GetLatestUpdateDatetime(ctx)
+	if err != nil {
+		return models.Currencies{}, errlib.Wrap(err, "could not get latest update datetime")
+	}
+
+	if updateDatetime.Id == 0 {
+		return models.Currencies{}, errors.New("no currencies stored yet")
+	}
+
+	currencies, err := a.storage.GetLatestCurrencies(ctx, updateDatetime.Id)
+	if err != nil {
+		return models.Currencies{}, errlib.Wrap(err, "could not get latest currencies")
+	}
+
+	if currencies.RateDate.IsZero() && (updateDatetime.RateDate != "") {
+		currencies.RateDate, _ = time.Parse(models.RateDateLayout, updateDatetime.RateDate)
+	}
+
+	return currencies, nil
+}
+
+// HealthCheck requests the health of the server, that is run with the
+// same configuration on the local host, and returns an error, unless
+// the server is healthy. Over HTTPS the certificate of the server is not
+// verified, as the server is the local one, and it is presented as the
+// certificate of the client, when the clients must present them.
+func (a *App) HealthCheck(timeout time.Duration) error {
+	host := a.config.HttpServerListenIp
+
+	if (host == "") || net.ParseIP(host).IsUnspecified() {
+		host = "localhost"
+	}
+
+	scheme := "http"
+
+	client := http.Client{Timeout: timeout}
+
+	if a.config.IsTls() {
+		tlsConfig, err := server.TlsConfig(a.config)
+		if err != nil {
+			return errlib.Wrap(err, "could not get tls config")
+		}
+
+		scheme = "https"
+
+		client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				MinVersion:         tls.VersionTLS12,
+				Certificates:       tlsConfig.Certificates,
+				InsecureSkipVerify: true,
+			},
+		}
+	}
+
+	healthUrl := scheme + "://" + net.JoinHostPort(host, a.config.HttpServerListenPort) + "/healthz"
+
+	resp, err := client.Get(healthUrl)
+	if err != nil {
+		return errlib.Wrap(err, "could not request health")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("server is unhealthy: " + resp.Status)
+	}
+
+	return nil
+}
+
+// PrintConfig writes the effective configuration with the secrets
+// redacted.
+func (a *App) PrintConfig(w io.Writer) error {
+	return a.config.Print(w)
+}
+
+// Migrate brings the database schema up to date and exits.
+func (a *App) Migrate() error {
+	if err := a.storage.Connect(); err != nil {
+		return errlib.Wrap(err, "could not connect to database")
+	}
+	defer func() { _ = a.storage.Disconnect() }()
+
+	if err := a.storage.Migrate(context.Background()); err != nil {
+		return errlib.Wrap(err, "could not migrate database schema")
+	}
+
+	a.logger.Info().Msg("database schema migrated")
+
+	return nil
+}
+
+// Export writes the currency history of the period to the writer in
+// the format and exits. The history in NDJSON is streamed, so the one
+// of many years is never kept in memory at once.
+func (a *App) Export(fromDate string, toDate string, format string, w io.Writer) error {
+	for _, date := range []string{fromDate, toDate} {
+		if _, err := time.Parse(models.RateDateLayout, date); err != nil {
+			return errlib.Wrap(err, "could not parse date")
+		}
+	}
+
+	if err := a.storage.Connect(); err != nil {
+		return errlib.Wrap(err, "could not connect to database")
+	}
+	defer func() { _ = a.storage.Disconnect() }()
+
+	if format == historyio.FormatNdjson {
+		exported := 0
+
+		err := historyio.ForEachWindow(context.Background(), a.storage.GetCurrencyHistory, fromDate, toDate,
+			func(history []models.HistoryCurrency) error {
+				exported += len(history)
+
+				return historyio.Write(w, format, history)
+			})
+		if err != nil {
+			return errlib.Wrap(err, "could not stream currency history")
+		}
+
+		a.logger.Info().Msg("exported currencies: " + strconv.Itoa(exported))
+
+		return nil
+	}
+
+	history, err := a.storage.GetCurrencyHistory(context.Background(), fromDate, toDate)
+	if err != nil {
+		return errlib.Wrap(err, "could not get currency history")
+	}
+
+	if err = historyio.Write(w, format, history); err != nil {
+		return errlib.Wrap(err, "could not write currency history")
+	}
+
+	a.logger.Info().Msg("exported currencies: " + strconv.Itoa(len(history)))
+
+	return nil
+}
+
+// Import validates the snapshots of the currency history in the CSV
+// format, which is read from the reader, and loads them into the
+// storage. The snapshots of the dates, that are already stored, are
+// replaced. Nothing is loaded, if any snapshot is invalid.
+func (a *App) Import(r io.Reader) error {
+	snapshots, err := historyio.ReadCsv(r)
+	if err != nil {
+		return errlib.Wrap(err, "could not read currency history")
+	}
+
+	for _, currencies := range snapshots {
+		if err = validator.Validate(currencies); err != nil {
+			return errlib.Wrap(err, "invalid snapshot of "+currencies.RateDateString())
+		}
+	}
+
+	if err = a.storage.Connect(); err != nil {
+		return errlib.Wrap(err, "could not connect to database")
+	}
+	defer func() { _ = a.storage.Disconnect() }()
+
+	ctx := context.Background()
+
+	for _, currencies := range snapshots {
+		// The imported rates were not fetched by the service, so the
+		// start of the rate date is taken as the update datetime.
+		datetime := currencies.RateDate.Format(time.RFC3339)
+
+		if _, err = a.storage.InsertSnapshot(ctx, datetime, currencies); err != nil {
+			return errlib.Wrap(err, "could not insert snapshot of "+currencies.RateDateString())
+		}
+	}
+
+	a.logger.Info().Msg("imported snapshots: " + strconv.Itoa(len(snapshots)))
+
+	return nil
+}
+
+func (a *App) SaveCurrencyDataToFile() error {
+	data, err := a.endpoint.CurrenciesFromSource.CurrenciesFromSource(context.Background())
+	if err != nil {
+		return errlib.Wrap(err, "could not get currencies from web")
+	}
+
+	if err = a.fsOps.OverwriteCurrencyDataFile(data); err != nil {
+		return errlib.Wrap(err, "could not write currencies to file")
+	}
+
+	a.logger.Info().Msg("currency data saved in file: " + a.config.CurrencySourceFile)
+
+	return nil
+}
+
+func (a *App) workLoop(ctx context.Context) error {
+	var (
+		nextUpdate    time.Time
+		retryInterval time.Duration
+		isForceUpdate bool
+		err           error
+	)
+
+	a.warmCache(ctx)
+
+	if a.config.CatchUpMaxDays > 0 {
+		if err = a.catchUp(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			a.logger.Warn().Err(err).Msg("missed days are not caught up")
+		}
+	}
+
+	for {
+		err = a.updateCycle(ctx, isForceUpdate)
+
+		a.markUpdate(err)
+
+		if err != nil {
+			if !a.health.Check(ctx) {
+				// The update is retried, when the database is back.
+				a.logger.Error().Err(err).Msg("could not update data, waiting for database")
+
+				if err = a.health.WaitUp(ctx); err != nil {
+					return nil
+				}
+
+				continue
+			}
+
+			retryInterval = nextRetryInterval(retryInterval, a.config.UpdateRetryMinInterval, a.config.UpdateRetryMaxInterval)
+
+			a.logger.Error().Err(err).Msg("could not update data, retrying after " +
+				retryInterval.String())
+
+			// The failed forced update is retried as forced.
+			isRefresh, err := a.wait(ctx, retryInterval)
+			if err != nil {
+				return nil
+			}
+
+			isForceUpdate = isForceUpdate || isRefresh
+
+			continue
+		}
+
+		retryInterval = 0
+
+		nextUpdate, err = a.timeChecks.NextUpdate()
+		if err != nil {
+			return errlib.Wrap(err, "could not get next update datetime")
+		}
+
+		nextUpdate = nextUpdate.Add(a.jitter())
+
+		a.memCache.SetNextUpdateAt(nextUpdate)
+
+		a.logger.Info().Msg("next update will occur after " +
+			nextUpdate.Sub(a.clock.Now()).Round(time.Second).String())
+
+		if isForceUpdate, err = a.waitUntil(ctx, nextUpdate); err != nil {
+			return nil
+		}
+	}
+}
+
+// warmCache loads the latest stored snapshot into memory cache, so the
+// data are served right after the start, while the first update cycle
+// gets the new ones from the source in the background. The failure is
+// only logged, as the update cycle loads the data anyway.
+func (a *App) warmCache(ctx context.Context) {
+	latestUpdateDatetime, err := a.storage.GetLatestUpdateDatetime(ctx)
+	if err != nil {
+		a.logger.Warn().Err(err).Msg("cache is not warmed up")
+
+		return
+	}
+
+	if latestUpdateDatetime.Id == 0 {
+		a.logger.Debug().Msg("cache is not warmed up, as there are no stored snapshots")
+
+		return
+	}
+
+	if err = a.loadCurrencies(ctx, latestUpdateDatetime, memcache.SourceDb); err != nil {
+		a.logger.Warn().Err(err).Msg("cache is not warmed up")
+
+		return
+	}
+
+	a.logger.Info().Str("rateDate", latestUpdateDatetime.RateDate).Msg("cache warmed up from storage")
+}
+
+// catchUp backfills the rates of the days, that have been missed since
+// the latest rate date in the storage, e.g. while the service was down.
+// The rates of today are left to the update cycle. The snapshots, that
+// are inserted before the failure, are kept, and the callers only log
+// the error, so the service starts anyway.
+func (a *App) catchUp(ctx context.Context) error {
+	if a.config.IsReadCurrencyDataFromFile {
+		return nil
+	}
+
+	latestUpdateDatetime, err := a.storage.GetLatestUpdateDatetime(ctx)
+	if err != nil {
+		return errlib.Wrap(err, "could not get latest update datetime")
+	}
+
+	// There is nothing to catch up with, if the storage is empty.
+	if latestUpdateDatetime.RateDate == "" {
+		return nil
+	}
+
+	latestRateDate, err := time.Parse(models.RateDateLayout, latestUpdateDatetime.RateDate)
+	if err != nil {
+		return errlib.Wrap(err, "could not parse latest rate date")
+	}
+
+	today, _ := time.Parse(models.RateDateLayout, a.clock.Now().Format(models.RateDateLayout))
+
+	fromDate := latestRateDate.AddDate(0, 0, 1)
+
+	if earliestDate := today.AddDate(0, 0, -a.config.CatchUpMaxDays); fromDate.Before(earliestDate) {
+		fromDate = earliestDate
+	}
+
+	inserted, err := a.backfill(ctx, fromDate, today, latestUpdateDatetime.RateDate)
+
+	if inserted > 0 {
+		a.logger.Info().Msg("caught up missed snapshots: " + strconv.Itoa(inserted))
+	}
+
+	if err != nil {
+		return errlib.Wrap(err, "could not catch up missed days")
+	}
+
+	return nil
+}
+
+// backfill inserts the snapshots of the rates, that are effective on
+// the days from the first date up to the last one, excluding it, and
+// are later than the stored rate date. It returns the number of the
+// inserted snapshots.
+func (a *App) backfill(ctx context.Context, fromDate time.Time, toDate time.Time, storedRateDate string) (int, error) {
+	inserted := 0
+
+	for date := fromDate; date.Before(toDate); date = date.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return inserted, err
+		}
+
+		data, err := a.endpoint.CurrenciesFromSource.CurrenciesOnDate(ctx, date)
+		if err != nil {
+			return inserted, errlib.Wrap(err, "could not get currencies of "+date.Format(models.RateDateLayout))
+		}
+
+		currencies, _, err := a.parsedData(ctx, data)
+		if err != nil {
+			return inserted, errlib.Wrap(err, "could not parse currencies of "+date.Format(models.RateDateLayout))
+		}
+
+		// There are no new rates on weekends and holidays, so the
+		// source responds with the ones, that are stored already.
+		rateDate := currencies.RateDateString()
+		if (rateDate == "") || (rateDate <= storedRateDate) {
+			continue
+		}
+
+		// The same as the imported ones, the rates have not been got
+		// on time, so the start of the rate date is the update one.
+		datetime := currencies.RateDate.Format(time.RFC3339)
+
+		if _, err = a.storage.InsertSnapshot(ctx, datetime, currencies); err != nil {
+			return inserted, errlib.Wrap(err, "could not insert snapshot of "+rateDate)
+		}
+
+		storedRateDate = rateDate
+		inserted++
+	}
+
+	return inserted, nil
+}
+
+// updateCycle updates the currencies and then the metals. The forced
+// update gets the currencies from the source, even if they are up to
+// date. The messages of the cycle are logged with the id of it. Only
+// the failure of the currencies fails the cycle, as the metals are
+// secondary and have the source of their own.
+func (a *App) updateCycle(ctx context.Context, isForceUpdate bool) error {
+	logger := a.logger.With().Str("cycleId", newId()).Logger()
+	ctx = logger.WithContext(ctx)
+
+	if err := a.updateCurrencyDataInStorages(ctx, isForceUpdate); err != nil {
+		return errlib.Wrap(err, "could not update currency data in storages")
+	}
+
+	a.updateMetals(ctx)
+
+	return nil
+}
+
+// updateMetals updates the metals and marks them as stale, when the
+// update has failed. The failure is only logged and tracked apart from
+// the currencies. The metals are not updated, when the currencies are
+// read from the file, as the source of the metals is on the web.
+func (a *App) updateMetals(ctx context.Context) {
+	logger := zerolog.Ctx(ctx)
+
+	if a.config.IsReadCurrencyDataFromFile {
+		logger.Debug().Msg("metals data are not updated, as currency data are read from file")
+
+		return
+	}
+
+	err := a.updateMetalDataInStorage(ctx)
+	if err == nil {
+		a.memCache.SetMetalsUpdateFailure(nil)
+
+		metrics.MetalsStale.Set(0)
+
+		return
+	}
+
+	logger.Error().Err(err).Msg("could not update metal data in storage")
+
+	a.memCache.SetMetalsUpdateFailure(&memcache.UpdateFailure{
+		Error:    err.Error(),
+		FailedAt: a.clock.Now(),
+	})
+
+	metrics.MetalsStale.Set(1)
+	metrics.MetalsUpdateFailures.Inc()
+}
+
+// markUpdate marks the served data as stale, when the update cycle has
+// failed, and clears the mark, when it has succeeded. The data in memory
+// cache are only replaced on success, so the data of the previous
+// update are served until then.
+func (a *App) markUpdate(err error) {
+	if err == nil {
+		a.memCache.SetUpdateFailure(nil)
+
+		metrics.DataStale.Set(0)
+		metrics.LastUpdateSuccess.SetToCurrentTime()
+
+		return
+	}
+
+	a.memCache.SetUpdateFailure(&memcache.UpdateFailure{
+		Error:    err.Error(),
+		FailedAt: a.clock.Now(),
+	})
+
+	metrics.DataStale.Set(1)
+	metrics.UpdateFailures.Inc()
+}
+
+// nextRetryInterval returns the interval before the next retry of the
+// failed update cycle, which is doubled after every failure up to the
+// max one.
+func nextRetryInterval(interval time.Duration, minInterval time.Duration, maxInterval time.Duration) time.Duration {
+	if interval < minInterval {
+		return minInterval
+	}
+
+	if interval *= 2; interval > maxInterval {
+		return maxInterval
+	}
+
+	return interval
+}
+
+// jitter returns the random delay of the update, so the instances do
+// not request the source all at once.
+func (a *App) jitter() time.Duration {
+	if a.config.UpdateJitter <= 0 {
+		return 0
+	}
+
+	return time.Duration(mathrand.Int63n(int64(a.config.UpdateJitter)))
+}
+
+// wait waits for the duration or the refresh request and reports,
+// whether the refresh has been requested. The error is returned, when
+// the context is canceled.
+func (a *App) wait(ctx context.Context, duration time.Duration) (bool, error) {
+	return a.waitUntil(ctx, a.clock.Now().Add(duration))
+}
+
+// waitUntil waits for the clock of the app to reach the time or for the
+// refresh request and reports, whether the refresh has been requested.
+// The clock is checked at least once in the wake check interval, so
+// the wait does not drift, when the clock is adjusted or the host is
+// suspended. The error is returned, when the context is canceled.
+func (a *App) waitUntil(ctx context.Context, until time.Time) (bool, error) {
+	// The monotonic clock reading is stripped to compare by the wall
+	// clock.
+	until = until.Round(0)
+
+	for {
+		remaining := until.Sub(a.clock.Now())
+		if remaining <= 0 {
+			return false, nil
+		}
+
+		if remaining > wakeCheckInterval {
+			remaining = wakeCheckInterval
+		}
+
+		timer := time.NewTimer(remaining)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return false, ctx.Err()
+		case <-timer.C:
+		case <-a.refresh:
+			timer.Stop()
+
+			a.logger.Info().Msg("refresh requested")
+
+			return true, nil
+		}
+	}
+}
+
+// Refresh requests the work loop to update the data out of schedule.
+// The requests, that are made before the update starts, are merged.
+func (a *App) Refresh() {
+	select {
+	case a.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// refreshOnSignal requests the refresh on every SIGHUP or SIGUSR1,
+// until the context is canceled.
+func (a *App) refreshOnSignal(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGUSR1)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			a.Refresh()
+		}
+	}
+}
+
+// reloadOnChange reloads the configuration every time the config file
+// is changed, until the context is canceled.
+func (a *App) reloadOnChange(ctx context.Context) {
+	if err := config.Watch(ctx, a.flags.ConfigFile, a.reloadConfig); err != nil {
+		a.logger.Error().Err(err).Msg("could not watch config file, reload is disabled")
+	}
+}
+
+// reloadConfig applies the settings of the reloaded configuration, that
+// can be changed without restart. The current configuration is kept,
+// if the reloaded one is invalid.
+func (a *App) reloadConfig() {
+	cfg, err := a.config.Reload(a.flags)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("could not reload configuration, the current one is kept")
+		return
 	}
 
-	memCache := memcache.New()
+	// The level is validated by the config.
+	logLevel, _ := zerolog.ParseLevel(cfg.LogLevel)
 
-	db := database.New(cfg)
+	zerolog.SetGlobalLevel(logLevel)
 
-	repository := repository.New(cfg, db)
+	a.logger.Info().Str("logLevel", logLevel.String()).Msg("configuration reloaded")
 
-	service := service.New(cfg, repository)
+	if a.maintenance.Set(cfg.IsMaintenanceMode) {
+		a.logger.Warn().Bool("isEnabled", cfg.IsMaintenanceMode).Msg("maintenance mode switched by configuration")
+	}
 
-	endpoint := endpoint.New(cfg, memCache, service)
+	if a.config.IsRestartNeeded(cfg) {
+		a.logger.Warn().Msg("configuration changes other than log level take effect after restart")
+	}
+}
 
-	mwCors := middleware.CORS()
+// pruneLoop deletes the updates, that are out of the retention window,
+// once in the prune interval, until the context is canceled.
+func (a *App) pruneLoop(ctx context.Context) {
+	ticker := time.NewTicker(a.config.PruneInterval)
+	defer ticker.Stop()
 
-	server := server.New(cfg, endpoint, mwCors)
+	for {
+		a.prune(ctx)
 
-	return &App{
-		config:     cfg,
-		fsOps:      fsops.New(cfg),
-		xmlParser:  xmlparser.New(cfg),
-		timeChecks: timechecks.New(cfg),
-		memCache:   memCache,
-		database:   db,
-		service:    service,
-		endpoint:   endpoint,
-		server:     server,
-	}, nil
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
-func (a *App) Run() error {
-	log.Info().Msg("service started")
+func (a *App) prune(ctx context.Context) {
+	date := a.clock.Now().AddDate(0, 0, -a.config.RetentionDays).Format(models.RateDateLayout)
 
-	err := a.database.Connect()
+	deleted, err := a.storage.PruneBefore(ctx, date)
 	if err != nil {
-		return errlib.Wrap(err, "could not connect to database")
+		a.logger.Error().Err(err).Msg("could not prune outdated updates")
+		return
 	}
 
-	log.Debug().Msg("database connection opened")
+	metrics.PrunedUpdates.Add(float64(deleted))
 
-	goErr := make(chan error, 1)
+	a.logger.Info().Msg("pruned updates older than " + date + ": " + strconv.FormatInt(deleted, 10))
+}
 
-	isShutdown := false
+func (a *App) updateCurrencyDataInStorages(ctx context.Context, isForceUpdate bool) error {
+	logger := zerolog.Ctx(ctx)
 
-	go func() {
-		if err := a.server.Start(); (err != nil) && !isShutdown {
-			goErr <- errlib.Wrap(err, "could not start http server")
-		}
-	}()
+	currentDatetime := a.clock.Now().Format(time.RFC3339)
 
-	go func() {
-		if err := a.workLoop(); err != nil {
-			goErr <- errlib.Wrap(err, "could not proceed work loop")
+	var (
+		latestUpdateDatetime models.UpdateDatetime
+		latestCurrencies     models.Currencies
+		currencyData         []byte
+		isNeedUpdate         bool
+		err                  error
+	)
+
+	logger.Info().Msg("checking latest update datetime...")
+
+	latestUpdateDatetime, err = a.storage.GetLatestUpdateDatetime(ctx)
+	if err != nil {
+		return errlib.Wrap(err, "could not get current update datetime")
+	}
+
+	// An empty storage has no update datetime to check.
+	if isForceUpdate || (latestUpdateDatetime.UpdateDatetime == "") {
+		isNeedUpdate = true
+	} else {
+		isNeedUpdate, err = a.timeChecks.IsNeedForUpdateDb(&latestUpdateDatetime)
+		if err != nil {
+			return errlib.Wrap(err, "could not check is need update for db or not")
 		}
-	}()
+	}
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	if isNeedUpdate {
+		logger.Info().Msg("data is outdated")
+		logger.Info().Msg("initializing update process...")
 
-	select {
-	case err := <-goErr:
-		return err
-	case <-quit:
-		break
+		latestCurrencies, currencyData, err = a.parsedDataFromSource(ctx, latestUpdateDatetime.Id)
+
+		switch {
+		case errors.Is(err, ErrUnchangedData):
+			// The source has republished the same data. The stored
+			// update is kept as the latest one, so the next update
+			// still looks for the new data.
+			logger.Info().Str("checksum", a.dataChecksum).Msg("data is unchanged, update skipped")
+
+			a.memCache.SetUnchangedData(&memcache.UnchangedData{
+				Checksum:  a.dataChecksum,
+				CheckedAt: a.clock.Now(),
+			})
+
+			metrics.UnchangedUpdates.Inc()
+
+			isNeedUpdate = false
+		case err != nil:
+			return errlib.Wrap(err, "could not get parsed data from source")
+		}
 	}
 
-	// Graceful shutdown
+	if isNeedUpdate && (a.anomalies != nil) {
+		if err = a.checkAnomalies(ctx, latestUpdateDatetime, latestCurrencies, currencyData); err != nil {
+			return err
+		}
+	}
+
+	if isNeedUpdate {
+		logger.Info().Msg("saving data...")
+
+		latestUpdateDatetime, err = a.storage.InsertSnapshot(ctx, currentDatetime, latestCurrencies)
+		if err != nil {
+			return errlib.Wrap(err, "could not insert snapshot into db")
+		}
+
+		if a.config.IsNotifyUpdates {
+			a.notifyUpdate(ctx)
+		}
+
+		if a.config.IsArchiveCurrencyData {
+			err = a.fsOps.ArchiveCurrencyData(latestUpdateDatetime.Id, currencyData)
+			if err != nil {
+				return errlib.Wrap(err, "could not archive currency data")
+			}
+		}
 
-	log.Info().Msg("shutdown signal read")
+		a.setDataChecksum(latestUpdateDatetime.Id, currencyData)
+		a.memCache.SetUnchangedData(nil)
 
-	isShutdown = true
+		if a.anomalies != nil {
+			a.anomalies.Reset()
+		}
 
-	ctx, shutdown := context.WithTimeout(context.Background(), 5*time.Second)
-	defer shutdown()
+		// The failed backup is not retried, as the snapshot is stored
+		// already and the retried cycle would not update it again.
+		if a.config.IsEnableBackup {
+			logger.Info().Msg("uploading backup...")
 
-	if err = a.server.Shutdown(ctx); err != nil {
-		return errlib.Wrap(err, "could not shutdown http server")
+			err = a.backup.Upload(latestUpdateDatetime, currencyData, latestCurrencies)
+			if err != nil {
+				logger.Error().Err(err).Msg("could not upload backup")
+			}
+		}
 	}
 
-	log.Debug().Msg("http server shut down")
+	source := memcache.SourceDb
+
+	if isNeedUpdate {
+		source = a.sourceName()
+	}
 
-	if err = a.database.Disconnect(); err != nil {
-		return errlib.Wrap(err, "could not disconnect from database")
+	if err = a.loadCurrencies(ctx, latestUpdateDatetime, source); err != nil {
+		return errlib.Wrap(err, "could not load currencies")
 	}
 
-	log.Debug().Msg("database connection closed")
+	logger.Info().Msg("data is now up to date")
 
-	log.Info().Msg("service gracefully shut down")
+	if isNeedUpdate && (a.alerter != nil) {
+		a.checkAlerts(ctx)
+	}
 
 	return nil
 }
 
-func (a *App) SaveCurrencyDataToFile() error {
-	data, err := a.endpoint.CurrenciesFromSource.CurrenciesFromSource()
+// checkAnomalies returns ErrAnomalousData, when the rates of the
+// currencies have changed since the latest stored update by more than
+// the threshold, unless the admin has accepted the data, so they are
+// not stored and the update is retried. The data, that are held back,
+// are alerted about once. The failed notifications are only logged.
+func (a *App) checkAnomalies(
+	ctx context.Context,
+	latestUpdateDatetime models.UpdateDatetime,
+	currencies models.Currencies,
+	currencyData []byte,
+) error {
+	logger := zerolog.Ctx(ctx)
+
+	dataChecksum := checksum(currencyData)
+
+	// An empty storage has no rates to compare with.
+	if (latestUpdateDatetime.Id == 0) || a.anomalies.IsAccepted(dataChecksum) {
+		return nil
+	}
+
+	previous, err := a.storage.GetLatestCurrencies(ctx, latestUpdateDatetime.Id)
 	if err != nil {
-		return errlib.Wrap(err, "could not get currencies from web")
+		return errlib.Wrap(err, "could not get currencies from db")
 	}
 
-	if err = a.fsOps.OverwriteCurrencyDataFile(data); err != nil {
-		return errlib.Wrap(err, "could not write currencies to file")
+	changes, err := a.anomalies.Detect(previous, currencies)
+	if err != nil {
+		return errlib.Wrap(err, "could not detect anomalies")
 	}
 
-	log.Info().Msg("currency data saved in file: " + a.config.CurrencySourceFile)
+	if len(changes) == 0 {
+		return nil
+	}
 
-	return nil
-}
+	if a.anomalies.Hold(dataChecksum, currencies.RateDateString(), changes) {
+		logger.Warn().Str("checksum", dataChecksum).Int("count", len(changes)).
+			Msg("anomalous rates detected, data held back until accepted")
 
-func (a *App) workLoop() error {
-	var (
-		timeToNextUpdate time.Duration
-		err              error
-	)
+		metrics.AnomalousUpdates.Inc()
 
-	for {
-		if err = a.updateCurrencyDataInStorages(); err != nil {
-			return errlib.Wrap(err, "could not update currency data in storages")
-		}
+		if a.alerter != nil {
+			alerts := alerting.AnomalyAlerts(changes, a.anomalies.Threshold(), currencies.RateDateString())
 
-		timeToNextUpdate, err = a.timeChecks.TimeToNextUpdate()
-		if err != nil {
-			return errlib.Wrap(err, "could not get time to next update")
+			if err = a.alerter.Send(ctx, alerts); err != nil {
+				logger.Error().Err(err).Msg("could not send alerts")
+			}
 		}
+	}
 
-		log.Info().Msg("next update will occur after " +
-			(timeToNextUpdate).Round(time.Second).String())
+	return errlib.Wrap(ErrAnomalousData, strconv.Itoa(len(changes))+
+		" rates changed by more than "+a.anomalies.Threshold().String()+"%")
+}
 
-		if err = a.calculateOutputData(); err != nil {
-			return errlib.Wrap(err, "could not calculate output data")
-		}
+// checkAlerts sends the alerts, that are fired by the change of the
+// rates since the previous update. The failed notifications are only
+// logged, as the data are updated anyway.
+func (a *App) checkAlerts(ctx context.Context) {
+	logger := zerolog.Ctx(ctx)
 
-		time.Sleep(timeToNextUpdate)
+	previous, current := a.memCache.Previous(), a.memCache.Snapshot()
+
+	if (previous == nil) || (previous.Currencies == nil) || (current.Currencies == nil) {
+		return
 	}
 
-	return nil
+	previousRates, err := rates.Of(*previous.Currencies)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not get previous rates for alerts")
+		return
+	}
+
+	currentRates, err := rates.Of(*current.Currencies)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not get current rates for alerts")
+		return
+	}
+
+	alerts, err := a.alerter.Check(ctx, previousRates, currentRates)
+
+	for _, alert := range alerts {
+		logger.Warn().Int("ruleId", alert.Rule.Id).Msg("alert fired: " + alert.Message)
+	}
+
+	if err != nil {
+		logger.Error().Err(err).Msg("could not send alerts")
+	}
 }
 
-func (a *App) updateCurrencyDataInStorages() error {
-	currentDatetime := time.Now().Format(time.RFC3339)
+// loadCurrencies puts the currencies of the update in memory cache
+// along with the output data, calculated from them. The source is where
+// the currencies have come from before getting in the storage.
+func (a *App) loadCurrencies(ctx context.Context, updateDatetime models.UpdateDatetime, source string) error {
+	// The storages return the empty update, when there are no updates.
+	if updateDatetime.Id == 0 {
+		return storage.ErrNoUpdate
+	}
 
-	var (
-		latestUpdateDatetime models.UpdateDatetime
-		latestCurrencies     models.Currencies
-		isNeedUpdate         bool
-		err                  error
-	)
+	currencies, err := a.storage.GetLatestCurrencies(ctx, updateDatetime.Id)
+	if err != nil {
+		return errlib.Wrap(err, "could not get currencies from db")
+	}
+
+	// The currencies, that are stored before the filter is changed, are
+	// filtered as well.
+	currencies = a.currencyFilter.Apply(currencies)
 
-	log.Info().Msg("checking latest update datetime...")
+	// The cache keeps the previous currencies rather than the empty
+	// ones.
+	if len(currencies.Currencies) == 0 {
+		return errlib.Wrap(ErrNoCurrencies, "could not get currencies of update "+strconv.Itoa(updateDatetime.Id))
+	}
+
+	currencies = a.withBaskets(ctx, currencies)
 
-	latestUpdateDatetime, err = a.service.UpdateDatetime.GetLatest()
+	calculatedCurrencies, err := calculateCurrencies(ctx, currencies)
 	if err != nil {
-		return errlib.Wrap(err, "could not get current update datetime")
+		return errlib.Wrap(err, "could not calculate output data")
 	}
 
-	isNeedUpdate, err = a.timeChecks.IsNeedForUpdateDb(&latestUpdateDatetime)
+	// The update datetime is when the currencies were fetched from the
+	// source, even if they are loaded from the storage now.
+	fetchedAt, err := time.Parse(time.RFC3339, updateDatetime.UpdateDatetime)
 	if err != nil {
-		return errlib.Wrap(err, "could not check is need update for db or not")
+		fetchedAt = a.clock.Now()
 	}
 
-	if isNeedUpdate {
-		log.Info().Msg("data is outdated")
-		log.Info().Msg("initializing update process...")
+	err = a.memCache.SetCurrencies(&updateDatetime, &currencies, calculatedCurrencies, memcache.Metadata{
+		Source:    source,
+		FetchedAt: fetchedAt,
+	})
+	if err != nil {
+		return errlib.Wrap(err, "could not put currencies in memory cache")
+	}
 
-		if latestCurrencies, err = a.parsedDataFromSource(); err != nil {
-			return errlib.Wrap(err, "could not get parsed data from source")
-		}
+	return nil
+}
 
-		log.Info().Msg("saving data...")
+// notifyUpdate tells the other instances, that share the database,
+// about the update, so they reload the data without waiting for their
+// own schedules.
+func (a *App) notifyUpdate(ctx context.Context) {
+	logger := zerolog.Ctx(ctx)
 
-		latestUpdateDatetime, err = a.service.UpdateDatetime.Create(currentDatetime)
-		if err != nil {
-			return errlib.Wrap(err, "could not insert datetime into db")
+	notifier, ok := a.storage.(storage.Notifier)
+	if !ok {
+		logger.Error().Err(storage.ErrNoNotifications).Msg("could not notify about update")
+		return
+	}
+
+	if err := notifier.NotifyUpdate(ctx, a.instanceId); err != nil {
+		logger.Error().Err(err).Msg("could not notify about update")
+	}
+}
+
+// listenUpdates reloads the data from the storage, when the other
+// instance notifies about an update, until the context is canceled.
+func (a *App) listenUpdates(ctx context.Context) {
+	notifier, ok := a.storage.(storage.Notifier)
+	if !ok {
+		a.logger.Error().Err(storage.ErrNoNotifications).Msg("could not listen to updates")
+		return
+	}
+
+	err := notifier.ListenUpdates(ctx, func(sender string) {
+		if sender == a.instanceId {
+			return
 		}
 
-		err = a.service.Currencies.Create(latestCurrencies, latestUpdateDatetime.Id)
-		if err != nil {
-			return errlib.Wrap(err, "could not insert currencies into db")
+		a.logger.Info().Msg("update notification received, reloading data...")
+
+		if err := a.reloadCurrencies(ctx); err != nil {
+			a.logger.Error().Err(err).Msg("could not reload data")
 		}
+	})
+	if err != nil {
+		a.logger.Error().Err(err).Msg("could not listen to updates")
 	}
+}
 
-	latestCurrencies, err = a.service.Currencies.GetLatest(latestUpdateDatetime.Id)
+// reloadCurrencies puts the latest currencies of the storage in memory
+// cache.
+func (a *App) reloadCurrencies(ctx context.Context) error {
+	updateDatetime, err := a.storage.GetLatestUpdateDatetime(ctx)
 	if err != nil {
-		return errlib.Wrap(err, "could not get currencies from db")
+		return errlib.Wrap(err, "could not get current update datetime")
 	}
 
-	a.memCache.SetUpdateDatetime(&latestUpdateDatetime)
-	a.memCache.SetCurrencies(&latestCurrencies)
+	return a.loadCurrencies(ctx, updateDatetime, memcache.SourceDb)
+}
 
-	log.Info().Msg("data is now up to date")
+// sourceName returns the kind of the source, the new currencies are got
+// from.
+func (a *App) sourceName() string {
+	if a.config.IsReadCurrencyDataFromFile {
+		return memcache.SourceFile
+	}
 
-	return nil
+	return memcache.SourceWeb
+}
+
+// newLogger returns the logger, that writes the messages to stderr in
+// the configured format.
+func newLogger(cfg *config.Config) zerolog.Logger {
+	if cfg.LogFormat == config.LogFormatJson {
+		return zerolog.New(os.Stderr).With().Timestamp().Logger()
+	}
+
+	return zerolog.New(zerolog.ConsoleWriter{
+		Out:        os.Stderr,
+		TimeFormat: time.RFC3339,
+	}).With().Timestamp().Logger()
 }
 
-func (a *App) parsedDataFromSource() (models.Currencies, error) {
+// newId returns the random id, e.g. of the instance of the application
+// among the others, that share the database, or of the update cycle.
+func newId() string {
+	id := make([]byte, 8)
+
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// checksum returns the SHA-256 checksum of the data in hex.
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// parsedDataFromSource returns parsed currencies along with the raw
+// data, that was fetched from the source. The data, that are the same
+// as the ones of the latest stored update by the id, are not parsed,
+// and ErrUnchangedData is returned instead. The data of multiple
+// sources are always parsed, as they are reconciled.
+func (a *App) parsedDataFromSource(ctx context.Context, latestUpdateDatetimeId int) (models.Currencies, []byte, error) {
+	logger := zerolog.Ctx(ctx).With().Str("source", a.sourceName()).Logger()
+	ctx = logger.WithContext(ctx)
+
 	var (
 		currencies   models.Currencies
 		currencyData []byte
 		err          error
 	)
 
-	log.Info().Msg("getting new data...")
+	logger.Info().Msg("getting new data...")
 
 	if a.config.IsReadCurrencyDataFromFile {
-		log.Debug().Msg("getting data from local file...")
+		logger.Debug().Msg("getting data from local file...")
 
 		if currencyData, err = a.fsOps.CurrencyData(); err != nil {
-			return currencies, errlib.Wrap(err, "could not get currencies from file")
+			return currencies, nil, errlib.Wrap(err, "could not get currencies from file")
 		}
+	} else if len(a.config.CurrencyExtraSourceUrls) > 0 {
+		logger.Debug().Msg("getting data from multiple sources...")
+
+		return a.parsedDataFromSources(ctx)
 	} else {
-		log.Debug().Msg("getting data from web...")
+		logger.Debug().Msg("getting data from web...")
 
-		if currencyData, err = a.endpoint.CurrenciesFromSource.CurrenciesFromSource(); err != nil {
-			return currencies, errlib.Wrap(err, "could not get curencies from web")
+		if currencyData, err = a.endpoint.CurrenciesFromSource.CurrenciesFromSource(ctx); err != nil {
+			return currencies, nil, errlib.Wrap(err, "could not get curencies from web")
 		}
 	}
 
-	if err = replaceCommasWithDots(currencyData); err != nil {
-		return currencies, errlib.Wrap(err, "could not replace commas in data")
+	if a.isUnchangedData(latestUpdateDatetimeId, currencyData) {
+		return currencies, currencyData, ErrUnchangedData
 	}
 
-	log.Info().Msg("parsing data...")
+	return a.parsedData(ctx, currencyData)
+}
 
-	if currencies, err = a.xmlParser.Parse(currencyData); err != nil {
-		return currencies, errlib.Wrap(err, "could not parse data")
+// isUnchangedData reports, whether the raw currency data are the same
+// as the ones of the stored update by the id. The checksum of the
+// latter is taken from the archive, if it is not known yet, e.g. after
+// the restart.
+func (a *App) isUnchangedData(updateDatetimeId int, currencyData []byte) bool {
+	if !a.config.IsSkipUnchangedCurrencyData || (updateDatetimeId == 0) {
+		return false
 	}
 
-	return currencies, nil
+	if (a.dataChecksumId != updateDatetimeId) && a.config.IsArchiveCurrencyData {
+		archived, err := a.fsOps.ArchivedCurrencyData(updateDatetimeId)
+		if err != nil {
+			return false
+		}
+
+		a.setDataChecksum(updateDatetimeId, archived)
+	}
+
+	return (a.dataChecksumId == updateDatetimeId) && (a.dataChecksum == checksum(currencyData))
 }
 
-func replaceCommasWithDots(data []byte) error {
-	const (
-		startDataIndex = 100
-		charComma      = ','
-		charDot        = '.'
+func (a *App) setDataChecksum(updateDatetimeId int, currencyData []byte) {
+	a.dataChecksum = checksum(currencyData)
+	a.dataChecksumId = updateDatetimeId
+}
+
+// parsedDataFromSources concurrently gets currencies from the main and
+// the extra sources, each of them parsed by the parser of its format,
+// and reconciles them. The sources, that failed, are skipped. The raw
+// data are the reconciled currencies encoded in XML, as they are not
+// of any single source.
+func (a *App) parsedDataFromSources(ctx context.Context) (models.Currencies, []byte, error) {
+	urls := append([]string{a.config.CurrencySourceUrl}, a.config.CurrencyExtraSourceUrls...)
+	formats := []string{a.config.CurrencySourceFormat}
+	parsers := append([]parser.Parser{a.parser}, a.extraParsers...)
+
+	for i := range a.config.CurrencyExtraSourceUrls {
+		formats = append(formats, a.config.ExtraSourceFormat(i))
+	}
+
+	var (
+		sets  = make([]models.Currencies, len(urls))
+		errs  = make([]error, len(urls))
+		group errgroup.Group
 	)
 
-	if data == nil {
-		return errors.New("data is empty")
+	for i, url := range urls {
+		i, url := i, url
+
+		group.Go(func() error {
+			currencyData, err := a.endpoint.CurrenciesFromSource.CurrenciesFromUrl(ctx, url, formats[i])
+			if err != nil {
+				errs[i] = errlib.Wrap(err, "could not get currencies from "+url)
+
+				return nil
+			}
+
+			sets[i], _, errs[i] = a.parsedDataOf(ctx, parsers[i], currencyData)
+
+			return nil
+		})
 	}
 
-	lengthOfData := len(data)
+	_ = group.Wait()
+
+	succeededSets := make([]reconciler.Set, 0, len(urls))
 
-	for i := startDataIndex; i < lengthOfData; i++ {
-		if data[i] == charComma {
-			data[i] = charDot
+	for i := range urls {
+		if errs[i] != nil {
+			zerolog.Ctx(ctx).Warn().Err(errs[i]).Int("sourceIndex", i).Str("sourceUrl", urls[i]).
+				Str("sourceFormat", formats[i]).Msg("source skipped")
+
+			continue
 		}
+
+		succeededSets = append(succeededSets, reconciler.Set{Source: urls[i], Currencies: sets[i]})
+	}
+
+	if len(succeededSets) == 0 {
+		return models.Currencies{}, nil, errlib.Wrap(errors.Join(errs...), "all sources failed")
+	}
+
+	currencies := a.reconciler.Reconcile(ctx, succeededSets)
+
+	currencyData, err := xml.Marshal(currencies)
+	if err != nil {
+		return currencies, nil, errlib.Wrap(err, "could not encode reconciled currencies")
+	}
+
+	return currencies, append([]byte(xml.Header), currencyData...), nil
+}
+
+// parsedData parses the currency data of the main source and returns it
+// along with the parsed currencies.
+func (a *App) parsedData(ctx context.Context, currencyData []byte) (models.Currencies, []byte, error) {
+	return a.parsedDataOf(ctx, a.parser, currencyData)
+}
+
+// parsedDataOf parses the currency data by the parser of the format of
+// them and returns it along with the parsed currencies.
+func (a *App) parsedDataOf(ctx context.Context, p parser.Parser, currencyData []byte) (models.Currencies, []byte, error) {
+	zerolog.Ctx(ctx).Info().Msg("parsing data...")
+
+	currencies, err := p.Parse(currencyData)
+	if err != nil {
+		return currencies, nil, errlib.Wrap(err, "could not parse data")
+	}
+
+	if err = validator.Validate(currencies); err != nil {
+		return currencies, nil, errlib.Wrap(err, "parsed data is invalid")
+	}
+
+	if err = a.checkIso4217(ctx, currencies); err != nil {
+		return currencies, nil, errlib.Wrap(err, "parsed data does not match ISO 4217")
+	}
+
+	// The filtered out currencies are neither stored nor served, but the
+	// raw data is kept as it is.
+	if currencies = a.currencyFilter.Apply(currencies); len(currencies.Currencies) == 0 {
+		return currencies, nil, errlib.Wrap(ErrNoCurrencies, "no currencies are left by the filter")
 	}
 
+	return currencies, currencyData, nil
+}
+
+// checkIso4217 validates the codes of the parsed currencies against
+// ISO 4217. The mismatches are logged and, if the config says so, fail
+// the update, as they are likely caused by a change of the format of
+// the source.
+func (a *App) checkIso4217(ctx context.Context, currencies models.Currencies) error {
+	if a.config.Iso4217Validation == config.Iso4217ValidationOff {
+		return nil
+	}
+
+	// The unknown codes are only warned about even in the reject mode,
+	// as the built-in table is not the full list of ISO 4217.
+	if unknown := validator.UnknownIso4217(currencies); len(unknown) > 0 {
+		zerolog.Ctx(ctx).Warn().Err(&validator.ValidationError{Records: unknown}).
+			Msg("parsed codes are not in ISO 4217 table")
+	}
+
+	err := validator.ValidateIso4217(currencies)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *validator.ValidationError
+	if errors.As(err, &validationErr) {
+		metrics.Iso4217Mismatches.Add(float64(len(validationErr.Records)))
+	}
+
+	if a.config.Iso4217Validation == config.Iso4217ValidationReject {
+		return err
+	}
+
+	zerolog.Ctx(ctx).Warn().Err(err).Msg("parsed codes do not match ISO 4217")
+
+	return nil
+}
+
+// updateMetalDataInStorage gets precious metals quotations from the
+// source and puts the latest of them in memory cache.
+func (a *App) updateMetalDataInStorage(ctx context.Context) error {
+	logger := zerolog.Ctx(ctx)
+
+	logger.Info().Msg("getting metals data...")
+
+	data, err := a.endpoint.MetalsFromSource.MetalsFromSource(ctx)
+	if err != nil {
+		return errlib.Wrap(err, "could not get metals from web")
+	}
+
+	metals, err := a.xmlParser.ParseMetals(data)
+	if err != nil {
+		return errlib.Wrap(err, "could not parse metals data")
+	}
+
+	a.memCache.SetCalculatedMetals(latestMetals(metals))
+
+	logger.Info().Msg("metals data is now up to date")
+
 	return nil
 }
 
-func (a *App) calculateOutputData() error {
-	currencies := a.memCache.Currencies()
+// latestMetals returns the quotations of the latest date in metals.
+// The source sorts the records by date in ascending order.
+func latestMetals(metals models.Metals) []models.CalculatedMetal {
+	calculatedMetals := make([]models.CalculatedMetal, 0, len(metals.Metals))
+
+	count := len(metals.Metals)
+	if count == 0 {
+		return calculatedMetals
+	}
+
+	latestDate := metals.Metals[count-1].Date
+
+	for _, metal := range metals.Metals {
+		if metal.Date != latestDate {
+			continue
+		}
+
+		calculatedMetals = append(calculatedMetals, models.CalculatedMetal{
+			Name: metal.Name(),
+			Code: metal.Code,
+			Date: metal.Date,
+			Buy:  string(metal.Buy),
+			Sell: string(metal.Sell),
+		})
+	}
+
+	return calculatedMetals
+}
+
+// withBaskets returns the currencies along with the baskets, that are
+// calculated from them. The baskets, that can not be calculated, e.g.
+// as a currency of them is missing in the source, are skipped.
+func (a *App) withBaskets(ctx context.Context, currencies models.Currencies) models.Currencies {
+	if len(a.baskets) == 0 {
+		return currencies
+	}
+
+	r, err := rates.Of(currencies)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("baskets skipped")
+
+		return currencies
+	}
+
+	withBaskets := currencies
+	withBaskets.Currencies = make([]models.Currency, 0, len(currencies.Currencies)+len(a.baskets))
+	withBaskets.Currencies = append(withBaskets.Currencies, currencies.Currencies...)
+
+	for _, basket := range a.baskets {
+		currency, err := basket.Currency(r)
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("basket", basket.CharCode).Msg("basket skipped")
+
+			continue
+		}
+
+		withBaskets.Currencies = append(withBaskets.Currencies, currency)
+	}
+
+	return withBaskets
+}
 
+// calculateCurrencies calculates the output data of the currencies.
+func calculateCurrencies(ctx context.Context, currencies models.Currencies) ([]models.CalculatedCurrency, error) {
 	calculatedCurrencies := make(
 		[]models.CalculatedCurrency,
 		0,
@@ -308,56 +1939,41 @@ func (a *App) calculateOutputData() error {
 		err                error
 	)
 
-	log.Info().Msg("calculate output data...")
+	zerolog.Ctx(ctx).Info().Msg("calculate output data...")
 
 	for _, currency := range currencies.Currencies {
-		ratio, err = calculateRatio(currency.Value, currency.Multiplier)
+		ratio, err = calculateRatio(string(currency.UnitValue))
 		if err != nil {
-			return errlib.Wrap(err, "could not calculate currency rate")
+			return nil, errlib.Wrap(err, "could not calculate currency rate")
 		}
 
 		calculatedCurrency.Name = currency.Name
 		calculatedCurrency.CharCode = currency.CharCode
+		calculatedCurrency.UnitValue = string(currency.UnitValue)
 		calculatedCurrency.Ratio = ratio
 
+		// The currencies, that are not in the table, have no metadata.
+		info, _ := iso4217.Lookup(currency.CharCode)
+
+		calculatedCurrency.Symbol = info.Symbol
+		calculatedCurrency.MinorUnits = info.MinorUnits
+		calculatedCurrency.Countries = info.Countries
+
 		calculatedCurrencies = append(calculatedCurrencies, calculatedCurrency)
 	}
 
-	a.memCache.SetCalculatedCurrencies(calculatedCurrencies)
-
-	return nil
+	return calculatedCurrencies, nil
 }
 
-func calculateRatio(currencyValue string, currencyMultiplier int) (string, error) {
-	const (
-		floatBitSize   = 64
-		floatFormat    = 'f'
-		floatPrecision = -1
-	)
-
-	var (
-		value      float64
-		multiplier float64
-		result     float64
-		output     string
-		err        error
-	)
-
-	value, err = strconv.ParseFloat(currencyValue, floatBitSize)
+func calculateRatio(currencyUnitValue string) (string, error) {
+	unitValue, err := decimal.NewFromString(currencyUnitValue)
 	if err != nil {
-		return output, errlib.Wrap(err, "could not parse string to float")
+		return "", errlib.Wrap(err, "could not parse string to decimal")
 	}
 
-	multiplier = float64(currencyMultiplier)
-
-	result = 1 / (value / multiplier)
-
-	output = strconv.FormatFloat(
-		result,
-		floatFormat,
-		floatPrecision,
-		floatBitSize,
-	)
+	if unitValue.IsZero() {
+		return "", errors.New("zero unit value")
+	}
 
-	return output, nil
+	return decimal.NewFromInt(1).Div(unitValue).String(), nil
 }
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/endpoint"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/parser"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
+	"github.com/rs/zerolog"
+)
+
+// The dependencies are set by the options, and the ones, that are not
+// set, are created by the configuration.
+type dependencies struct {
+	source  endpoint.CurrenciesFromSource
+	parser  parser.Parser
+	storage storage.Storage
+	clock   clock.Clock
+	logger  *zerolog.Logger
+}
+
+// An Option sets a dependency of the application instead of the one,
+// that New creates by the configuration.
+type Option func(deps *dependencies)
+
+// WithSource sets the source, the currencies are got from.
+func WithSource(source endpoint.CurrenciesFromSource) Option {
+	return func(deps *dependencies) {
+		deps.source = source
+	}
+}
+
+// WithParser sets the parser of the currency data of the source.
+func WithParser(parser parser.Parser) Option {
+	return func(deps *dependencies) {
+		deps.parser = parser
+	}
+}
+
+// WithStorage sets the storage of the currencies. It is still wrapped
+// to collect the metrics and in the redis cache, if it is enabled.
+func WithStorage(storage storage.Storage) Option {
+	return func(deps *dependencies) {
+		deps.storage = storage
+	}
+}
+
+// WithClock sets the clock, that tells the current time to decide on
+// updates and to date the data.
+func WithClock(clock clock.Clock) Option {
+	return func(deps *dependencies) {
+		deps.clock = clock
+	}
+}
+
+// WithLogger sets the logger of the application.
+func WithLogger(logger zerolog.Logger) Option {
+	return func(deps *dependencies) {
+		deps.logger = &logger
+	}
+}
@@ -0,0 +1,185 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+// messagePrecision is the number of decimal places of the rates and the
+// changes in the messages.
+const messagePrecision = 4
+
+var hundredPercent = decimal.NewFromInt(100)
+
+// An Alert is the rule fired by the change of the rate since the
+// previous update.
+type Alert struct {
+	Rule          Rule            `json:"rule"`
+	Previous      decimal.Decimal `json:"previous"`
+	Current       decimal.Decimal `json:"current"`
+	ChangePercent decimal.Decimal `json:"changePercent"`
+	RateDate      string          `json:"rateDate"`
+	Message       string          `json:"message"`
+}
+
+// An Alerter keeps the rules and sends the alerts, that are fired after
+// the update, to the configured channels. The rules are taken from the
+// config and may be changed while running.
+type Alerter struct {
+	notifiers []notifier
+	mu        sync.RWMutex
+	rules     []Rule
+	nextId    int
+}
+
+func New(cfg *config.Config) (*Alerter, error) {
+	a := &Alerter{
+		notifiers: newNotifiers(cfg),
+		nextId:    1,
+	}
+
+	for _, text := range cfg.AlertRules {
+		rule, err := ParseRule(text)
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not parse alert rule")
+		}
+
+		a.AddRule(rule)
+	}
+
+	return a, nil
+}
+
+// Rules returns the copy of the rules.
+func (a *Alerter) Rules() []Rule {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return append([]Rule(nil), a.rules...)
+}
+
+// AddRule adds the rule, that is valid, and returns it with the id.
+func (a *Alerter) AddRule(rule Rule) Rule {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rule.Id = a.nextId
+	a.nextId++
+
+	a.rules = append(a.rules, rule)
+
+	return rule
+}
+
+// DeleteRule deletes the rule by the id and reports, whether there was
+// such rule.
+func (a *Alerter) DeleteRule(id int) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, rule := range a.rules {
+		if rule.Id == id {
+			a.rules = append(a.rules[:i], a.rules[i+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check sends the alerts of the rules, that are fired by the change of
+// the rates from the previous ones to the current ones, to every
+// channel, and returns the alerts. The channels are tried all, even if
+// some of them fail.
+func (a *Alerter) Check(ctx context.Context, previous *rates.Rates, current *rates.Rates) ([]Alert, error) {
+	alerts := Evaluate(a.Rules(), previous, current)
+	if len(alerts) == 0 {
+		return nil, nil
+	}
+
+	return alerts, a.Send(ctx, alerts)
+}
+
+// Send sends the alerts to every channel. The channels are tried all,
+// even if some of them fail.
+func (a *Alerter) Send(ctx context.Context, alerts []Alert) error {
+	var errs []error
+
+	for _, n := range a.notifiers {
+		if err := n.notify(ctx, alerts); err != nil {
+			errs = append(errs, errlib.Wrap(err, "could not notify by "+n.name()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Evaluate returns the alerts of the rules, that are fired by the change
+// of the rates from the previous ones to the current ones. The rules of
+// the currencies, that are missing in any of the rates, are skipped.
+func Evaluate(rules []Rule, previous *rates.Rates, current *rates.Rates) []Alert {
+	var (
+		alerts   []Alert
+		rateDate string
+		one      = decimal.NewFromInt(1)
+	)
+
+	if !current.Date.IsZero() {
+		rateDate = current.Date.Format(models.RateDateLayout)
+	}
+
+	for _, rule := range rules {
+		previousValue, err := previous.Convert(one, rule.From, rule.To)
+		if (err != nil) || previousValue.IsZero() {
+			continue
+		}
+
+		currentValue, err := current.Convert(one, rule.From, rule.To)
+		if err != nil {
+			continue
+		}
+
+		changePercent := currentValue.Sub(previousValue).Div(previousValue).Mul(hundredPercent)
+
+		var message string
+
+		switch rule.Kind {
+		case KindChange:
+			if changePercent.Abs().GreaterThan(rule.Threshold) {
+				message = rule.Pair() + " changed by " + changePercent.StringFixed(2) + "%"
+			}
+		case KindAbove:
+			if previousValue.LessThan(rule.Threshold) && currentValue.GreaterThanOrEqual(rule.Threshold) {
+				message = rule.Pair() + " rose above " + rule.Threshold.String()
+			}
+		case KindBelow:
+			if previousValue.GreaterThan(rule.Threshold) && currentValue.LessThanOrEqual(rule.Threshold) {
+				message = rule.Pair() + " fell below " + rule.Threshold.String()
+			}
+		}
+
+		if message == "" {
+			continue
+		}
+
+		alerts = append(alerts, Alert{
+			Rule:          rule,
+			Previous:      previousValue.Round(messagePrecision),
+			Current:       currentValue.Round(messagePrecision),
+			ChangePercent: changePercent.Round(messagePrecision),
+			RateDate:      rateDate,
+			Message: message + ": " + previousValue.StringFixed(messagePrecision) +
+				" -> " + currentValue.StringFixed(messagePrecision),
+		})
+	}
+
+	return alerts
+}
@@ -0,0 +1,39 @@
+package alerting
+
+import (
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/shopspring/decimal"
+)
+
+// KindAnomaly is the kind of the alerts, that are fired by the rates,
+// which are held back as anomalous, rather than by the rules.
+const KindAnomaly = "anomaly"
+
+// AnomalyAlerts returns the alerts of the changes of the rates, that
+// exceed the threshold percent, so the rates are held back until the
+// admin accepts them.
+func AnomalyAlerts(changes []rates.Change, threshold decimal.Decimal, rateDate string) []Alert {
+	alerts := make([]Alert, 0, len(changes))
+
+	for _, change := range changes {
+		rule := Rule{
+			From:      change.CharCode,
+			To:        rates.BaseCharCode,
+			Kind:      KindAnomaly,
+			Threshold: threshold,
+		}
+
+		alerts = append(alerts, Alert{
+			Rule:          rule,
+			Previous:      change.Previous.Round(messagePrecision),
+			Current:       change.Current.Round(messagePrecision),
+			ChangePercent: change.ChangePercent.Round(messagePrecision),
+			RateDate:      rateDate,
+			Message: rule.Pair() + " changed by " + change.ChangePercent.StringFixed(2) +
+				"% and is held back as anomalous: " + change.Previous.StringFixed(messagePrecision) +
+				" -> " + change.Current.StringFixed(messagePrecision),
+		})
+	}
+
+	return alerts
+}
@@ -0,0 +1,171 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/tracing"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+const (
+	headerContentType = "Content-Type"
+
+	mimeJson = "application/json"
+
+	emailSubject = "Currency rate alerts"
+)
+
+// A notifier sends the alerts to a channel.
+type notifier interface {
+	name() string
+	notify(ctx context.Context, alerts []Alert) error
+}
+
+// newNotifiers returns the notifiers of the channels, that are set in
+// the config.
+func newNotifiers(cfg *config.Config) []notifier {
+	client := &http.Client{Timeout: cfg.AlertTimeout}
+
+	var notifiers []notifier
+
+	if cfg.AlertWebhookUrl != "" {
+		notifiers = append(notifiers, &webhook{url: cfg.AlertWebhookUrl, client: client})
+	}
+
+	if cfg.AlertTelegramBotToken != "" {
+		notifiers = append(notifiers, &telegram{
+			url:    strings.TrimSuffix(cfg.AlertTelegramApiUrl, "/") + "/bot" + cfg.AlertTelegramBotToken + "/sendMessage",
+			chatId: cfg.AlertTelegramChatId,
+			client: client,
+		})
+	}
+
+	if cfg.AlertSmtpAddress != "" {
+		notifiers = append(notifiers, &email{config: cfg})
+	}
+
+	return notifiers
+}
+
+// A webhook posts the alerts as JSON to the URL.
+type webhook struct {
+	url    string
+	client *http.Client
+}
+
+func (w *webhook) name() string {
+	return "webhook"
+}
+
+func (w *webhook) notify(ctx context.Context, alerts []Alert) error {
+	return postJson(ctx, w.client, w.url, struct {
+		Alerts []Alert `json:"alerts"`
+	}{alerts})
+}
+
+// A telegram sends the alerts as the message of the bot to the chat.
+type telegram struct {
+	url    string
+	chatId string
+	client *http.Client
+}
+
+func (t *telegram) name() string {
+	return "telegram"
+}
+
+func (t *telegram) notify(ctx context.Context, alerts []Alert) error {
+	return postJson(ctx, t.client, t.url, struct {
+		ChatId string `json:"chat_id"`
+		Text   string `json:"text"`
+	}{t.chatId, messageText(alerts)})
+}
+
+// An email sends the alerts as the plain text letter by SMTP.
+type email struct {
+	config *config.Config
+}
+
+func (e *email) name() string {
+	return "email"
+}
+
+func (e *email) notify(_ context.Context, alerts []Alert) error {
+	var auth smtp.Auth
+
+	if e.config.AlertSmtpUsername != "" {
+		// The address is validated by the config.
+		host, _, _ := net.SplitHostPort(e.config.AlertSmtpAddress)
+
+		auth = smtp.PlainAuth("", e.config.AlertSmtpUsername, e.config.AlertSmtpPassword, host)
+	}
+
+	letter := "From: " + e.config.AlertEmailFrom + "\r\n" +
+		"To: " + strings.Join(e.config.AlertEmailTo, ", ") + "\r\n" +
+		"Subject: " + emailSubject + "\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		strings.ReplaceAll(messageText(alerts), "\n", "\r\n") + "\r\n"
+
+	err := smtp.SendMail(e.config.AlertSmtpAddress, auth, e.config.AlertEmailFrom, e.config.AlertEmailTo, []byte(letter))
+	if err != nil {
+		return errlib.Wrap(err, "could not send email")
+	}
+
+	return nil
+}
+
+// messageText returns the messages of the alerts, one per line.
+func messageText(alerts []Alert) string {
+	lines := make([]string, 0, len(alerts))
+
+	for _, alert := range alerts {
+		line := alert.Message
+
+		if alert.RateDate != "" {
+			line += " (rates of " + alert.RateDate + ")"
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func postJson(ctx context.Context, client *http.Client, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return errlib.Wrap(err, "could not encode json")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errlib.Wrap(err, "could not create request")
+	}
+
+	req.Header.Set(headerContentType, mimeJson)
+
+	tracing.Inject(ctx, req.Header)
+
+	res, err := client.Do(req)
+	if err != nil {
+		// The error has the URL, which may have the secret token.
+		return errlib.Wrap(errors.Unwrap(err), "could not send request")
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if (res.StatusCode < http.StatusOK) || (res.StatusCode >= http.StatusMultipleChoices) {
+		return errors.New("unexpected response status " + strconv.Itoa(res.StatusCode))
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package alerting
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+// The kinds of the rules. The change rule fires, when the rate changes
+// more than the threshold percent since the previous update, and the
+// above and below ones fire, when the rate crosses the threshold.
+const (
+	KindChange = "change"
+	KindAbove  = "above"
+	KindBelow  = "below"
+)
+
+var ErrInvalidRule = errors.New("invalid alert rule")
+
+// A Rule is the condition on the rate of the currency from in the
+// currency to, that fires the alert.
+type Rule struct {
+	Id        int             `json:"id"`
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Kind      string          `json:"kind"`
+	Threshold decimal.Decimal `json:"threshold"`
+}
+
+// ParseRule parses the rule of the form PAIR:KIND:THRESHOLD, e.g.
+// USD:change:2 or USD/EUR:above:1.1. The currency to of the pair is the
+// base one, if it is omitted.
+func ParseRule(text string) (Rule, error) {
+	parts := strings.Split(text, ":")
+	if len(parts) != 3 {
+		return Rule{}, errlib.Wrap(ErrInvalidRule, text+": expected PAIR:KIND:THRESHOLD")
+	}
+
+	from, to, _ := strings.Cut(parts[0], "/")
+
+	if to == "" {
+		to = rates.BaseCharCode
+	}
+
+	threshold, err := decimal.NewFromString(parts[2])
+	if err != nil {
+		return Rule{}, errlib.Wrap(ErrInvalidRule, text+": invalid threshold")
+	}
+
+	rule := Rule{
+		From:      strings.ToUpper(from),
+		To:        strings.ToUpper(to),
+		Kind:      parts[1],
+		Threshold: threshold,
+	}
+
+	if err = rule.Validate(); err != nil {
+		return Rule{}, errlib.Wrap(err, text)
+	}
+
+	return rule, nil
+}
+
+// Validate checks the currencies, the kind and the threshold of the
+// rule.
+func (r Rule) Validate() error {
+	if (r.From == "") || (r.To == "") || (r.From == r.To) {
+		return errlib.Wrap(ErrInvalidRule, "two different currencies are required")
+	}
+
+	switch r.Kind {
+	case KindChange, KindAbove, KindBelow:
+	default:
+		return errlib.Wrap(ErrInvalidRule, "kind must be change, above or below")
+	}
+
+	if !r.Threshold.IsPositive() {
+		return errlib.Wrap(ErrInvalidRule, "threshold must be positive")
+	}
+
+	return nil
+}
+
+// Pair returns the pair of the currencies, e.g. USD/RUB.
+func (r Rule) Pair() string {
+	return r.From + "/" + r.To
+}
@@ -0,0 +1,143 @@
+package anomaly
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+// A Held is the data of the source, that are held back, as the rates
+// of them have changed too much since the stored ones. The data are
+// told apart by the checksum of them.
+type Held struct {
+	Checksum   string
+	RateDate   string
+	Changes    []rates.Change
+	DetectedAt time.Time
+}
+
+// A Detector finds the rates, that have changed since the previous
+// update by more than the threshold percent, as they are likely the
+// errors of the source. It keeps the data, that are held back, until
+// the admin accepts them or the source publishes the other ones.
+type Detector struct {
+	threshold decimal.Decimal
+	clock     clock.Clock
+	mu        sync.Mutex
+	held      *Held
+	accepted  string
+}
+
+func New(cfg *config.Config, cl clock.Clock) *Detector {
+	return &Detector{
+		threshold: decimal.NewFromFloat(cfg.AnomalyThresholdPercent),
+		clock:     cl,
+	}
+}
+
+// Threshold returns the percent of the change of the rate, that is
+// anomalous.
+func (d *Detector) Threshold() decimal.Decimal {
+	return d.threshold
+}
+
+// Detect returns the changes of the rates from the previous currencies
+// to the current ones, that exceed the threshold, from the largest one
+// to the smallest one.
+func (d *Detector) Detect(previous models.Currencies, current models.Currencies) ([]rates.Change, error) {
+	previousRates, err := rates.Of(previous)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not get previous rates")
+	}
+
+	currentRates, err := rates.Of(current)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not get current rates")
+	}
+
+	changes := rates.Changes(previousRates, currentRates)
+
+	// The changes are sorted from the largest one.
+	for i, change := range changes {
+		if !change.ChangePercent.Abs().GreaterThan(d.threshold) {
+			return changes[:i], nil
+		}
+	}
+
+	return changes, nil
+}
+
+// Hold holds back the data by the checksum and reports, whether they
+// are the other ones, than those held back already, so the admins are
+// told about them once.
+func (d *Detector) Hold(checksum string, rateDate string, changes []rates.Change) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if (d.held != nil) && (d.held.Checksum == checksum) {
+		return false
+	}
+
+	d.held = &Held{
+		Checksum:   checksum,
+		RateDate:   rateDate,
+		Changes:    changes,
+		DetectedAt: d.clock.Now(),
+	}
+
+	return true
+}
+
+// Held returns the data, that are held back, or nil, if there are none.
+func (d *Detector) Held() *Held {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.held == nil {
+		return nil
+	}
+
+	held := *d.held
+
+	return &held
+}
+
+// Accept lets the data, that are held back, through the next check and
+// returns them, or nil, if there are none.
+func (d *Detector) Accept() *Held {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	held := d.held
+	if held == nil {
+		return nil
+	}
+
+	d.held, d.accepted = nil, held.Checksum
+
+	return held
+}
+
+// IsAccepted reports, whether the data by the checksum are accepted by
+// the admin.
+func (d *Detector) IsAccepted(checksum string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return (d.accepted != "") && (d.accepted == checksum)
+}
+
+// Reset forgets the data, that are held back or accepted, e.g. when the
+// other data are stored.
+func (d *Detector) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.held, d.accepted = nil, ""
+}
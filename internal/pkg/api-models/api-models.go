@@ -0,0 +1,189 @@
+// Package apimodels has the models of the data, that are sent in the
+// responses of the API. They are decoupled from the models of the
+// storage and of the source, so the names of the fields in JSON are
+// stable and documented, and the internal identifiers are never sent.
+package apimodels
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/health"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/quota"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/version"
+	"github.com/shopspring/decimal"
+)
+
+// A Currency is the rate of the currency to the base one per unit of it.
+type Currency struct {
+	Name       string   `json:"name"`
+	CharCode   string   `json:"char_code"`
+	UnitValue  string   `json:"unit_value"`
+	Ratio      string   `json:"ratio"`
+	Symbol     string   `json:"symbol,omitempty"`
+	MinorUnits *int     `json:"minor_units,omitempty"`
+	Countries  []string `json:"countries,omitempty"`
+}
+
+func NewCurrency(currency models.CalculatedCurrency) Currency {
+	return Currency{
+		Name:       currency.Name,
+		CharCode:   currency.CharCode,
+		UnitValue:  currency.UnitValue,
+		Ratio:      currency.Ratio,
+		Symbol:     currency.Symbol,
+		MinorUnits: currency.MinorUnits,
+		Countries:  currency.Countries,
+	}
+}
+
+func NewCurrencies(currencies []models.CalculatedCurrency) []Currency {
+	result := make([]Currency, 0, len(currencies))
+
+	for _, currency := range currencies {
+		result = append(result, NewCurrency(currency))
+	}
+
+	return result
+}
+
+// A Metal is the quotation of the metal on the date.
+type Metal struct {
+	Name string `json:"name"`
+	Code int    `json:"code"`
+	Date string `json:"date"`
+	Buy  string `json:"buy"`
+	Sell string `json:"sell"`
+}
+
+func NewMetals(metals []models.CalculatedMetal) []Metal {
+	result := make([]Metal, 0, len(metals))
+
+	for _, metal := range metals {
+		result = append(result, Metal(metal))
+	}
+
+	return result
+}
+
+// An UpdateDatetime is when the currencies were updated and the date,
+// they are effective on. The update id is an opaque cursor, that the
+// client passes to sync the currencies, which have changed since.
+type UpdateDatetime struct {
+	UpdateId       int    `json:"update_id"`
+	UpdateDatetime string `json:"update_datetime"`
+	RateDate       string `json:"rate_date"`
+}
+
+func NewUpdateDatetime(updateDatetime models.UpdateDatetime) UpdateDatetime {
+	return UpdateDatetime{
+		UpdateId:       updateDatetime.Id,
+		UpdateDatetime: updateDatetime.UpdateDatetime,
+		RateDate:       updateDatetime.RateDate,
+	}
+}
+
+// An AuditEntry is the action of the admin along with the actor, who
+// made it, and the payload of it.
+type AuditEntry struct {
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt string          `json:"created_at"`
+}
+
+func NewAuditEntries(entries []models.AuditEntry) []AuditEntry {
+	result := make([]AuditEntry, 0, len(entries))
+
+	for _, entry := range entries {
+		result = append(result, AuditEntry{
+			Actor:     entry.Actor,
+			Action:    entry.Action,
+			Payload:   entry.Payload,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+
+	return result
+}
+
+// A Change is the absolute and the percentage change of the rate of the
+// currency.
+type Change struct {
+	CharCode      string          `json:"char_code"`
+	Name          string          `json:"name"`
+	Previous      decimal.Decimal `json:"previous"`
+	Current       decimal.Decimal `json:"current"`
+	Change        decimal.Decimal `json:"change"`
+	ChangePercent decimal.Decimal `json:"change_percent"`
+}
+
+func NewChanges(changes []rates.Change) []Change {
+	result := make([]Change, 0, len(changes))
+
+	for _, change := range changes {
+		result = append(result, Change(change))
+	}
+
+	return result
+}
+
+// A Version is the build metadata of the running binary.
+type Version struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+}
+
+func NewVersion(info version.Info) Version {
+	return Version(info)
+}
+
+// A DatabaseStatus is the result of the latest check of the database.
+type DatabaseStatus struct {
+	IsUp      bool      `json:"is_up"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+func NewDatabaseStatus(status health.Status) DatabaseStatus {
+	return DatabaseStatus(status)
+}
+
+// An UpdateFailure is the error of the latest update, that has failed.
+type UpdateFailure struct {
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// A QuotaExceeded is the body of the response to the request, that has
+// exceeded the quota of the API key, with the window of the quota and
+// the seconds to wait until it is reset.
+type QuotaExceeded struct {
+	Message    string    `json:"message"`
+	Window     string    `json:"window"`
+	Limit      int64     `json:"limit"`
+	Remaining  int64     `json:"remaining"`
+	ResetAt    time.Time `json:"reset_at"`
+	RetryAfter int64     `json:"retry_after"`
+}
+
+func NewQuotaExceeded(message string, limit quota.Limit, retryAfter int64) QuotaExceeded {
+	return QuotaExceeded{
+		Message:    message,
+		Window:     limit.Window,
+		Limit:      limit.Quota,
+		Remaining:  limit.Remaining,
+		ResetAt:    limit.ResetAt,
+		RetryAfter: retryAfter,
+	}
+}
+
+// An UnchangedData is the checksum of the data of the source, that were
+// the same as the stored ones at the latest check.
+type UnchangedData struct {
+	Checksum  string    `json:"checksum"`
+	CheckedAt time.Time `json:"checked_at"`
+}
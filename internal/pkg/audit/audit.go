@@ -0,0 +1,100 @@
+// Package audit records the actions of the admins along with the actors,
+// who made them, and the payloads of them.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// The actions, that are recorded.
+const (
+	ActionRefresh            = "refresh"
+	ActionAddAlertRule       = "alert_rule_add"
+	ActionDeleteAlertRule    = "alert_rule_delete"
+	ActionAcceptAnomalies    = "anomalies_accept"
+	ActionEnableMaintenance  = "maintenance_enable"
+	ActionDisableMaintenance = "maintenance_disable"
+)
+
+// ActorAnonymous is the actor of the actions, that are made without
+// authentication.
+const ActorAnonymous = "anonymous"
+
+type actorKey struct{}
+
+// WithActor returns the context of the actions, that are made by the
+// actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the actor of the context, or the anonymous one, if it
+// is not set.
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok {
+		return actor
+	}
+
+	return ActorAnonymous
+}
+
+// A Log records the entries in the storage, so they are kept across the
+// restarts and are shared by the instances.
+type Log struct {
+	config  *config.Config
+	auditor storage.Auditor
+	clock   clock.Clock
+}
+
+func New(cfg *config.Config, au storage.Auditor, cl clock.Clock) *Log {
+	return &Log{
+		config:  cfg,
+		auditor: au,
+		clock:   cl,
+	}
+}
+
+// Record records the action, that is made by the actor of the context,
+// with the payload, that is encoded in JSON and may be nil.
+func (l *Log) Record(ctx context.Context, action string, payload any) (models.AuditEntry, error) {
+	entry := models.AuditEntry{
+		Actor:     Actor(ctx),
+		Action:    action,
+		CreatedAt: l.clock.Now().UTC().Format(time.RFC3339),
+	}
+
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return entry, errlib.Wrap(err, "could not encode audit payload")
+		}
+
+		entry.Payload = data
+	}
+
+	entry, err := l.auditor.InsertAuditEntry(ctx, entry)
+	if err != nil {
+		return entry, errlib.Wrap(err, "could not insert audit entry")
+	}
+
+	return entry, nil
+}
+
+// Entries returns the entries, that are created on the dates of UTC
+// from the first date to the last one inclusive.
+func (l *Log) Entries(ctx context.Context, fromDate string, toDate string) ([]models.AuditEntry, error) {
+	return l.auditor.GetAuditEntries(ctx, fromDate, toDate)
+}
+
+// Today returns the date of today in UTC.
+func (l *Log) Today() string {
+	return l.clock.Now().UTC().Format(models.RateDateLayout)
+}
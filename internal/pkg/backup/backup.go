@@ -0,0 +1,213 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+const (
+	methodPut = "PUT"
+
+	headerAuthorization = "Authorization"
+	headerContentType   = "Content-Type"
+	headerContentSha256 = "X-Amz-Content-Sha256"
+	headerDate          = "X-Amz-Date"
+
+	mimeXml  = "application/xml"
+	mimeJson = "application/json"
+
+	signAlgorithm = "AWS4-HMAC-SHA256"
+	signService   = "s3"
+	signRequest   = "aws4_request"
+	signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+
+	amzDateLayout   = "20060102T150405Z"
+	scopeDateLayout = "20060102"
+	keyDateLayout   = "2006-01-02"
+)
+
+// A Backup uploads snapshots of currency data to an S3-compatible
+// object storage, such as AWS S3 or MinIO.
+type Backup struct {
+	config *config.Config
+	client *http.Client
+	clock  clock.Clock
+}
+
+func New(cfg *config.Config, cl clock.Clock) *Backup {
+	return &Backup{
+		config: cfg,
+		client: new(http.Client),
+		clock:  cl,
+	}
+}
+
+// snapshot is the JSON export of parsed currency data.
+type snapshot struct {
+	UpdateDatetime string            `json:"updateDatetime"`
+	Currencies     []models.Currency `json:"currencies"`
+}
+
+// Upload puts the raw currency data and the JSON export of the parsed
+// currencies into the bucket. Objects of one rate date are grouped under
+// the same key prefix, so the backfilled and the late updates are kept
+// with the other ones of the date.
+func (b *Backup) Upload(updateDatetime models.UpdateDatetime, rawData []byte, currencies models.Currencies) error {
+	jsonData, err := json.Marshal(snapshot{
+		UpdateDatetime: updateDatetime.UpdateDatetime,
+		Currencies:     currencies.Currencies,
+	})
+	if err != nil {
+		return errlib.Wrap(err, "could not marshal snapshot to json")
+	}
+
+	keyDate, err := keyDateOf(updateDatetime, currencies)
+	if err != nil {
+		return errlib.Wrap(err, "could not get date of snapshot")
+	}
+
+	keyPrefix := path.Join(
+		b.config.BackupS3Prefix,
+		keyDate,
+		strconv.Itoa(updateDatetime.Id),
+	)
+
+	if err = b.putObject(keyPrefix+".xml", mimeXml, rawData); err != nil {
+		return errlib.Wrap(err, "could not upload raw currency data")
+	}
+
+	if err = b.putObject(keyPrefix+".json", mimeJson, jsonData); err != nil {
+		return errlib.Wrap(err, "could not upload snapshot")
+	}
+
+	return nil
+}
+
+// keyDateOf returns the rate date of the currencies, or the date of the
+// update in UTC, if the source has not provided the rate date.
+func keyDateOf(updateDatetime models.UpdateDatetime, currencies models.Currencies) (string, error) {
+	if rateDate := currencies.RateDateString(); rateDate != "" {
+		return rateDate, nil
+	}
+
+	datetime, err := time.Parse(time.RFC3339, updateDatetime.UpdateDatetime)
+	if err != nil {
+		return "", errlib.Wrap(err, "could not parse update datetime")
+	}
+
+	return datetime.UTC().Format(keyDateLayout), nil
+}
+
+func (b *Backup) putObject(key string, contentType string, data []byte) error {
+	url, err := url.Parse(b.config.BackupS3Endpoint)
+	if err != nil {
+		return errlib.Wrap(err, "could not parse endpoint url")
+	}
+
+	url.Path = path.Join("/", b.config.BackupS3Bucket, key)
+
+	req, err := http.NewRequest(methodPut, url.String(), bytes.NewReader(data))
+	if err != nil {
+		return errlib.Wrap(err, "could not create request")
+	}
+
+	req.Header.Set(headerContentType, contentType)
+
+	b.sign(req, data, b.clock.Now().UTC())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return errlib.Wrap(err, "could not send request to object storage")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+
+		return fmt.Errorf("object storage responded with status %d: %s", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// sign adds AWS Signature Version 4 headers to the request.
+func (b *Backup) sign(req *http.Request, data []byte, now time.Time) {
+	payloadHash := sha256Hex(data)
+	amzDate := now.Format(amzDateLayout)
+	scopeDate := now.Format(scopeDateLayout)
+
+	req.Header.Set(headerContentSha256, payloadHash)
+	req.Header.Set(headerDate, amzDate)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{
+		scopeDate,
+		b.config.BackupS3Region,
+		signService,
+		signRequest,
+	}, "/")
+
+	stringToSign := strings.Join([]string{
+		signAlgorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+b.config.BackupS3SecretKey), scopeDate)
+	key = hmacSha256(key, b.config.BackupS3Region)
+	key = hmacSha256(key, signService)
+	key = hmacSha256(key, signRequest)
+
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+
+	req.Header.Set(headerAuthorization, fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm,
+		b.config.BackupS3AccessKey,
+		scope,
+		signedHeaders,
+		signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
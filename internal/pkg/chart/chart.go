@@ -0,0 +1,113 @@
+// Package chart renders the line charts of the rates as SVG images,
+// that are embedded in the pages as they are.
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strconv"
+
+	"github.com/shopspring/decimal"
+)
+
+// The sizes of the chart and the margins of the plot inside it, in
+// pixels.
+const (
+	width        = 600
+	height       = 240
+	marginTop    = 32
+	marginBottom = 28
+	marginLeft   = 64
+	marginRight  = 16
+
+	// labelPrecision is the number of decimal places of the values in
+	// the labels.
+	labelPrecision = 4
+)
+
+// A Point is the value on the date.
+type Point struct {
+	Date  string
+	Value decimal.Decimal
+}
+
+// Render returns the SVG image of the line chart of the points, which
+// are in the order of the dates, with the title above it. The points
+// are spaced evenly, as there are no rates on the days off anyway. The
+// points must not be empty.
+func Render(title string, points []Point) []byte {
+	minValue, maxValue := points[0].Value, points[0].Value
+
+	for _, point := range points {
+		minValue = decimal.Min(minValue, point.Value)
+		maxValue = decimal.Max(maxValue, point.Value)
+	}
+
+	low, high := minValue.InexactFloat64(), maxValue.InexactFloat64()
+
+	// The line of the equal values is drawn in the middle.
+	if high == low {
+		low, high = low-1, high+1
+	}
+
+	plotWidth := float64(width - marginLeft - marginRight)
+	plotHeight := float64(height - marginTop - marginBottom)
+
+	x := func(i int) float64 {
+		if len(points) == 1 {
+			return marginLeft + plotWidth/2
+		}
+
+		return marginLeft + plotWidth*float64(i)/float64(len(points)-1)
+	}
+
+	y := func(value decimal.Decimal) float64 {
+		return marginTop + plotHeight*(high-value.InexactFloat64())/(high-low)
+	}
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`,
+		width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/>`, width, height)
+	fmt.Fprintf(&b, `<text x="%d" y="20" font-size="14" font-weight="bold">%s</text>`, marginLeft, html.EscapeString(title))
+
+	// The axes.
+	fmt.Fprintf(&b, `<path d="M%d %d V%d H%d" fill="none" stroke="#999"/>`,
+		marginLeft, marginTop, height-marginBottom, width-marginRight)
+
+	// The labels of the highest and the lowest values and of the first
+	// and the last dates.
+	fmt.Fprintf(&b, `<text x="%d" y="%s" text-anchor="end" fill="#666">%s</text>`,
+		marginLeft-6, formatFloat(y(maxValue)+4), maxValue.StringFixed(labelPrecision))
+	fmt.Fprintf(&b, `<text x="%d" y="%s" text-anchor="end" fill="#666">%s</text>`,
+		marginLeft-6, formatFloat(y(minValue)+4), minValue.StringFixed(labelPrecision))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" fill="#666">%s</text>`,
+		marginLeft, height-8, html.EscapeString(points[0].Date))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end" fill="#666">%s</text>`,
+		width-marginRight, height-8, html.EscapeString(points[len(points)-1].Date))
+
+	b.WriteString(`<polyline fill="none" stroke="#1f77b4" stroke-width="2" stroke-linejoin="round" points="`)
+
+	for i, point := range points {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+
+		b.WriteString(formatFloat(x(i)) + "," + formatFloat(y(point.Value)))
+	}
+
+	b.WriteString(`"/>`)
+
+	last := points[len(points)-1]
+
+	fmt.Fprintf(&b, `<circle cx="%s" cy="%s" r="3" fill="#1f77b4"/>`, formatFloat(x(len(points)-1)), formatFloat(y(last.Value)))
+	b.WriteString(`</svg>`)
+
+	return b.Bytes()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 1, 64)
+}
@@ -0,0 +1,16 @@
+package clock
+
+import "time"
+
+// A Clock tells the current time, so the time can be fixed or moved
+// forward, where it is needed.
+type Clock interface {
+	Now() time.Time
+}
+
+// A System is the clock of the system.
+type System struct{}
+
+func (System) Now() time.Time {
+	return time.Now()
+}
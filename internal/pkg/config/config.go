@@ -1,7 +1,7 @@
 package config
 
 import (
-	"errors"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/mrumyantsev/go-errlib"
@@ -9,30 +9,174 @@ import (
 
 const (
 	EnvPrefix = ""
+
+	SourceFormatCbrXml  = "cbr-xml"
+	SourceFormatCbrJson = "cbr-json"
+	SourceFormatEcbXml  = "ecb-xml"
+
+	DbDriverPostgres = "postgres"
+	DbDriverSqlite   = "sqlite"
+	DbDriverMysql    = "mysql"
+	DbDriverBolt     = "bolt"
+	DbDriverNone     = "none"
+
+	LogFormatConsole = "console"
+	LogFormatJson    = "json"
+
+	Iso4217ValidationOff    = "off"
+	Iso4217ValidationWarn   = "warn"
+	Iso4217ValidationReject = "reject"
+
+	ScopeRead  = "read"
+	ScopeAdmin = "admin"
 )
 
 // A Config is the application configuration structure.
 type Config struct {
-	IsEnableDebugLogs            bool   `envconfig:"ENABLE_DEBUG_LOGS" default:"false"`
-	IsReadCurrencyDataFromFile   bool   `envconfig:"READ_CURRENCIES_FROM_FILE" default:"false"`
-	CurrencySourceUrl            string `envconfig:"CURRENCIES_SOURCE_URL" default:"https://www.cbr.ru/scripts/XML_daily.asp"`
-	CurrencySourceFile           string `envconfig:"CURRENCIES_SOURCE_FILE" default:"currencies.xml"`
-	HttpRequestProtocol          string `envconfig:"HTTP_REQUEST_PROTOCOL" default:"HTTP/2"`
-	FakeUserAgentHeaderValue     string `envconfig:"FAKE_USER_AGENT_HEADER_VALUE" default:"Mozilla/5.0 (X11; Linux x86_64)"`
-	IsUseMultithreadedParsing    bool   `envconfig:"USE_MULTITHREADED_PARSING" default:"true"`
-	TimeWhenNeedToUpdateCurrency string `envconfig:"TIME_WHEN_NEED_TO_UPDATE_CURRENCY" default:"13:30:00"`
-	InitialCurrenciesCapacity    int    `envconfig:"INITIAL_CURRENCIES_CAPACITY" default:"50"`
-
-	DbDriver   string `envconfig:"DB_DRIVER" default:"postgres"`
+	LogLevel                      string   `envconfig:"LOG_LEVEL" default:"debug"`
+	LogFormat                     string   `envconfig:"LOG_FORMAT" default:"console"`
+	IsReadCurrencyDataFromFile    bool     `envconfig:"READ_CURRENCIES_FROM_FILE" default:"false"`
+	CurrencySourceUrl             string   `envconfig:"CURRENCIES_SOURCE_URL" default:"https://www.cbr.ru/scripts/XML_daily.asp"`
+	IsPreferJsonSource            bool     `envconfig:"PREFER_JSON_SOURCE" default:"false"`
+	CurrencyJsonSourceUrl         string   `envconfig:"CURRENCIES_JSON_SOURCE_URL" default:""`
+	CurrencySourceFormat          string   `envconfig:"CURRENCIES_SOURCE_FORMAT" default:"cbr-xml"`
+	CurrencySourceFile            string   `envconfig:"CURRENCIES_SOURCE_FILE" default:"currencies.xml"`
+	CurrencyExtraSourceUrls       []string `envconfig:"CURRENCIES_EXTRA_SOURCE_URLS" default:""`
+	CurrencyExtraSourceFormats    []string `envconfig:"CURRENCIES_EXTRA_SOURCE_FORMATS" default:""`
+	SourcesReconcilePolicy        string   `envconfig:"SOURCES_RECONCILE_POLICY" default:"primary"`
+	SourcesDiscrepancyTolerance   float64  `envconfig:"SOURCES_DISCREPANCY_TOLERANCE" default:"0.5"`
+	MetalSourceUrl                string   `envconfig:"METALS_SOURCE_URL" default:"https://www.cbr.ru/scripts/xml_metall.asp"`
+	MetalSourceDaysRange          int      `envconfig:"METALS_SOURCE_DAYS_RANGE" default:"7"`
+	IsArchiveCurrencyData         bool     `envconfig:"ARCHIVE_CURRENCY_DATA" default:"true"`
+	ArchiveDir                    string   `envconfig:"ARCHIVE_DIR" default:"archive"`
+	IsSkipUnchangedCurrencyData   bool     `envconfig:"SKIP_UNCHANGED_CURRENCY_DATA" default:"true"`
+	SnapshotsDir                  string   `envconfig:"SNAPSHOTS_DIR" default:"snapshots"`
+	SourceMaxResponseSize         int64    `envconfig:"SOURCE_MAX_RESPONSE_SIZE" default:"1048576"`
+	HttpRequestProtocol           string   `envconfig:"HTTP_REQUEST_PROTOCOL" default:"HTTP/2"`
+	FakeUserAgentHeaderValue      string   `envconfig:"FAKE_USER_AGENT_HEADER_VALUE" default:"Mozilla/5.0 (X11; Linux x86_64)"`
+	TimesWhenNeedToUpdateCurrency []string `envconfig:"TIME_WHEN_NEED_TO_UPDATE_CURRENCY" default:"13:30:00"`
+	UpdateCron                    string   `envconfig:"UPDATE_CRON" default:""`
+	UpdateTimezone                string   `envconfig:"UPDATE_TIMEZONE" default:"Europe/Moscow"`
+	NonPublishingWeekdays         []string `envconfig:"NON_PUBLISHING_WEEKDAYS" default:"Saturday,Sunday"`
+	Holidays                      []string `envconfig:"HOLIDAYS" default:""`
+	InitialCurrenciesCapacity     int      `envconfig:"INITIAL_CURRENCIES_CAPACITY" default:"50"`
+	CurrencyWhitelist             []string `envconfig:"CURRENCIES_WHITELIST" default:""`
+	CurrencyBlacklist             []string `envconfig:"CURRENCIES_BLACKLIST" default:""`
+	CurrencyBaskets               []string `envconfig:"CURRENCY_BASKETS" default:""`
+
+	SourceTimeout time.Duration `envconfig:"SOURCE_TIMEOUT" default:"30s"`
+
+	MemCacheHistorySize int           `envconfig:"MEM_CACHE_HISTORY_SIZE" default:"10"`
+	StaleDataAge        time.Duration `envconfig:"STALE_DATA_AGE" default:"36h"`
+	LongPollMaxTimeout  time.Duration `envconfig:"LONG_POLL_MAX_TIMEOUT" default:"2m"`
+
+	AnomalyThresholdPercent float64 `envconfig:"ANOMALY_THRESHOLD_PERCENT" default:"0"`
+	Iso4217Validation       string  `envconfig:"ISO4217_VALIDATION" default:"warn"`
+
+	IsMaintenanceMode     bool          `envconfig:"MAINTENANCE_MODE" default:"false"`
+	MaintenanceRetryAfter time.Duration `envconfig:"MAINTENANCE_RETRY_AFTER" default:"5m"`
+
+	ConvertPrecision int    `envconfig:"CONVERT_PRECISION" default:"4"`
+	ConvertRounding  string `envconfig:"CONVERT_ROUNDING" default:"half-up"`
+
+	ConvertMarkupPercent  float64            `envconfig:"CONVERT_MARKUP_PERCENT" default:"0"`
+	ConvertMarkupsPercent map[string]float64 `envconfig:"CONVERT_MARKUPS_PERCENT" default:""`
+
+	UpdateRetryMinInterval time.Duration `envconfig:"UPDATE_RETRY_MIN_INTERVAL" default:"10m"`
+	UpdateRetryMaxInterval time.Duration `envconfig:"UPDATE_RETRY_MAX_INTERVAL" default:"2h"`
+	CatchUpMaxDays         int           `envconfig:"CATCH_UP_MAX_DAYS" default:"31"`
+	UpdateJitter           time.Duration `envconfig:"UPDATE_JITTER" default:"0"`
+
+	IsEnableBackup    bool   `envconfig:"ENABLE_BACKUP" default:"false"`
+	BackupS3Endpoint  string `envconfig:"BACKUP_S3_ENDPOINT" default:"http://localhost:9000"`
+	BackupS3Region    string `envconfig:"BACKUP_S3_REGION" default:"us-east-1"`
+	BackupS3Bucket    string `envconfig:"BACKUP_S3_BUCKET" default:"currency-converter"`
+	BackupS3Prefix    string `envconfig:"BACKUP_S3_PREFIX" default:"snapshots"`
+	BackupS3AccessKey string `envconfig:"BACKUP_S3_ACCESS_KEY" default:"" secret:"true"`
+	BackupS3SecretKey string `envconfig:"BACKUP_S3_SECRET_KEY" default:"" secret:"true"`
+
+	DbDriver   string `envconfig:"DB_DRIVER" default:""`
 	DbHostname string `envconfig:"DB_HOSTNAME" default:"localhost"`
 	DbPort     string `envconfig:"DB_PORT" default:"5432"`
 	DbUsername string `envconfig:"DB_USERNAME" default:"postgres"`
-	DbPassword string `envconfig:"DB_PASSWORD" default:""`
+	DbPassword string `envconfig:"DB_PASSWORD" default:"" secret:"true"`
 	DbDatabase string `envconfig:"DB_DATABASE" default:"currency_storage"`
 	DbSSLMode  string `envconfig:"DB_SSLMODE" default:"disable"`
 
+	DbMaxOpenConns        int           `envconfig:"DB_MAX_OPEN_CONNS" default:"10"`
+	DbMaxIdleConns        int           `envconfig:"DB_MAX_IDLE_CONNS" default:"5"`
+	DbConnMaxLifetime     time.Duration `envconfig:"DB_CONN_MAX_LIFETIME" default:"1h"`
+	DbConnMaxIdleTime     time.Duration `envconfig:"DB_CONN_MAX_IDLE_TIME" default:"15m"`
+	DbQueryTimeout        time.Duration `envconfig:"DB_QUERY_TIMEOUT" default:"10s"`
+	DbPingTimeout         time.Duration `envconfig:"DB_PING_TIMEOUT" default:"5s"`
+	DbSlowQueryThreshold  time.Duration `envconfig:"DB_SLOW_QUERY_THRESHOLD" default:"500ms"`
+	DbHealthCheckInterval time.Duration `envconfig:"DB_HEALTH_CHECK_INTERVAL" default:"15s"`
+	DbReconnectMinBackoff time.Duration `envconfig:"DB_RECONNECT_MIN_BACKOFF" default:"1s"`
+	DbReconnectMaxBackoff time.Duration `envconfig:"DB_RECONNECT_MAX_BACKOFF" default:"1m"`
+	IsNotifyUpdates       bool          `envconfig:"NOTIFY_UPDATES" default:"false"`
+	IsMigrateOnStartup    bool          `envconfig:"MIGRATE_ON_STARTUP" default:"true"`
+	DbSqliteFile          string        `envconfig:"DB_SQLITE_FILE" default:"./save/currency_storage.db"`
+	DbBoltFile            string        `envconfig:"DB_BOLT_FILE" default:"./save/currency_storage.bolt"`
+	RetentionDays         int           `envconfig:"RETENTION_DAYS" default:"0"`
+	PruneInterval         time.Duration `envconfig:"PRUNE_INTERVAL" default:"24h"`
+
+	IsEnableRedisCache bool          `envconfig:"ENABLE_REDIS_CACHE" default:"false"`
+	RedisAddress       string        `envconfig:"REDIS_ADDRESS" default:"localhost:6379"`
+	RedisPassword      string        `envconfig:"REDIS_PASSWORD" default:"" secret:"true"`
+	RedisDb            int           `envconfig:"REDIS_DB" default:"0"`
+	RedisKeyPrefix     string        `envconfig:"REDIS_KEY_PREFIX" default:"currency-converter"`
+	RedisCacheTtl      time.Duration `envconfig:"REDIS_CACHE_TTL" default:"1h"`
+
+	IsEnableAlerts        bool          `envconfig:"ENABLE_ALERTS" default:"false"`
+	AlertRules            []string      `envconfig:"ALERT_RULES" default:""`
+	IsEnableAlertsApi     bool          `envconfig:"ENABLE_ALERTS_API" default:"false"`
+	AlertTimeout          time.Duration `envconfig:"ALERT_TIMEOUT" default:"10s"`
+	AlertWebhookUrl       string        `envconfig:"ALERT_WEBHOOK_URL" default:""`
+	AlertTelegramApiUrl   string        `envconfig:"ALERT_TELEGRAM_API_URL" default:"https://api.telegram.org"`
+	AlertTelegramBotToken string        `envconfig:"ALERT_TELEGRAM_BOT_TOKEN" default:"" secret:"true"`
+	AlertTelegramChatId   string        `envconfig:"ALERT_TELEGRAM_CHAT_ID" default:""`
+	AlertSmtpAddress      string        `envconfig:"ALERT_SMTP_ADDRESS" default:""`
+	AlertSmtpUsername     string        `envconfig:"ALERT_SMTP_USERNAME" default:""`
+	AlertSmtpPassword     string        `envconfig:"ALERT_SMTP_PASSWORD" default:"" secret:"true"`
+	AlertEmailFrom        string        `envconfig:"ALERT_EMAIL_FROM" default:""`
+	AlertEmailTo          []string      `envconfig:"ALERT_EMAIL_TO" default:""`
+
+	ApiKeys             map[string]string `envconfig:"API_KEYS" default:"" secret:"true"`
+	AdminApiKey         string            `envconfig:"ADMIN_API_KEY" default:"" secret:"true"`
+	ApiKeyScopes        map[string]string `envconfig:"API_KEY_SCOPES" default:""`
+	ApiKeyDailyQuota    int64             `envconfig:"API_KEY_DAILY_QUOTA" default:"0"`
+	ApiKeyMonthlyQuota  int64             `envconfig:"API_KEY_MONTHLY_QUOTA" default:"0"`
+	ApiKeyDailyQuotas   map[string]int64  `envconfig:"API_KEY_DAILY_QUOTAS" default:""`
+	ApiKeyMonthlyQuotas map[string]int64  `envconfig:"API_KEY_MONTHLY_QUOTAS" default:""`
+	UsageFlushInterval  time.Duration     `envconfig:"USAGE_FLUSH_INTERVAL" default:"10s"`
+
+	OidcIssuerUrl   string            `envconfig:"OIDC_ISSUER_URL" default:""`
+	OidcClientId    string            `envconfig:"OIDC_CLIENT_ID" default:""`
+	OidcGroupsClaim string            `envconfig:"OIDC_GROUPS_CLAIM" default:"groups"`
+	OidcGroupScopes map[string]string `envconfig:"OIDC_GROUP_SCOPES" default:""`
+	OidcTimeout     time.Duration     `envconfig:"OIDC_TIMEOUT" default:"10s"`
+
+	ResponseSigningKey string `envconfig:"RESPONSE_SIGNING_KEY" default:"" secret:"true"`
+
+	IdempotencyKeyTtl      time.Duration `envconfig:"IDEMPOTENCY_KEY_TTL" default:"24h"`
+	IdempotencyMaxBodySize int64         `envconfig:"IDEMPOTENCY_MAX_BODY_SIZE" default:"1048576"`
+
 	HttpServerListenIp   string `envconfig:"HTTP_SERVER_LISTEN_IP" default:"0.0.0.0"`
 	HttpServerListenPort string `envconfig:"HTTP_SERVER_LISTEN_PORT" default:"8080"`
+
+	TlsCertFile         string   `envconfig:"TLS_CERT_FILE" default:""`
+	TlsKeyFile          string   `envconfig:"TLS_KEY_FILE" default:""`
+	TlsClientCaFile     string   `envconfig:"TLS_CLIENT_CA_FILE" default:""`
+	TlsClientAllowedCns []string `envconfig:"TLS_CLIENT_ALLOWED_CNS" default:""`
+
+	ApiIpAllowlist   []string `envconfig:"API_IP_ALLOWLIST" default:""`
+	ApiIpDenylist    []string `envconfig:"API_IP_DENYLIST" default:""`
+	AdminIpAllowlist []string `envconfig:"ADMIN_IP_ALLOWLIST" default:""`
+	AdminIpDenylist  []string `envconfig:"ADMIN_IP_DENYLIST" default:""`
+
+	// fileVars are the names of the variables, that are taken from the
+	// config file, so they can be taken again on reload.
+	fileVars []string
 }
 
 // New creates an application configuration.
@@ -40,15 +184,67 @@ func New() *Config {
 	return &Config{}
 }
 
-// Init initializes application configuration.
-func (c *Config) Init() error {
+// Init initializes application configuration from the config file, the
+// environment and the flags, which may be nil.
+func (c *Config) Init(flags *Flags) error {
+	if flags == nil {
+		flags = new(Flags)
+	}
+
+	if flags.ConfigFile != "" {
+		var err error
+
+		c.fileVars, err = loadFile(flags.ConfigFile)
+		if err != nil {
+			return errlib.Wrap(err, "could not load config file")
+		}
+	}
+
 	if err := envconfig.Process(EnvPrefix, c); err != nil {
 		return errlib.Wrap(err, "could not populate config structure")
 	}
 
-	if c.DbPassword == "" {
-		return errors.New("no database password specified")
+	if err := c.loadSecretFiles(); err != nil {
+		return errlib.Wrap(err, "could not load secrets")
+	}
+
+	if err := c.applyFlags(flags); err != nil {
+		return errlib.Wrap(err, "could not apply flags")
 	}
 
-	return nil
+	// The JSON source replaces the configured one, as it does not
+	// need charset decoding and comma handling. CBR publishes no JSON,
+	// so the source is a mirror, that must be chosen explicitly.
+	if c.IsPreferJsonSource && (c.CurrencyJsonSourceUrl != "") {
+		c.CurrencySourceUrl = c.CurrencyJsonSourceUrl
+		c.CurrencySourceFormat = SourceFormatCbrJson
+	}
+
+	// Without a database the snapshots are kept in files. The driver,
+	// that is not set, is postgres, when the connection is configured
+	// by the password, as before the drivers were added.
+	if c.DbDriver == "" {
+		if c.DbPassword != "" {
+			c.DbDriver = DbDriverPostgres
+		} else {
+			c.DbDriver = DbDriverNone
+		}
+	}
+
+	return c.validate()
+}
+
+// ExtraSourceFormat returns the format of the extra currency source by
+// the index, that is the format of the main source, unless it is set.
+func (c *Config) ExtraSourceFormat(i int) string {
+	if (i < len(c.CurrencyExtraSourceFormats)) && (c.CurrencyExtraSourceFormats[i] != "") {
+		return c.CurrencyExtraSourceFormats[i]
+	}
+
+	return c.CurrencySourceFormat
+}
+
+// IsTls reports, whether the server listens over HTTPS.
+func (c *Config) IsTls() bool {
+	return c.TlsCertFile != ""
 }
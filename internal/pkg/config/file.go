@@ -0,0 +1,63 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// loadFile puts the variables of the config file in the environment,
+// unless they are set there already, and returns the names of the set
+// ones. The file has the same format as the .env file: a
+// VARIABLE=value pair per line, empty lines and lines starting with #
+// are skipped.
+func loadFile(path string) (names []string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not open config file")
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if (line == "") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			return names, errors.New("invalid line " + strconv.Itoa(lineNum) + " of config file")
+		}
+
+		name = strings.TrimSpace(name)
+
+		if _, ok = os.LookupEnv(name); ok {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		if err = os.Setenv(name, value); err != nil {
+			return names, errlib.Wrap(err, "could not set "+name)
+		}
+
+		names = append(names, name)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return names, errlib.Wrap(err, "could not read config file")
+	}
+
+	return names, nil
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// redacted replaces the values of the secrets in the printed
+// configuration.
+const redacted = "******"
+
+// Print writes the effective configuration in the format of the config
+// file, so it can be seen, what the environment, the config file and
+// the flags have resulted in. The values of the fields, that are tagged
+// as secret, and the passwords in URLs are redacted.
+func (c *Config) Print(w io.Writer) error {
+	value := reflect.ValueOf(c).Elem()
+	typ := value.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		name := field.Tag.Get("envconfig")
+		if name == "" {
+			continue
+		}
+
+		text := formatValue(value.Field(i))
+
+		if (text != "") && (field.Tag.Get("secret") == "true") {
+			text = redacted
+		} else {
+			text = redactUrl(text)
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", EnvPrefix+name, text); err != nil {
+			return errlib.Wrap(err, "could not write configuration")
+		}
+	}
+
+	return nil
+}
+
+// formatValue formats the value the way envconfig parses it, so the
+// items of the slices and the maps are separated by commas, and the
+// keys of the maps are separated from the values by colons.
+func formatValue(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.Slice:
+		items := make([]string, value.Len())
+
+		for i := range items {
+			items[i] = fmt.Sprint(value.Index(i).Interface())
+		}
+
+		return strings.Join(items, ",")
+	case reflect.Map:
+		items := make([]string, 0, value.Len())
+
+		iter := value.MapRange()
+
+		for iter.Next() {
+			items = append(items, fmt.Sprint(iter.Key().Interface())+":"+fmt.Sprint(iter.Value().Interface()))
+		}
+
+		// The order of the map is random.
+		sort.Strings(items)
+
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
+
+// redactUrl redacts the password of the text, if it is a URL with one.
+func redactUrl(text string) string {
+	parsedUrl, err := url.Parse(text)
+	if (err != nil) || (parsedUrl.User == nil) {
+		return text
+	}
+
+	if _, ok := parsedUrl.User.Password(); !ok {
+		return text
+	}
+
+	// The password is set after formatting, as the URL would escape it.
+	parsedUrl.User = url.UserPassword(parsedUrl.User.Username(), "")
+
+	return strings.Replace(parsedUrl.String(), "@", redacted+"@", 1)
+}
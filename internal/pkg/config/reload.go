@@ -0,0 +1,104 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// reloadDelay is the time to wait for the config file to be written
+// completely, as editors change it with several writes.
+const reloadDelay = 500 * time.Millisecond
+
+// Reload initializes a new configuration with the current contents of
+// the config file and returns it. The configuration itself is not
+// changed, as it is in use, and the new one is to be applied partly,
+// see IsRestartNeeded.
+func (c *Config) Reload(flags *Flags) (*Config, error) {
+	// The variables of the file are unset, otherwise they would be
+	// taken for the environment, that overrides the file.
+	unsetVars(c.fileVars)
+
+	cfg := New()
+
+	err := cfg.Init(flags)
+
+	c.fileVars = cfg.fileVars
+
+	if err != nil {
+		unsetVars(c.fileVars)
+		c.fileVars = nil
+
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// IsRestartNeeded reports, whether the next configuration differs from
+// this one in the settings, that take effect only after restart. Only
+// the log level and the maintenance mode are applied without restart.
+func (c *Config) IsRestartNeeded(next *Config) bool {
+	current, reloaded := *c, *next
+
+	reloaded.LogLevel = current.LogLevel
+	reloaded.IsMaintenanceMode = current.IsMaintenanceMode
+	current.fileVars, reloaded.fileVars = nil, nil
+
+	return !reflect.DeepEqual(current, reloaded)
+}
+
+// Watch calls the function every time the config file is changed,
+// until the context is canceled. The directory of the file is watched,
+// as editors often replace the file instead of writing it.
+func Watch(ctx context.Context, path string, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errlib.Wrap(err, "could not create file watcher")
+	}
+	defer func() { _ = watcher.Close() }()
+
+	if err = watcher.Add(filepath.Dir(path)); err != nil {
+		return errlib.Wrap(err, "could not watch config file directory")
+	}
+
+	name := filepath.Clean(path)
+
+	var delay <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if (filepath.Clean(event.Name) == name) && (event.Op&(fsnotify.Write|fsnotify.Create) != 0) {
+				delay = time.After(reloadDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			return errlib.Wrap(err, "could not watch config file")
+		case <-delay:
+			delay = nil
+
+			onChange()
+		}
+	}
+}
+
+func unsetVars(names []string) {
+	for _, name := range names {
+		_ = os.Unsetenv(name)
+	}
+}
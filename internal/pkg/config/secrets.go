@@ -0,0 +1,53 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// secretFileSuffix is appended to the name of the variable of a secret
+// to get the name of the variable with the path to the secret file,
+// as it is done for Docker and Kubernetes secrets.
+const secretFileSuffix = "_FILE"
+
+// loadSecretFiles reads the secrets from the files, that are specified
+// in the variables like DB_PASSWORD_FILE, so the plain values are not
+// needed to be set in the environment.
+func (c *Config) loadSecretFiles() error {
+	secrets := []struct {
+		env   string
+		value *string
+	}{
+		{"DB_USERNAME", &c.DbUsername},
+		{"DB_PASSWORD", &c.DbPassword},
+		{"REDIS_PASSWORD", &c.RedisPassword},
+		{"BACKUP_S3_ACCESS_KEY", &c.BackupS3AccessKey},
+		{"BACKUP_S3_SECRET_KEY", &c.BackupS3SecretKey},
+	}
+
+	for _, secret := range secrets {
+		fileEnv := EnvPrefix + secret.env + secretFileSuffix
+
+		path, ok := os.LookupEnv(fileEnv)
+		if !ok || (path == "") {
+			continue
+		}
+
+		if _, ok = os.LookupEnv(EnvPrefix + secret.env); ok {
+			return errors.New("both " + secret.env + " and " + fileEnv + " specified")
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return errlib.Wrap(err, "could not read secret file of "+fileEnv)
+		}
+
+		// Secret files are usually written with a trailing newline.
+		*secret.value = strings.TrimRight(string(data), "\r\n")
+	}
+
+	return nil
+}
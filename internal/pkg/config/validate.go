@@ -0,0 +1,471 @@
+package config
+
+import (
+	"database/sql"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	ipfilter "github.com/mrumyantsev/currency-converter-app/internal/pkg/ip-filter"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/iso4217"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/signer"
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog"
+)
+
+const (
+	minPort = 1
+	maxPort = 65535
+
+	minApiKeyLength  = 16
+	maxKeyNameLength = 64
+)
+
+// problems are the errors of the configuration, each of which names
+// the variable to fix.
+type problems []error
+
+func (p *problems) add(env string, msg string) {
+	*p = append(*p, errors.New(env+": "+msg))
+}
+
+// check adds the problem, if the condition is not met.
+func (p *problems) check(isOk bool, env string, msg string) {
+	if !isOk {
+		p.add(env, msg)
+	}
+}
+
+// validate checks the whole configuration and reports all problems at
+// once, so they can be fixed before the next start.
+func (c *Config) validate() error {
+	var p problems
+
+	_, err := zerolog.ParseLevel(c.LogLevel)
+	p.check(err == nil, "LOG_LEVEL", "must be one of trace, debug, info, warn or error")
+	p.check((c.LogFormat == LogFormatConsole) || (c.LogFormat == LogFormatJson), "LOG_FORMAT",
+		"must be console or json")
+
+	c.validateSource(&p)
+	c.validateSchedule(&p)
+	c.validateDatabase(&p)
+
+	p.check(isPort(c.HttpServerListenPort), "HTTP_SERVER_LISTEN_PORT",
+		"must be a port number from 1 to 65535")
+	p.check((c.HttpServerListenIp == "") || (net.ParseIP(c.HttpServerListenIp) != nil), "HTTP_SERVER_LISTEN_IP",
+		"must be an IP address")
+	p.check((c.TlsCertFile == "") == (c.TlsKeyFile == ""), "TLS_CERT_FILE, TLS_KEY_FILE",
+		"must be set together")
+	p.check((c.TlsClientCaFile == "") || c.IsTls(), "TLS_CLIENT_CA_FILE",
+		"must be set along with TLS_CERT_FILE")
+	p.check((len(c.TlsClientAllowedCns) == 0) || (c.TlsClientCaFile != ""), "TLS_CLIENT_ALLOWED_CNS",
+		"must be set along with TLS_CLIENT_CA_FILE")
+
+	for env, entries := range map[string][]string{
+		"API_IP_ALLOWLIST":   c.ApiIpAllowlist,
+		"API_IP_DENYLIST":    c.ApiIpDenylist,
+		"ADMIN_IP_ALLOWLIST": c.AdminIpAllowlist,
+		"ADMIN_IP_DENYLIST":  c.AdminIpDenylist,
+	} {
+		for _, entry := range entries {
+			_, err := ipfilter.ParsePrefix(entry)
+			p.check(err == nil, env, "must be IP addresses or networks in CIDR notation, got "+entry)
+		}
+	}
+	p.check(c.InitialCurrenciesCapacity >= 0, "INITIAL_CURRENCIES_CAPACITY", "must not be negative")
+	p.check(c.MemCacheHistorySize > 0, "MEM_CACHE_HISTORY_SIZE", "must be positive")
+	p.check(c.StaleDataAge > 0, "STALE_DATA_AGE", "must be positive")
+	p.check(c.LongPollMaxTimeout > 0, "LONG_POLL_MAX_TIMEOUT", "must be positive")
+	p.check(c.AnomalyThresholdPercent >= 0, "ANOMALY_THRESHOLD_PERCENT", "must not be negative")
+	p.check((c.Iso4217Validation == Iso4217ValidationOff) || (c.Iso4217Validation == Iso4217ValidationWarn) ||
+		(c.Iso4217Validation == Iso4217ValidationReject), "ISO4217_VALIDATION", "must be off, warn or reject")
+	p.check(c.IdempotencyKeyTtl > 0, "IDEMPOTENCY_KEY_TTL", "must be positive")
+	p.check(c.IdempotencyMaxBodySize > 0, "IDEMPOTENCY_MAX_BODY_SIZE", "must be positive")
+	p.check(c.MaintenanceRetryAfter >= time.Second, "MAINTENANCE_RETRY_AFTER", "must be at least 1s")
+	p.check((c.ConvertPrecision >= 0) && (c.ConvertPrecision <= rates.MaxPrecision), "CONVERT_PRECISION",
+		"must be from 0 to "+strconv.Itoa(rates.MaxPrecision))
+	p.check(rates.IsRounding(c.ConvertRounding), "CONVERT_ROUNDING",
+		"must be one of half-up, half-even, bankers, down or up")
+	p.check(isMarkup(c.ConvertMarkupPercent), "CONVERT_MARKUP_PERCENT", "must be from 0 to 100, excluding 100")
+
+	for charCode, markup := range c.ConvertMarkupsPercent {
+		p.check(isMarkup(markup), "CONVERT_MARKUPS_PERCENT", "markup of "+charCode+" must be from 0 to 100, excluding 100")
+	}
+
+	if c.IsEnableRedisCache {
+		p.check(c.RedisAddress != "", "REDIS_ADDRESS", "must be set, when the redis cache is enabled")
+		p.check(c.RedisCacheTtl > 0, "REDIS_CACHE_TTL", "must be positive")
+	}
+
+	if c.IsEnableBackup {
+		p.check(isHttpUrl(c.BackupS3Endpoint), "BACKUP_S3_ENDPOINT", "must be an http or https URL")
+		p.check(c.BackupS3Bucket != "", "BACKUP_S3_BUCKET", "must be set, when the backup is enabled")
+		p.check((c.BackupS3AccessKey != "") && (c.BackupS3SecretKey != ""), "BACKUP_S3_ACCESS_KEY, BACKUP_S3_SECRET_KEY",
+			"must be set, when the backup is enabled")
+	}
+
+	if c.IsEnableAlerts {
+		c.validateAlerts(&p)
+	}
+
+	c.validateApiKeys(&p)
+
+	if c.OidcIssuerUrl != "" {
+		c.validateOidc(&p)
+	}
+
+	if c.ResponseSigningKey != "" {
+		if _, err := signer.ParseKey(c.ResponseSigningKey); err != nil {
+			p.add("RESPONSE_SIGNING_KEY", err.Error())
+		}
+	}
+
+	if len(p) > 0 {
+		return errors.Join(append([]error{errors.New("invalid configuration")}, p...)...)
+	}
+
+	return nil
+}
+
+func (c *Config) validateSource(p *problems) {
+	if c.IsReadCurrencyDataFromFile {
+		p.check(c.CurrencySourceFile != "", "CURRENCIES_SOURCE_FILE", "must be set, when reading currencies from file")
+		p.check(len(c.CurrencyExtraSourceUrls) == 0, "CURRENCIES_EXTRA_SOURCE_URLS",
+			"must not be set, when reading currencies from file")
+	} else if c.IsPreferJsonSource {
+		p.check(isHttpUrl(c.CurrencyJsonSourceUrl), "CURRENCIES_JSON_SOURCE_URL",
+			"must be an http or https URL, when PREFER_JSON_SOURCE is set")
+	} else {
+		p.check(isHttpUrl(c.CurrencySourceUrl), "CURRENCIES_SOURCE_URL", "must be an http or https URL")
+	}
+
+	for _, extraUrl := range c.CurrencyExtraSourceUrls {
+		p.check(isHttpUrl(extraUrl), "CURRENCIES_EXTRA_SOURCE_URLS", "must be http or https URLs, got "+extraUrl)
+	}
+
+	p.check(isSourceFormat(c.CurrencySourceFormat), "CURRENCIES_SOURCE_FORMAT",
+		"must be one of cbr-xml, cbr-json or ecb-xml, got "+c.CurrencySourceFormat)
+	p.check(len(c.CurrencyExtraSourceFormats) <= len(c.CurrencyExtraSourceUrls), "CURRENCIES_EXTRA_SOURCE_FORMATS",
+		"must not have more formats than CURRENCIES_EXTRA_SOURCE_URLS")
+
+	for _, format := range c.CurrencyExtraSourceFormats {
+		p.check((format == "") || isSourceFormat(format), "CURRENCIES_EXTRA_SOURCE_FORMATS",
+			"must be cbr-xml, cbr-json or ecb-xml, got "+format)
+	}
+
+	p.check((c.SourcesReconcilePolicy == "primary") || (c.SourcesReconcilePolicy == "average"), "SOURCES_RECONCILE_POLICY",
+		"must be primary or average, got "+c.SourcesReconcilePolicy)
+	p.check(c.SourcesDiscrepancyTolerance >= 0, "SOURCES_DISCREPANCY_TOLERANCE", "must not be negative")
+	p.check(c.SourceMaxResponseSize > 0, "SOURCE_MAX_RESPONSE_SIZE", "must be positive")
+	p.check(c.SourceTimeout > 0, "SOURCE_TIMEOUT", "must be positive")
+	p.check(isHttpUrl(c.MetalSourceUrl), "METALS_SOURCE_URL", "must be an http or https URL")
+	p.check(c.MetalSourceDaysRange > 0, "METALS_SOURCE_DAYS_RANGE", "must be positive")
+
+	whitelisted := make(map[string]bool, len(c.CurrencyWhitelist))
+
+	for _, charCode := range c.CurrencyWhitelist {
+		p.check(isCharCode(charCode), "CURRENCIES_WHITELIST", "must be char codes in upper case, got "+charCode)
+
+		whitelisted[charCode] = true
+	}
+
+	for _, charCode := range c.CurrencyBlacklist {
+		p.check(isCharCode(charCode), "CURRENCIES_BLACKLIST", "must be char codes in upper case, got "+charCode)
+		p.check(!whitelisted[charCode], "CURRENCIES_BLACKLIST", "must not have whitelisted "+charCode)
+	}
+
+	c.validateBaskets(p, whitelisted)
+}
+
+// validateBaskets checks the baskets, the currencies of which must not
+// be filtered out, as the baskets are calculated from the served ones.
+func (c *Config) validateBaskets(p *problems, whitelisted map[string]bool) {
+	blacklisted := make(map[string]bool, len(c.CurrencyBlacklist))
+
+	for _, charCode := range c.CurrencyBlacklist {
+		blacklisted[charCode] = true
+	}
+
+	basketCodes := make(map[string]bool, len(c.CurrencyBaskets))
+
+	for _, text := range c.CurrencyBaskets {
+		basket, err := rates.ParseBasket(text)
+		if err != nil {
+			p.add("CURRENCY_BASKETS", err.Error())
+			continue
+		}
+
+		_, isCurrency := iso4217.Lookup(basket.CharCode)
+
+		p.check(isCharCode(basket.CharCode) && (basket.CharCode != rates.BaseCharCode) && !isCurrency, "CURRENCY_BASKETS",
+			"code of basket must be three letters, that are not a code of a currency, got "+basket.CharCode)
+		p.check(!basketCodes[basket.CharCode], "CURRENCY_BASKETS", "code of basket "+basket.CharCode+" is duplicated")
+
+		basketCodes[basket.CharCode] = true
+
+		for _, component := range basket.Components {
+			if component.CharCode == rates.BaseCharCode {
+				continue
+			}
+
+			isFiltered := ((len(whitelisted) > 0) && !whitelisted[component.CharCode]) || blacklisted[component.CharCode]
+
+			p.check(!isFiltered, "CURRENCY_BASKETS",
+				"currency "+component.CharCode+" of basket "+basket.CharCode+" is filtered out by CURRENCIES_WHITELIST or CURRENCIES_BLACKLIST")
+		}
+	}
+}
+
+func (c *Config) validateSchedule(p *problems) {
+	if c.UpdateCron != "" {
+		_, err := cron.ParseStandard(c.UpdateCron)
+		p.check(err == nil, "UPDATE_CRON", "must be a cron expression of five fields, e.g. \"30 12 * * MON-FRI\"")
+	} else {
+		p.check(len(c.TimesWhenNeedToUpdateCurrency) > 0, "TIME_WHEN_NEED_TO_UPDATE_CURRENCY",
+			"must be set, when UPDATE_CRON is not")
+	}
+
+	for _, updateTime := range c.TimesWhenNeedToUpdateCurrency {
+		_, err := time.Parse(time.TimeOnly, strings.TrimSpace(updateTime))
+		p.check(err == nil, "TIME_WHEN_NEED_TO_UPDATE_CURRENCY", "must be times like 13:30:00, got "+updateTime)
+	}
+
+	_, err := time.LoadLocation(c.UpdateTimezone)
+	p.check(err == nil, "UPDATE_TIMEZONE", "must be an IANA timezone, e.g. Europe/Moscow")
+
+	for _, weekday := range c.NonPublishingWeekdays {
+		p.check(isWeekday(strings.TrimSpace(weekday)), "NON_PUBLISHING_WEEKDAYS", "must be days of week, got "+weekday)
+	}
+
+	for _, holiday := range c.Holidays {
+		_, err = time.Parse(time.DateOnly, strings.TrimSpace(holiday))
+		p.check(err == nil, "HOLIDAYS", "must be dates like 2024-01-01, got "+holiday)
+	}
+
+	p.check(c.UpdateRetryMinInterval > 0, "UPDATE_RETRY_MIN_INTERVAL", "must be positive")
+	p.check(c.UpdateRetryMaxInterval >= c.UpdateRetryMinInterval, "UPDATE_RETRY_MAX_INTERVAL",
+		"must not be less than UPDATE_RETRY_MIN_INTERVAL")
+	p.check(c.UpdateJitter >= 0, "UPDATE_JITTER", "must not be negative")
+	p.check(c.CatchUpMaxDays >= 0, "CATCH_UP_MAX_DAYS", "must not be negative")
+}
+
+func (c *Config) validateDatabase(p *problems) {
+	switch c.DbDriver {
+	case DbDriverNone:
+	case DbDriverPostgres, DbDriverMysql:
+		p.check(c.DbHostname != "", "DB_HOSTNAME", "must be set for the "+c.DbDriver+" driver")
+		p.check(isPort(c.DbPort), "DB_PORT", "must be a port number from 1 to 65535")
+		p.check(c.DbUsername != "", "DB_USERNAME", "must be set for the "+c.DbDriver+" driver")
+		p.check(c.DbPassword != "", "DB_PASSWORD", "must be set for the "+c.DbDriver+" driver")
+		p.check(c.DbDatabase != "", "DB_DATABASE", "must be set for the "+c.DbDriver+" driver")
+		p.check(isSslMode(c.DbSSLMode), "DB_SSLMODE",
+			"must be disable, allow, prefer, require, verify-ca or verify-full, got "+c.DbSSLMode)
+	case DbDriverSqlite:
+		p.check(isSqlDriver(DbDriverSqlite), "DB_DRIVER",
+			"sqlite is not compiled in, the server must be built with the sqlite tag")
+		p.check(c.DbSqliteFile != "", "DB_SQLITE_FILE", "must be set for the sqlite driver")
+	case DbDriverBolt:
+		p.check(c.DbBoltFile != "", "DB_BOLT_FILE", "must be set for the bolt driver")
+	default:
+		p.add("DB_DRIVER", "must be one of postgres, mysql, sqlite, bolt or none, got "+c.DbDriver)
+	}
+
+	p.check(!c.IsNotifyUpdates || (c.DbDriver == DbDriverPostgres), "NOTIFY_UPDATES",
+		"is supported by the postgres driver only")
+	p.check(c.DbMaxOpenConns >= 0, "DB_MAX_OPEN_CONNS", "must not be negative")
+	p.check(c.DbMaxIdleConns >= 0, "DB_MAX_IDLE_CONNS", "must not be negative")
+	p.check(c.DbQueryTimeout > 0, "DB_QUERY_TIMEOUT", "must be positive")
+	p.check(c.DbPingTimeout > 0, "DB_PING_TIMEOUT", "must be positive")
+	p.check(c.DbSlowQueryThreshold >= 0, "DB_SLOW_QUERY_THRESHOLD", "must not be negative")
+	p.check(c.DbHealthCheckInterval > 0, "DB_HEALTH_CHECK_INTERVAL", "must be positive")
+	p.check(c.DbReconnectMinBackoff > 0, "DB_RECONNECT_MIN_BACKOFF", "must be positive")
+	p.check(c.DbReconnectMaxBackoff >= c.DbReconnectMinBackoff, "DB_RECONNECT_MAX_BACKOFF",
+		"must not be less than DB_RECONNECT_MIN_BACKOFF")
+	p.check(c.RetentionDays >= 0, "RETENTION_DAYS", "must not be negative")
+	p.check((c.RetentionDays == 0) || (c.PruneInterval > 0), "PRUNE_INTERVAL", "must be positive, when RETENTION_DAYS is set")
+}
+
+// validateAlerts checks the notification channels of the alerts. The
+// rules are checked, when they are parsed.
+func (c *Config) validateAlerts(p *problems) {
+	p.check((c.AlertWebhookUrl != "") || (c.AlertTelegramBotToken != "") || (c.AlertSmtpAddress != ""),
+		"ALERT_WEBHOOK_URL, ALERT_TELEGRAM_BOT_TOKEN, ALERT_SMTP_ADDRESS",
+		"at least one must be set, when the alerts are enabled")
+	p.check(c.AlertTimeout > 0, "ALERT_TIMEOUT", "must be positive")
+
+	if c.AlertWebhookUrl != "" {
+		p.check(isHttpUrl(c.AlertWebhookUrl), "ALERT_WEBHOOK_URL", "must be an http or https URL")
+	}
+
+	if c.AlertTelegramBotToken != "" {
+		p.check(isHttpUrl(c.AlertTelegramApiUrl), "ALERT_TELEGRAM_API_URL", "must be an http or https URL")
+		p.check(c.AlertTelegramChatId != "", "ALERT_TELEGRAM_CHAT_ID", "must be set, when the telegram bot token is set")
+	}
+
+	if c.AlertSmtpAddress != "" {
+		_, _, err := net.SplitHostPort(c.AlertSmtpAddress)
+		p.check(err == nil, "ALERT_SMTP_ADDRESS", "must be host:port")
+		p.check(c.AlertEmailFrom != "", "ALERT_EMAIL_FROM", "must be set, when the smtp address is set")
+		p.check(len(c.AlertEmailTo) > 0, "ALERT_EMAIL_TO", "must be set, when the smtp address is set")
+	}
+}
+
+// validateApiKeys checks the names and the values of the API keys and
+// the quotas of them. The names are stored along with the usage, so they
+// are limited to the letters, the digits, the dashes and the
+// underscores.
+func (c *Config) validateApiKeys(p *problems) {
+	keyNames := make(map[string]string, len(c.ApiKeys))
+
+	for name, key := range c.ApiKeys {
+		p.check(isKeyName(name), "API_KEYS", "name "+name+" must be up to 64 letters, digits, dashes or underscores")
+		p.check(len(key) >= minApiKeyLength, "API_KEYS", "key of "+name+" must be at least "+strconv.Itoa(minApiKeyLength)+" characters")
+		p.check(key != c.AdminApiKey, "API_KEYS", "key of "+name+" must differ from ADMIN_API_KEY")
+
+		if other, ok := keyNames[key]; ok {
+			p.add("API_KEYS", "keys of "+other+" and "+name+" must differ")
+		}
+
+		keyNames[key] = name
+	}
+
+	for name, scope := range c.ApiKeyScopes {
+		_, ok := c.ApiKeys[name]
+		p.check(ok, "API_KEY_SCOPES", "scope of "+name+" is set, but there is no such key in API_KEYS")
+		p.check((scope == ScopeRead) || (scope == ScopeAdmin), "API_KEY_SCOPES",
+			"scope of "+name+" must be read or admin, got "+scope)
+	}
+
+	if c.AdminApiKey != "" {
+		p.check(len(c.AdminApiKey) >= minApiKeyLength, "ADMIN_API_KEY", "must be at least "+strconv.Itoa(minApiKeyLength)+" characters")
+	}
+
+	p.check(c.ApiKeyDailyQuota >= 0, "API_KEY_DAILY_QUOTA", "must not be negative")
+	p.check(c.ApiKeyMonthlyQuota >= 0, "API_KEY_MONTHLY_QUOTA", "must not be negative")
+	p.check(c.UsageFlushInterval > 0, "USAGE_FLUSH_INTERVAL", "must be positive")
+
+	for env, quotas := range map[string]map[string]int64{
+		"API_KEY_DAILY_QUOTAS":   c.ApiKeyDailyQuotas,
+		"API_KEY_MONTHLY_QUOTAS": c.ApiKeyMonthlyQuotas,
+	} {
+		for name, quota := range quotas {
+			_, ok := c.ApiKeys[name]
+			p.check(ok, env, "quota of "+name+" is set, but there is no such key in API_KEYS")
+			p.check(quota >= 0, env, "quota of "+name+" must not be negative")
+		}
+	}
+}
+
+// validateOidc checks the provider of the ID tokens and the scopes of
+// the groups of the admins, as no one is an admin without them.
+func (c *Config) validateOidc(p *problems) {
+	p.check(isHttpUrl(c.OidcIssuerUrl), "OIDC_ISSUER_URL", "must be an http or https URL")
+	p.check(c.OidcClientId != "", "OIDC_CLIENT_ID", "must be set, when OIDC_ISSUER_URL is set")
+	p.check(c.OidcGroupsClaim != "", "OIDC_GROUPS_CLAIM", "must not be empty")
+	p.check(len(c.OidcGroupScopes) > 0, "OIDC_GROUP_SCOPES", "must be set, when OIDC_ISSUER_URL is set")
+	p.check(c.OidcTimeout > 0, "OIDC_TIMEOUT", "must be positive")
+
+	for group, scope := range c.OidcGroupScopes {
+		p.check((scope == ScopeRead) || (scope == ScopeAdmin), "OIDC_GROUP_SCOPES",
+			"scope of "+group+" must be read or admin, got "+scope)
+	}
+}
+
+func isKeyName(name string) bool {
+	if (name == "") || (len(name) > maxKeyNameLength) {
+		return false
+	}
+
+	for _, r := range name {
+		if !(((r >= 'a') && (r <= 'z')) || ((r >= 'A') && (r <= 'Z')) || ((r >= '0') && (r <= '9')) || (r == '-') || (r == '_')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isCharCode reports, whether the code is of three latin letters in
+// upper case, e.g. "USD".
+func isCharCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+
+	for _, r := range code {
+		if (r < 'A') || (r > 'Z') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSslMode reports, whether the mode is the one of Postgres, that the
+// MySQL connections are mapped from too.
+func isSslMode(mode string) bool {
+	switch mode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		return true
+	default:
+		return false
+	}
+}
+
+func isPort(port string) bool {
+	number, err := strconv.Atoi(port)
+
+	return (err == nil) && (number >= minPort) && (number <= maxPort)
+}
+
+// isMarkup reports, whether the percent is the markup, that leaves some
+// of the converted amount.
+func isMarkup(percent float64) bool {
+	return (percent >= 0) && (percent < 100)
+}
+
+// isSqlDriver reports, whether the driver of the database is registered,
+// i.e. compiled in the binary, as some of them are built by the tags.
+func isSqlDriver(name string) bool {
+	for _, driver := range sql.Drivers() {
+		if driver == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSourceFormat(format string) bool {
+	switch format {
+	case SourceFormatCbrXml, SourceFormatCbrJson, SourceFormatEcbXml:
+		return true
+	default:
+		return false
+	}
+}
+
+func isHttpUrl(rawUrl string) bool {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return false
+	}
+
+	return ((parsedUrl.Scheme == "http") || (parsedUrl.Scheme == "https")) && (parsedUrl.Host != "")
+}
+
+// isWeekday reports, whether the name is of a day of the week in any
+// case, e.g. "Sunday".
+func isWeekday(name string) bool {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if strings.EqualFold(weekday.String(), name) {
+			return true
+		}
+	}
+
+	return false
+}
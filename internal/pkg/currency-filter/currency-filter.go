@@ -0,0 +1,65 @@
+// Package currencyfilter restricts the currencies, that are stored and
+// served, to the configured ones.
+package currencyfilter
+
+import (
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+)
+
+// A Filter keeps the currencies of the whitelist, if it is set, except
+// the ones of the blacklist.
+type Filter struct {
+	whitelist map[string]bool
+	blacklist map[string]bool
+}
+
+func New(cfg *config.Config) *Filter {
+	return &Filter{
+		whitelist: codeSet(cfg.CurrencyWhitelist),
+		blacklist: codeSet(cfg.CurrencyBlacklist),
+	}
+}
+
+// IsEnabled reports, whether any currency may be filtered out.
+func (f *Filter) IsEnabled() bool {
+	return (len(f.whitelist) > 0) || (len(f.blacklist) > 0)
+}
+
+// IsAllowed reports, whether the currency of the char code is kept.
+func (f *Filter) IsAllowed(charCode string) bool {
+	if (len(f.whitelist) > 0) && !f.whitelist[charCode] {
+		return false
+	}
+
+	return !f.blacklist[charCode]
+}
+
+// Apply returns the currencies, that are kept. The currencies are the
+// same, if the filter is not enabled.
+func (f *Filter) Apply(currencies models.Currencies) models.Currencies {
+	if !f.IsEnabled() {
+		return currencies
+	}
+
+	filtered := currencies
+	filtered.Currencies = make([]models.Currency, 0, len(currencies.Currencies))
+
+	for _, currency := range currencies.Currencies {
+		if f.IsAllowed(currency.CharCode) {
+			filtered.Currencies = append(filtered.Currencies, currency)
+		}
+	}
+
+	return filtered
+}
+
+func codeSet(codes []string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+
+	for _, code := range codes {
+		set[code] = true
+	}
+
+	return set
+}
@@ -1,16 +1,45 @@
 package database
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"time"
 
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
 	"github.com/mrumyantsev/go-errlib"
 
 	"database/sql"
 
-	_ "github.com/lib/pq" // necessary for Postgres driver
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
 )
 
+// listenerPingInterval is the interval of checking the connection of
+// the listener, when there are no notifications.
+const listenerPingInterval = 90 * time.Second
+
+// mysqlTlsConfigs are the TLS configs of the MySQL driver by the SSL
+// modes of Postgres, so DB_SSLMODE is the same for both of them.
+var mysqlTlsConfigs = map[string]string{
+	"disable":     "false",
+	"allow":       "preferred",
+	"prefer":      "preferred",
+	"require":     "skip-verify",
+	"verify-ca":   "true",
+	"verify-full": "true",
+}
+
+// An Executor runs queries either in a transaction or outside of it.
+type Executor interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
 // A Database is used to control the connection to a database.
 type Database struct {
 	config *config.Config
@@ -23,9 +52,132 @@ func New(cfg *config.Config) *Database {
 	}
 }
 
-// Connect connects to the database.
+// Connect opens the pool of connections to the database, which is
+// meant to be kept for the process lifetime. The pool replaces broken
+// connections by itself, so the database is only pinged here to fail
+// fast on wrong settings.
 func (d *Database) Connect() error {
-	dataSourceName := fmt.Sprintf("host=%s port=%s user=%s "+
+	db, err := sql.Open(d.config.DbDriver, d.dataSourceName(false))
+	if err != nil {
+		return errlib.Wrap(err, "could not connect to db")
+	}
+
+	db.SetMaxOpenConns(d.config.DbMaxOpenConns)
+	db.SetMaxIdleConns(d.config.DbMaxIdleConns)
+	db.SetConnMaxLifetime(d.config.DbConnMaxLifetime)
+	db.SetConnMaxIdleTime(d.config.DbConnMaxIdleTime)
+
+	d.DB = db
+
+	if err = d.Ping(context.Background()); err != nil {
+		_ = db.Close()
+
+		return errlib.Wrap(err, "could not ping db")
+	}
+
+	return nil
+}
+
+// OpenForMigrations opens the connection, that runs the migrations. It
+// is the only one, that may run multiple statements in one query, as
+// they widen the harm of an injection. It is closed by the caller.
+func (d *Database) OpenForMigrations() (*sql.DB, error) {
+	db, err := sql.Open(d.config.DbDriver, d.dataSourceName(true))
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not connect to db for migrations")
+	}
+
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+// Ping checks, whether the database is reachable.
+func (d *Database) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, d.config.DbPingTimeout)
+	defer cancel()
+
+	return d.DB.PingContext(ctx)
+}
+
+// WithQueryTimeout returns the context, that limits a query by the
+// configured timeout, so a hung database can not block its caller.
+func (d *Database) WithQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, d.config.DbQueryTimeout)
+}
+
+// Notify sends the notification with the payload to the channel. It
+// is supported by Postgres only.
+func (d *Database) Notify(ctx context.Context, channel string, payload string) error {
+	ctx, cancel := d.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := d.Executor(ctx).ExecContext(ctx, "SELECT pg_notify($1, $2);", channel, payload); err != nil {
+		return errlib.Wrap(err, "could not send notification")
+	}
+
+	return nil
+}
+
+// Listen calls the function with the payload of every notification,
+// that is sent to the channel, until the context is canceled. The
+// listener reconnects by itself, and the function is called with an
+// empty payload after that, as notifications may have been missed. It
+// is supported by Postgres only.
+func (d *Database) Listen(ctx context.Context, channel string, fn func(payload string)) error {
+	listener := pq.NewListener(
+		d.dataSourceName(false),
+		d.config.DbReconnectMinBackoff,
+		d.config.DbReconnectMaxBackoff,
+		nil,
+	)
+	defer func() { _ = listener.Close() }()
+
+	if err := listener.Listen(channel); err != nil {
+		return errlib.Wrap(err, "could not listen to channel "+channel)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			if notification == nil {
+				fn("")
+				continue
+			}
+
+			fn(notification.Extra)
+		case <-time.After(listenerPingInterval):
+			go func() { _ = listener.Ping() }()
+		}
+	}
+}
+
+// dataSourceName returns the data source name of the configured
+// database driver. The multiple statements in one query are allowed by
+// MySQL only, when they are asked for, and the other drivers run them
+// anyway.
+func (d *Database) dataSourceName(isMultiStatements bool) string {
+	switch d.config.DbDriver {
+	case config.DbDriverSqlite:
+		return d.config.DbSqliteFile
+	case config.DbDriverMysql:
+		mysqlConfig := mysql.NewConfig()
+
+		mysqlConfig.User = d.config.DbUsername
+		mysqlConfig.Passwd = d.config.DbPassword
+		mysqlConfig.Net = "tcp"
+		mysqlConfig.Addr = net.JoinHostPort(d.config.DbHostname, d.config.DbPort)
+		mysqlConfig.DBName = d.config.DbDatabase
+		mysqlConfig.Params = map[string]string{"charset": "utf8mb4"}
+		mysqlConfig.TLSConfig = mysqlTlsConfigs[d.config.DbSSLMode]
+		mysqlConfig.MultiStatements = isMultiStatements
+
+		return mysqlConfig.FormatDSN()
+	}
+
+	return fmt.Sprintf("host=%s port=%s user=%s "+
 		"password=%s dbname=%s sslmode=%s",
 		d.config.DbHostname,
 		d.config.DbPort,
@@ -34,17 +186,39 @@ func (d *Database) Connect() error {
 		d.config.DbDatabase,
 		d.config.DbSSLMode,
 	)
+}
 
-	db, err := sql.Open(d.config.DbDriver, dataSourceName)
+// InTransaction runs the function in a transaction, which is committed,
+// if the function succeeds, and rolled back otherwise. The queries,
+// that use the executor of the passed context, run in the transaction.
+func (d *Database) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := d.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return errlib.Wrap(err, "could not connect to db")
+		return errlib.Wrap(err, "could not begin transaction")
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	d.DB = db
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errlib.Wrap(err, "could not commit transaction")
+	}
 
 	return nil
 }
 
+// Executor returns the transaction of the context, if there is one,
+// or the database otherwise.
+func (d *Database) Executor(ctx context.Context) Executor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return d.DB
+}
+
 // Disconnect disconnects from the database.
 func (d *Database) Disconnect() error {
 	if err := d.DB.Close(); err != nil {
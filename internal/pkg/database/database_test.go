@@ -0,0 +1,43 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+)
+
+func TestMysqlDataSourceName(t *testing.T) {
+	d := New(&config.Config{
+		DbDriver:   config.DbDriverMysql,
+		DbHostname: "db.local",
+		DbPort:     "3306",
+		DbUsername: "converter",
+		DbPassword: "p@ss:w/rd?x",
+		DbDatabase: "currency_storage",
+		DbSSLMode:  "verify-full",
+	})
+
+	for _, isMultiStatements := range []bool{false, true} {
+		parsed, err := mysql.ParseDSN(d.dataSourceName(isMultiStatements))
+		if err != nil {
+			t.Fatalf("could not parse dsn: %v", err)
+		}
+
+		if (parsed.User != "converter") || (parsed.Passwd != "p@ss:w/rd?x") {
+			t.Errorf("got user %q and password %q", parsed.User, parsed.Passwd)
+		}
+
+		if (parsed.Addr != "db.local:3306") || (parsed.DBName != "currency_storage") {
+			t.Errorf("got address %q and database %q", parsed.Addr, parsed.DBName)
+		}
+
+		if parsed.TLSConfig != "true" {
+			t.Errorf("got tls %q, want true", parsed.TLSConfig)
+		}
+
+		if parsed.MultiStatements != isMultiStatements {
+			t.Errorf("got multi statements %t, want %t", parsed.MultiStatements, isMultiStatements)
+		}
+	}
+}
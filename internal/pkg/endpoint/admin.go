@@ -0,0 +1,131 @@
+package endpoint
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	apimodels "github.com/mrumyantsev/currency-converter-app/internal/pkg/api-models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/audit"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// A Refresher updates the data out of schedule.
+type Refresher interface {
+	Refresh()
+}
+
+// An auditResponse is the actions of the admins over the period from
+// the first date to the last one inclusive.
+type auditResponse struct {
+	From    string                 `json:"from"`
+	To      string                 `json:"to"`
+	Entries []apimodels.AuditEntry `json:"entries"`
+}
+
+type AdminEndpoint struct {
+	config    *config.Config
+	audit     *audit.Log
+	refresher Refresher
+}
+
+func NewAdminEndpoint(cfg *config.Config, au *audit.Log, rf Refresher) *AdminEndpoint {
+	return &AdminEndpoint{
+		config:    cfg,
+		audit:     au,
+		refresher: rf,
+	}
+}
+
+// Refresh requests the update of the data out of schedule. The update
+// is made in the background, so the response is sent before it ends.
+func (e *AdminEndpoint) Refresh(ctx echo.Context) error {
+	e.refresher.Refresh()
+
+	requestLogger(ctx).Info().Msg("refresh requested by admin")
+
+	recordAudit(ctx, e.audit, audit.ActionRefresh, nil)
+
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+// Audit sends the actions of the admins over the period, e.g. for the
+// query ?from=2024-01-01&to=2024-01-31, in the order of them. The period
+// is the current month up to today by default.
+func (e *AdminEndpoint) Audit(ctx echo.Context) error {
+	fromDate, toDate, err := monthPeriod(ctx, e.audit.Today())
+	if err != nil {
+		return err
+	}
+
+	entries, err := e.audit.Entries(ctx.Request().Context(), fromDate, toDate)
+	if err != nil {
+		errMsg := "could not get audit entries"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	response := auditResponse{
+		From:    fromDate,
+		To:      toDate,
+		Entries: apimodels.NewAuditEntries(entries),
+	}
+
+	if err = ctx.JSON(http.StatusOK, response); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
+
+// recordAudit records the action of the request in the audit log, that
+// may be nil to record nothing. The action is already made, so the
+// entry, that can not be recorded, is logged instead.
+func recordAudit(ctx echo.Context, au *audit.Log, action string, payload any) {
+	if au == nil {
+		return
+	}
+
+	if _, err := au.Record(ctx.Request().Context(), action, payload); err != nil {
+		requestLogger(ctx).Error().Err(err).Str("action", action).Any("payload", payload).
+			Msg("could not record audit entry")
+	}
+}
+
+// monthPeriod returns the period of the query from the first date to
+// the last one, which is today by default. The first date is the first
+// one of the month of the last date by default.
+func monthPeriod(ctx echo.Context, today string) (string, string, error) {
+	toDate := ctx.QueryParam(queryTo)
+	if toDate == "" {
+		toDate = today
+	}
+
+	to, err := time.Parse(models.RateDateLayout, toDate)
+	if err != nil {
+		return "", "", echo.NewHTTPError(http.StatusBadRequest, "invalid last date, expected YYYY-MM-DD")
+	}
+
+	fromDate := ctx.QueryParam(queryFrom)
+	if fromDate == "" {
+		fromDate = to.AddDate(0, 0, 1-to.Day()).Format(models.RateDateLayout)
+	}
+
+	if _, err = time.Parse(models.RateDateLayout, fromDate); err != nil {
+		return "", "", echo.NewHTTPError(http.StatusBadRequest, "invalid first date, expected YYYY-MM-DD")
+	}
+
+	if fromDate > toDate {
+		return "", "", echo.NewHTTPError(http.StatusBadRequest, "first date is after last date")
+	}
+
+	return fromDate, toDate, nil
+}
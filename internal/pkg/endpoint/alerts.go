@@ -0,0 +1,79 @@
+package endpoint
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/alerting"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/audit"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+)
+
+type AlertsEndpoint struct {
+	alerter *alerting.Alerter
+	audit   *audit.Log
+}
+
+// NewAlertsEndpoint creates the endpoint, that records the changes of
+// the rules in the audit log, which may be nil to record nothing.
+func NewAlertsEndpoint(al *alerting.Alerter, au *audit.Log) *AlertsEndpoint {
+	return &AlertsEndpoint{
+		alerter: al,
+		audit:   au,
+	}
+}
+
+// Rules sends the alert rules, that are checked after every update.
+func (e *AlertsEndpoint) Rules(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, e.alerter.Rules())
+}
+
+// AddRule adds the alert rule from the request body, e.g.
+// {"from":"USD","kind":"change","threshold":"2"}, and sends it back with
+// the id. The currency to is the base one, if it is omitted.
+func (e *AlertsEndpoint) AddRule(ctx echo.Context) error {
+	var rule alerting.Rule
+
+	if err := ctx.Bind(&rule); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid alert rule")
+	}
+
+	if rule.To == "" {
+		rule.To = rates.BaseCharCode
+	}
+
+	rule.From, rule.To = strings.ToUpper(rule.From), strings.ToUpper(rule.To)
+
+	if err := rule.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	rule = e.alerter.AddRule(rule)
+
+	requestLogger(ctx).Info().Str("rule", rule.Pair()+":"+rule.Kind+":"+rule.Threshold.String()).
+		Msg("alert rule added")
+
+	recordAudit(ctx, e.audit, audit.ActionAddAlertRule, rule)
+
+	return ctx.JSON(http.StatusCreated, rule)
+}
+
+// DeleteRule deletes the alert rule by the id.
+func (e *AlertsEndpoint) DeleteRule(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param(paramId))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid alert rule id")
+	}
+
+	if !e.alerter.DeleteRule(id) {
+		return echo.NewHTTPError(http.StatusNotFound, "alert rule not found")
+	}
+
+	requestLogger(ctx).Info().Int("ruleId", id).Msg("alert rule deleted")
+
+	recordAudit(ctx, e.audit, audit.ActionDeleteAlertRule, map[string]int{"id": id})
+
+	return ctx.NoContent(http.StatusNoContent)
+}
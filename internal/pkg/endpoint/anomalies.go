@@ -0,0 +1,91 @@
+package endpoint
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/anomaly"
+	apimodels "github.com/mrumyantsev/currency-converter-app/internal/pkg/api-models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/audit"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// An anomaliesResponse is the data of the source, that are held back,
+// as the rates of them have changed by more than the threshold percent.
+type anomaliesResponse struct {
+	Checksum         string             `json:"checksum"`
+	RateDate         string             `json:"rate_date"`
+	ThresholdPercent string             `json:"threshold_percent"`
+	DetectedAt       time.Time          `json:"detected_at"`
+	Changes          []apimodels.Change `json:"changes"`
+}
+
+type AnomaliesEndpoint struct {
+	anomalies *anomaly.Detector
+	audit     *audit.Log
+	refresher Refresher
+}
+
+// NewAnomaliesEndpoint creates the endpoint, that records the accepted
+// data in the audit log, which may be nil to record nothing.
+func NewAnomaliesEndpoint(ad *anomaly.Detector, au *audit.Log, rf Refresher) *AnomaliesEndpoint {
+	return &AnomaliesEndpoint{
+		anomalies: ad,
+		audit:     au,
+		refresher: rf,
+	}
+}
+
+// Anomalies sends the data, that are held back, along with the changes
+// of the rates, that exceed the threshold, or no content, if there are
+// none.
+func (e *AnomaliesEndpoint) Anomalies(ctx echo.Context) error {
+	held := e.anomalies.Held()
+	if held == nil {
+		return ctx.NoContent(http.StatusNoContent)
+	}
+
+	changes := apimodels.NewChanges(held.Changes)
+
+	for i := range changes {
+		changes[i].Change = changes[i].Change.Round(changesPrecision)
+		changes[i].ChangePercent = changes[i].ChangePercent.Round(changesPrecision)
+	}
+
+	err := ctx.JSON(http.StatusOK, anomaliesResponse{
+		Checksum:         held.Checksum,
+		RateDate:         held.RateDate,
+		ThresholdPercent: e.anomalies.Threshold().String(),
+		DetectedAt:       held.DetectedAt,
+		Changes:          changes,
+	})
+	if err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
+
+// AcceptAnomalies accepts the data, that are held back, and requests
+// the update, that stores them, if they are still published by the
+// source. The update is made in the background, so the response is sent
+// before it ends.
+func (e *AnomaliesEndpoint) AcceptAnomalies(ctx echo.Context) error {
+	held := e.anomalies.Accept()
+	if held == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "no data are held back")
+	}
+
+	e.refresher.Refresh()
+
+	requestLogger(ctx).Warn().Str("checksum", held.Checksum).Msg("anomalous data accepted by admin")
+
+	recordAudit(ctx, e.audit, audit.ActionAcceptAnomalies, map[string]string{"checksum": held.Checksum})
+
+	return ctx.NoContent(http.StatusAccepted)
+}
@@ -0,0 +1,107 @@
+package endpoint
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	fsops "github.com/mrumyantsev/currency-converter-app/internal/pkg/fs-ops"
+	memcache "github.com/mrumyantsev/currency-converter-app/internal/pkg/mem-cache"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+const (
+	paramId        = "id"
+	mimeXmlCharset = "application/xml; charset=windows-1251"
+)
+
+type ArchiveEndpoint struct {
+	config   *config.Config
+	memCache *memcache.MemCache
+	fsOps    *fsops.FsOps
+}
+
+func NewArchiveEndpoint(cfg *config.Config, mc *memcache.MemCache, fo *fsops.FsOps) *ArchiveEndpoint {
+	return &ArchiveEndpoint{
+		config:   cfg,
+		memCache: mc,
+		fsOps:    fo,
+	}
+}
+
+// CurrencyData sends the raw currency data, that was fetched from the
+// source for the update with requested id.
+func (e *ArchiveEndpoint) CurrencyData(ctx echo.Context) error {
+	updateDatetimeId, err := strconv.Atoi(ctx.Param(paramId))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid update id")
+	}
+
+	return e.sendArchivedData(ctx, updateDatetimeId)
+}
+
+// LatestCurrencyData sends the raw currency data exactly as it was
+// fetched from the source for the update of the current currencies, so
+// the values may be verified against the official document.
+func (e *ArchiveEndpoint) LatestCurrencyData(ctx echo.Context) error {
+	snapshot := e.memCache.Snapshot()
+
+	if snapshot.UpdateDatetime == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "currencies are not loaded yet")
+	}
+
+	ctx.Response().Header().Set(headerRateDate, snapshot.UpdateDatetime.RateDate)
+
+	return e.sendArchivedData(ctx, snapshot.UpdateDatetime.Id)
+}
+
+// sendArchivedData sends the raw currency data of the update in the
+// format, that they are archived in.
+func (e *ArchiveEndpoint) sendArchivedData(ctx echo.Context, updateDatetimeId int) error {
+	data, err := e.fsOps.ArchivedCurrencyData(updateDatetimeId)
+	if err != nil {
+		if errors.Is(err, fsops.ErrNotArchived) {
+			return echo.NewHTTPError(http.StatusNotFound, "no archived data for update id")
+		}
+
+		errMsg := "could not get archived data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	if err = ctx.Blob(http.StatusOK, archivedContentType(data), data); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
+
+// archivedContentType returns the content type of the archived data by
+// the data themselves, as they are of the source, that may be of any
+// format, or the reconciled currencies of the sources encoded in XML.
+func archivedContentType(data []byte) string {
+	data = bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(data, []byte("{")) {
+		return echo.MIMEApplicationJSON
+	}
+
+	// The encoding is declared by the prolog of the XML, e.g. of the
+	// source, that is in windows-1251.
+	prolog, _, isProlog := bytes.Cut(data, []byte("?>"))
+
+	if isProlog && bytes.Contains(bytes.ToLower(prolog), []byte("windows-1251")) {
+		return mimeXmlCharset
+	}
+
+	return echo.MIMEApplicationXMLCharsetUTF8
+}
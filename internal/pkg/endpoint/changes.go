@@ -0,0 +1,202 @@
+package endpoint
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	apimodels "github.com/mrumyantsev/currency-converter-app/internal/pkg/api-models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/iso4217"
+	memcache "github.com/mrumyantsev/currency-converter-app/internal/pkg/mem-cache"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+const (
+	queryLimit = "limit"
+
+	// changesPrecision is the number of decimal places of the changes.
+	changesPrecision = 4
+
+	// changesLookbackDays is the length of the period before the rate
+	// date of the current rates, that the previous ones are looked for
+	// in the storage, e.g. over the long holidays.
+	changesLookbackDays = 30
+)
+
+// A changesResponse is the changes of the rates of the currencies since
+// the previous snapshot.
+type changesResponse struct {
+	RateDate         string             `json:"rate_date"`
+	PreviousRateDate string             `json:"previous_rate_date"`
+	Changes          []apimodels.Change `json:"changes"`
+}
+
+type ChangesEndpoint struct {
+	config   *config.Config
+	memCache *memcache.MemCache
+	storage  storage.Storage
+}
+
+func NewChangesEndpoint(cfg *config.Config, mc *memcache.MemCache, st storage.Storage) *ChangesEndpoint {
+	return &ChangesEndpoint{
+		config:   cfg,
+		memCache: mc,
+		storage:  st,
+	}
+}
+
+// Changes sends the absolute and the percentage changes of the rates of
+// the currencies since the previous snapshot, from the largest
+// percentage change in either direction to the smallest one, e.g. for
+// the top movers. The number of the currencies may be limited, e.g. by
+// the query ?limit=5. The previous snapshot is taken from the memory
+// cache, or from the storage, if it is not there.
+func (e *ChangesEndpoint) Changes(ctx echo.Context) error {
+	lang, err := requestLang(ctx)
+	if err != nil {
+		return err
+	}
+
+	limit := 0
+
+	if value := ctx.QueryParam(queryLimit); value != "" {
+		if limit, err = strconv.Atoi(value); (err != nil) || (limit <= 0) {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be positive")
+		}
+	}
+
+	snapshot := e.memCache.Snapshot()
+
+	if (snapshot.Currencies == nil) || (snapshot.UpdateDatetime == nil) {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "currencies are not loaded yet")
+	}
+
+	previous, err := e.previousCurrencies(ctx.Request().Context(), snapshot)
+	if err != nil {
+		errMsg := "could not get previous currencies"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	if previous == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "no previous snapshot")
+	}
+
+	currentRates, err := rates.Of(*snapshot.Currencies)
+	if err != nil {
+		errMsg := "could not get rates of currencies"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	previousRates, err := rates.Of(*previous)
+	if err != nil {
+		errMsg := "could not get rates of previous currencies"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	changes := rates.Changes(previousRates, currentRates)
+
+	if (limit > 0) && (limit < len(changes)) {
+		changes = changes[:limit]
+	}
+
+	for i := range changes {
+		changes[i].Change = changes[i].Change.Round(changesPrecision)
+		changes[i].ChangePercent = changes[i].ChangePercent.Round(changesPrecision)
+
+		if lang == langEn {
+			if info, ok := iso4217.Lookup(changes[i].CharCode); ok {
+				changes[i].Name = info.NameEn
+			}
+		}
+	}
+
+	ctx.Response().Header().Set(headerRateDate, snapshot.UpdateDatetime.RateDate)
+
+	setNextUpdateAtHeader(ctx, snapshot)
+
+	err = ctx.JSON(http.StatusOK, changesResponse{
+		RateDate:         snapshot.UpdateDatetime.RateDate,
+		PreviousRateDate: previous.RateDateString(),
+		Changes:          apimodels.NewChanges(changes),
+	})
+	if err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
+
+// previousCurrencies returns the currencies of the snapshot before the
+// current one, or nil, if there is no such snapshot.
+func (e *ChangesEndpoint) previousCurrencies(ctx context.Context, snapshot *memcache.Snapshot) (*models.Currencies, error) {
+	if previous := e.memCache.Previous(); (previous != nil) && (previous.Currencies != nil) {
+		return previous.Currencies, nil
+	}
+
+	rateDate, err := time.Parse(models.RateDateLayout, snapshot.UpdateDatetime.RateDate)
+	if err != nil {
+		// The snapshot of an unknown rate date has no previous one to
+		// be found by the date.
+		return nil, nil
+	}
+
+	return latestStoredCurrencies(ctx, e.storage,
+		rateDate.AddDate(0, 0, -changesLookbackDays).Format(models.RateDateLayout),
+		rateDate.AddDate(0, 0, -1).Format(models.RateDateLayout))
+}
+
+// latestStoredCurrencies returns the currencies of the latest snapshot
+// of the rate dates from the first date to the last one inclusive, or
+// nil, if there is none.
+func latestStoredCurrencies(ctx context.Context, st storage.Storage, fromDate string, toDate string) (*models.Currencies, error) {
+	history, err := st.GetCurrencyHistory(ctx, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// The history is of several snapshots, and the latest one of them
+	// is the one of the currencies.
+	var latest models.HistoryCurrency
+
+	for _, currency := range history {
+		if (currency.RateDate > latest.RateDate) ||
+			((currency.RateDate == latest.RateDate) && (currency.UpdateDatetimeId > latest.UpdateDatetimeId)) {
+			latest = currency
+		}
+	}
+
+	if latest.UpdateDatetimeId == 0 {
+		return nil, nil
+	}
+
+	currencies := &models.Currencies{}
+
+	currencies.RateDate, _ = time.Parse(models.RateDateLayout, latest.RateDate)
+
+	for _, currency := range history {
+		if currency.UpdateDatetimeId == latest.UpdateDatetimeId {
+			currencies.Currencies = append(currencies.Currencies, currency.Currency)
+		}
+	}
+
+	return currencies, nil
+}
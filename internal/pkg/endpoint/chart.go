@@ -0,0 +1,99 @@
+package endpoint
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/chart"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+const (
+	queryDays = "days"
+
+	mimeSvg = "image/svg+xml"
+
+	chartDefaultDays = 90
+	chartMaxDays     = 3660
+)
+
+type ChartEndpoint struct {
+	config  *config.Config
+	storage storage.Storage
+	clock   clock.Clock
+}
+
+func NewChartEndpoint(cfg *config.Config, st storage.Storage, cl clock.Clock) *ChartEndpoint {
+	return &ChartEndpoint{
+		config:  cfg,
+		storage: st,
+		clock:   cl,
+	}
+}
+
+// Chart sends the SVG image of the line chart of the stored rates of
+// the currency by the char code or the numeric code over the number of
+// the days, that end today, e.g. for the query ?days=90, which is the
+// default. The image is meant to be embedded in the pages as it is.
+func (e *ChartEndpoint) Chart(ctx echo.Context) error {
+	days := chartDefaultDays
+
+	if value := ctx.QueryParam(queryDays); value != "" {
+		var err error
+
+		days, err = strconv.Atoi(value)
+		if (err != nil) || (days <= 0) || (days > chartMaxDays) {
+			return echo.NewHTTPError(http.StatusBadRequest, "days must be from 1 to "+strconv.Itoa(chartMaxDays))
+		}
+	}
+
+	to := e.clock.Now()
+	from := to.AddDate(0, 0, -days)
+
+	history, err := codeHistory(ctx.Request().Context(), e.storage, ctx.Param(paramCode),
+		from.Format(models.RateDateLayout), to.Format(models.RateDateLayout))
+	if err != nil {
+		errMsg := "could not get currency history"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	if len(history) == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "no rates of currency over period")
+	}
+
+	points := make([]chart.Point, 0, len(history))
+
+	for _, currency := range history {
+		value, err := currency.UnitValueDecimal()
+		if err != nil {
+			errMsg := "could not get rates of currency"
+
+			requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+			return errlib.Wrap(err, errMsg)
+		}
+
+		points = append(points, chart.Point{Date: currency.RateDate, Value: value})
+	}
+
+	title := history[0].CharCode + "/" + rates.BaseCharCode + ", " + strconv.Itoa(days) + " d"
+
+	if err = ctx.Blob(http.StatusOK, mimeSvg, chart.Render(title, points)); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
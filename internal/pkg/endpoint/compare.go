@@ -0,0 +1,138 @@
+package endpoint
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/iso4217"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	queryDate1 = "date1"
+	queryDate2 = "date2"
+)
+
+// A compareResponse is the rates of the currencies on the two dates
+// side by side. The rate dates are the ones of the rates, that are
+// effective on the dates.
+type compareResponse struct {
+	Date1      string            `json:"date1"`
+	Date2      string            `json:"date2"`
+	RateDate1  string            `json:"rate_date1"`
+	RateDate2  string            `json:"rate_date2"`
+	Currencies []compareCurrency `json:"currencies"`
+}
+
+// A compareCurrency is the rate of the currency on the two dates along
+// with the absolute and the percentage changes from the first date to
+// the second one.
+type compareCurrency struct {
+	CharCode      string          `json:"char_code"`
+	Name          string          `json:"name"`
+	Value1        decimal.Decimal `json:"value1"`
+	Value2        decimal.Decimal `json:"value2"`
+	Change        decimal.Decimal `json:"change"`
+	ChangePercent decimal.Decimal `json:"change_percent"`
+}
+
+type CompareEndpoint struct {
+	config  *config.Config
+	storage storage.Storage
+}
+
+func NewCompareEndpoint(cfg *config.Config, st storage.Storage) *CompareEndpoint {
+	return &CompareEndpoint{
+		config:  cfg,
+		storage: st,
+	}
+}
+
+// Compare sends the stored rates of the currencies, that are effective
+// on the two dates, side by side, e.g. for the query
+// ?date1=2024-01-08&date2=2024-01-15, from the largest percentage change
+// in either direction to the smallest one. The currencies, that are
+// missing on any of the dates, are skipped.
+func (e *CompareEndpoint) Compare(ctx echo.Context) error {
+	lang, err := requestLang(ctx)
+	if err != nil {
+		return err
+	}
+
+	date1, date2 := ctx.QueryParam(queryDate1), ctx.QueryParam(queryDate2)
+
+	if (date1 == "") || (date2 == "") {
+		return echo.NewHTTPError(http.StatusBadRequest, "date1 and date2 are required")
+	}
+
+	currencies1, err := currenciesOnDate(ctx, e.storage, date1)
+	if err != nil {
+		return err
+	}
+
+	currencies2, err := currenciesOnDate(ctx, e.storage, date2)
+	if err != nil {
+		return err
+	}
+
+	rates1, err := rates.Of(*currencies1)
+	if err != nil {
+		errMsg := "could not get rates of first date"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	rates2, err := rates.Of(*currencies2)
+	if err != nil {
+		errMsg := "could not get rates of second date"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	changes := rates.Changes(rates1, rates2)
+
+	response := compareResponse{
+		Date1:      date1,
+		Date2:      date2,
+		RateDate1:  currencies1.RateDateString(),
+		RateDate2:  currencies2.RateDateString(),
+		Currencies: make([]compareCurrency, 0, len(changes)),
+	}
+
+	for _, change := range changes {
+		currency := compareCurrency{
+			CharCode:      change.CharCode,
+			Name:          change.Name,
+			Value1:        change.Previous,
+			Value2:        change.Current,
+			Change:        change.Change.Round(changesPrecision),
+			ChangePercent: change.ChangePercent.Round(changesPrecision),
+		}
+
+		if lang == langEn {
+			if info, ok := iso4217.Lookup(currency.CharCode); ok {
+				currency.Name = info.NameEn
+			}
+		}
+
+		response.Currencies = append(response.Currencies, currency)
+	}
+
+	if err = ctx.JSON(http.StatusOK, response); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package endpoint
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	memcache "github.com/mrumyantsev/currency-converter-app/internal/pkg/mem-cache"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	queryAmount    = "amount"
+	queryFrom      = "from"
+	queryTo        = "to"
+	queryPrecision = "precision"
+	queryRounding  = "rounding"
+	queryDate      = "date"
+)
+
+// convertLookbackDays is the number of the days before the date of the
+// conversion, that the rates are looked for, as the rates are not
+// published on the weekends and the holidays.
+const convertLookbackDays = 14
+
+// A conversionResponse is the result of the conversion of the amount
+// between the currencies. The rate is the official one, and the applied
+// rate is the one less the markup, which the result is converted by.
+// The results have exactly the precision number of the decimal places.
+type conversionResponse struct {
+	Amount         decimal.Decimal `json:"amount"`
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	Rate           decimal.Decimal `json:"rate"`
+	MarkupPercent  decimal.Decimal `json:"markup_percent"`
+	AppliedRate    decimal.Decimal `json:"applied_rate"`
+	Result         string          `json:"result"`
+	OfficialResult string          `json:"official_result"`
+	Precision      int             `json:"precision"`
+	Rounding       string          `json:"rounding"`
+	RateDate       string          `json:"rate_date"`
+}
+
+type ConvertEndpoint struct {
+	config   *config.Config
+	memCache *memcache.MemCache
+	storage  storage.Storage
+}
+
+func NewConvertEndpoint(cfg *config.Config, mc *memcache.MemCache, st storage.Storage) *ConvertEndpoint {
+	return &ConvertEndpoint{
+		config:   cfg,
+		memCache: mc,
+		storage:  st,
+	}
+}
+
+// Convert sends the amount of the currency from converted to the
+// currency to by the current rates, e.g. for the query
+// ?amount=100&from=USD&to=EUR. The rate is the number of units of the
+// currency to per a unit of the currency from. The result is rounded to
+// the precision by the rounding mode, which are configured, unless they
+// are set in the query, e.g. &precision=2&rounding=bankers. The amount
+// is converted by the stored rates, that are effective on the date, if
+// it is set, e.g. &date=2023-06-01.
+func (e *ConvertEndpoint) Convert(ctx echo.Context) error {
+	amount, err := decimal.NewFromString(ctx.QueryParam(queryAmount))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid amount")
+	}
+
+	from, to := strings.ToUpper(ctx.QueryParam(queryFrom)), strings.ToUpper(ctx.QueryParam(queryTo))
+
+	if (from == "") || (to == "") {
+		return echo.NewHTTPError(http.StatusBadRequest, "from and to currencies are required")
+	}
+
+	precision := e.config.ConvertPrecision
+
+	if value := ctx.QueryParam(queryPrecision); value != "" {
+		precision, err = strconv.Atoi(value)
+		if (err != nil) || (precision < 0) || (precision > rates.MaxPrecision) {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				"precision must be from 0 to "+strconv.Itoa(rates.MaxPrecision))
+		}
+	}
+
+	rounding := e.config.ConvertRounding
+
+	if value := ctx.QueryParam(queryRounding); value != "" {
+		if !rates.IsRounding(value) {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				"rounding must be one of half-up, half-even, bankers, down or up")
+		}
+
+		rounding = value
+	}
+
+	var (
+		snapshot   *memcache.Snapshot
+		currencies *models.Currencies
+		rateDate   string
+	)
+
+	if date := ctx.QueryParam(queryDate); date != "" {
+		if currencies, err = currenciesOnDate(ctx, e.storage, date); err != nil {
+			return err
+		}
+
+		rateDate = currencies.RateDate.Format(models.RateDateLayout)
+	} else {
+		snapshot = e.memCache.Snapshot()
+
+		if snapshot.Currencies == nil {
+			return echo.NewHTTPError(http.StatusServiceUnavailable, "currencies are not loaded yet")
+		}
+
+		currencies = snapshot.Currencies
+
+		if snapshot.UpdateDatetime != nil {
+			rateDate = snapshot.UpdateDatetime.RateDate
+		}
+	}
+
+	currentRates, err := rates.Of(*currencies)
+	if err != nil {
+		errMsg := "could not get rates of currencies"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	result, err := currentRates.Convert(amount, from, to)
+	if errors.Is(err, rates.ErrUnknownCurrency) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return errlib.Wrap(err, "could not convert")
+	}
+
+	// The units are known to the rates already.
+	rate, _ := currentRates.Convert(decimal.NewFromInt(1), from, to)
+
+	markupPercent := e.markupPercent(from, to)
+
+	// The rounding mode is checked already.
+	officialResult, _ := rates.Round(result, int32(precision), rounding)
+	result, _ = rates.Round(rates.ApplyMarkup(result, markupPercent), int32(precision), rounding)
+
+	response := conversionResponse{
+		Amount:         amount,
+		From:           from,
+		To:             to,
+		Rate:           rate,
+		MarkupPercent:  markupPercent,
+		AppliedRate:    rates.ApplyMarkup(rate, markupPercent),
+		Result:         result.StringFixed(int32(precision)),
+		OfficialResult: officialResult.StringFixed(int32(precision)),
+		Precision:      precision,
+		Rounding:       rounding,
+		RateDate:       rateDate,
+	}
+
+	if rateDate != "" {
+		ctx.Response().Header().Set(headerRateDate, rateDate)
+	}
+
+	// The rates of the past do not change on the next update.
+	if snapshot != nil {
+		setNextUpdateAtHeader(ctx, snapshot)
+	}
+
+	err = sendEncoded(ctx, response, func() []byte { return conversionProto(response) }, func() error {
+		return ctx.JSON(http.StatusOK, response)
+	})
+	if err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
+
+// currenciesOnDate returns the stored currencies, that are effective on
+// the date, which are the ones of the latest rate date up to it.
+func currenciesOnDate(ctx echo.Context, st storage.Storage, date string) (*models.Currencies, error) {
+	parsedDate, err := time.Parse(models.RateDateLayout, date)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid date "+date+", expected YYYY-MM-DD")
+	}
+
+	currencies, err := latestStoredCurrencies(ctx.Request().Context(), st,
+		parsedDate.AddDate(0, 0, -convertLookbackDays).Format(models.RateDateLayout), date)
+	if err != nil {
+		errMsg := "could not get currency history"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return nil, errlib.Wrap(err, errMsg)
+	}
+
+	if currencies == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "no rates are stored for "+date)
+	}
+
+	return currencies, nil
+}
+
+// markupPercent returns the markup of the currency to, or of the
+// currency from, if there is none for the first one, or the default
+// markup otherwise.
+func (e *ConvertEndpoint) markupPercent(from string, to string) decimal.Decimal {
+	for _, charCode := range []string{to, from} {
+		if markup, ok := e.config.ConvertMarkupsPercent[charCode]; ok {
+			return decimal.NewFromFloat(markup)
+		}
+	}
+
+	return decimal.NewFromFloat(e.config.ConvertMarkupPercent)
+}
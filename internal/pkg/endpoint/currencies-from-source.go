@@ -1,22 +1,25 @@
 package endpoint
 
 import (
-	"fmt"
-	"io"
+	"context"
+	"errors"
 	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
 	"github.com/mrumyantsev/go-errlib"
-	"github.com/rs/zerolog/log"
 )
 
 const (
-	methodGet       = "GET"
-	headerUserAgent = "User-Agent"
+	currenciesDateLayout = "02/01/2006"
+	queryCurrenciesDay   = "date_req"
 )
 
+// ErrNoHistoricalData is returned, when the currency source format has
+// no way to request the rates of a past date.
+var ErrNoHistoricalData = errors.New("source does not provide historical data")
+
 type CurrenciesFromSourceEndpoint struct {
 	config *config.Config
 	client *http.Client
@@ -25,52 +28,58 @@ type CurrenciesFromSourceEndpoint struct {
 func NewCurrenciesFromSourceEndpoint(cfg *config.Config) *CurrenciesFromSourceEndpoint {
 	return &CurrenciesFromSourceEndpoint{
 		config: cfg,
-		client: new(http.Client),
+		client: newSourceClient(cfg),
 	}
 }
 
-func (e *CurrenciesFromSourceEndpoint) CurrenciesFromSource() ([]byte, error) {
-	startTime := time.Now()
+func (e *CurrenciesFromSourceEndpoint) CurrenciesFromSource(ctx context.Context) ([]byte, error) {
+	return e.CurrenciesFromUrl(ctx, e.config.CurrencySourceUrl, e.config.CurrencySourceFormat)
+}
 
-	url, err := url.Parse(e.config.CurrencySourceUrl)
+// CurrenciesFromUrl gets currencies of the format from the source by
+// the url, which may differ from the configured main source.
+func (e *CurrenciesFromSourceEndpoint) CurrenciesFromUrl(ctx context.Context, rawUrl string, format string) ([]byte, error) {
+	url, err := url.Parse(rawUrl)
 	if err != nil {
 		return nil, errlib.Wrap(err, "could not parse url")
 	}
 
-	req := e.request(url, methodGet)
-
-	resp, err := e.client.Do(req)
+	data, err := fetchFromSource(
+		ctx,
+		e.config,
+		e.client,
+		url,
+		sourceFormatOf(format),
+	)
 	if err != nil {
-		return nil, errlib.Wrap(err, "could not send request to server")
+		return nil, errlib.Wrap(err, "could not fetch currencies from source")
 	}
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, errlib.Wrap(err, "could not read data from response body")
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	elapsedTime := time.Since(startTime)
-
-	log.Debug().Msg("getting http data time overall: " + elapsedTime.String())
-
 	return data, nil
 }
 
-func (e *CurrenciesFromSourceEndpoint) request(url *url.URL, method string) *http.Request {
-	log.Debug().Msg(fmt.Sprintf("using %s protocol in request", e.config.HttpRequestProtocol))
-	log.Debug().Msg(fmt.Sprintf("using user-agent header: %s", e.config.FakeUserAgentHeaderValue))
+// CurrenciesOnDate gets currencies of the date from the main source.
+// The source responds with the latest rates before the date, if there
+// are no rates for the date itself.
+func (e *CurrenciesFromSourceEndpoint) CurrenciesOnDate(ctx context.Context, date time.Time) ([]byte, error) {
+	if e.config.CurrencySourceFormat != config.SourceFormatCbrXml {
+		return nil, ErrNoHistoricalData
+	}
 
-	if method == "" {
-		method = methodGet
+	url, err := url.Parse(e.config.CurrencySourceUrl)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not parse url")
 	}
 
-	return &http.Request{
-		Method: method,
-		URL:    url,
-		Proto:  e.config.HttpRequestProtocol,
-		Header: map[string][]string{
-			headerUserAgent: {e.config.FakeUserAgentHeaderValue},
-		},
+	query := url.Query()
+	query.Set(queryCurrenciesDay, date.Format(currenciesDateLayout))
+
+	url.RawQuery = query.Encode()
+
+	data, err := fetchFromSource(ctx, e.config, e.client, url, formatXml)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not fetch currencies from source")
 	}
+
+	return data, nil
 }
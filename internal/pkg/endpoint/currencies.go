@@ -2,39 +2,185 @@ package endpoint
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	apimodels "github.com/mrumyantsev/currency-converter-app/internal/pkg/api-models"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
 	memcache "github.com/mrumyantsev/currency-converter-app/internal/pkg/mem-cache"
-	"github.com/mrumyantsev/currency-converter-app/internal/pkg/service"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
 	"github.com/mrumyantsev/go-errlib"
-	"github.com/rs/zerolog/log"
+)
+
+const (
+	headerRateDate     = "X-Rate-Date"
+	headerNextUpdateAt = "X-Next-Update-At"
+	headerEtag         = "ETag"
+
+	encodingGzip = "gzip"
 )
 
 type CurrenciesEndpoint struct {
 	config   *config.Config
 	memCache *memcache.MemCache
-	service  service.Currencies
+	storage  storage.Storage
 }
 
-func NewCurrenciesEndpoint(cfg *config.Config, mc *memcache.MemCache, svc service.Currencies) *CurrenciesEndpoint {
+func NewCurrenciesEndpoint(cfg *config.Config, mc *memcache.MemCache, st storage.Storage) *CurrenciesEndpoint {
 	return &CurrenciesEndpoint{
 		config:   cfg,
 		memCache: mc,
-		service:  svc,
+		storage:  st,
 	}
 }
 
+// Currencies sends all currencies with the names in the requested
+// language. The ones in the language of the source are sent as they are
+// encoded beforehand. They are sent in protobuf or MessagePack, if the
+// client asks for it by the Accept header. The currencies in JSON or
+// MessagePack have only the fields, that are requested, if any.
 func (e *CurrenciesEndpoint) Currencies(ctx echo.Context) error {
-	calculatedCurrencies := e.memCache.CalculatedCurrencies()
+	lang, err := requestLang(ctx)
+	if err != nil {
+		return err
+	}
+
+	fields, err := requestProjection(ctx, apimodels.Currency{})
+	if err != nil {
+		return err
+	}
+
+	snapshot := e.memCache.Snapshot()
+
+	setFreshnessHeaders(ctx, snapshot)
+
+	currencies := snapshot.CalculatedCurrencies
+
+	if lang != langRu {
+		currencies = localizeCurrencies(currencies, lang)
+	}
+
+	body := projected(fields, apimodels.NewCurrencies(currencies))
+
+	err = sendEncoded(ctx, body, func() []byte { return currenciesProto(currencies) }, func() error {
+		if (lang != langRu) || (fields != nil) {
+			return ctx.JSON(http.StatusOK, body)
+		}
+
+		return sendCachedJson(ctx, body, snapshot.CalculatedCurrenciesJson, snapshot.CalculatedCurrenciesJsonGzip)
+	})
+	if err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
+
+// CurrenciesHead sends the headers of the freshness of the currencies
+// without them, so the age of the data is checked cheaply, e.g. by the
+// monitoring.
+func (e *CurrenciesEndpoint) CurrenciesHead(ctx echo.Context) error {
+	snapshot := e.memCache.Snapshot()
+	if snapshot.UpdateDatetime == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "currencies are not loaded yet")
+	}
+
+	setFreshnessHeaders(ctx, snapshot)
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Currency sends the currency by the char code or the numeric code.
+func (e *CurrenciesEndpoint) Currency(ctx echo.Context) error {
+	lang, err := requestLang(ctx)
+	if err != nil {
+		return err
+	}
 
-	if err := ctx.JSON(http.StatusOK, calculatedCurrencies); err != nil {
+	snapshot := e.memCache.Snapshot()
+
+	calculatedCurrency, ok := snapshot.Lookup(ctx.Param(paramCode))
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "unknown currency")
+	}
+
+	if snapshot.UpdateDatetime != nil {
+		ctx.Response().Header().Set(headerRateDate, snapshot.UpdateDatetime.RateDate)
+	}
+
+	setNextUpdateAtHeader(ctx, snapshot)
+
+	calculatedCurrency = localizeCurrency(calculatedCurrency, lang)
+
+	currency := apimodels.NewCurrency(calculatedCurrency)
+
+	err = sendEncoded(ctx, currency, func() []byte { return currencyProto(calculatedCurrency) }, func() error {
+		return ctx.JSON(http.StatusOK, currency)
+	})
+	if err != nil {
 		errMsg := "could not send reponse data"
 
-		log.Error().Err(err).Msg(errMsg)
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
 
 		return errlib.Wrap(err, errMsg)
 	}
 
 	return nil
 }
+
+// sendCachedJson sends the JSON of the value, that is encoded
+// beforehand, or the gzipped variant of it, if the client accepts it.
+// The value is encoded here, if it has not been encoded yet.
+func sendCachedJson(ctx echo.Context, v any, plain []byte, gzipped []byte) error {
+	if plain == nil {
+		return ctx.JSON(http.StatusOK, v)
+	}
+
+	header := ctx.Response().Header()
+
+	header.Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+
+	if (gzipped != nil) && strings.Contains(ctx.Request().Header.Get(echo.HeaderAcceptEncoding), encodingGzip) {
+		header.Set(echo.HeaderContentEncoding, encodingGzip)
+
+		return ctx.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, gzipped)
+	}
+
+	return ctx.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, plain)
+}
+
+// setFreshnessHeaders tells the client the date of the rates, the entity
+// tag of the currencies, when they were updated and when they are going
+// to be updated next.
+func setFreshnessHeaders(ctx echo.Context, snapshot *memcache.Snapshot) {
+	header := ctx.Response().Header()
+
+	if snapshot.UpdateDatetime != nil {
+		header.Set(headerRateDate, snapshot.UpdateDatetime.RateDate)
+
+		if updatedAt, err := time.Parse(time.RFC3339, snapshot.UpdateDatetime.UpdateDatetime); err == nil {
+			header.Set(echo.HeaderLastModified, updatedAt.UTC().Format(http.TimeFormat))
+		}
+	}
+
+	if snapshot.CalculatedCurrenciesEtag != "" {
+		header.Set(headerEtag, snapshot.CalculatedCurrenciesEtag)
+	}
+
+	setNextUpdateAtHeader(ctx, snapshot)
+}
+
+// setNextUpdateAtHeader tells the client, when the data are going to be
+// updated, so it is known, when it is worth requesting them again.
+func setNextUpdateAtHeader(ctx echo.Context, snapshot *memcache.Snapshot) {
+	if snapshot.NextUpdateAt.IsZero() {
+		return
+	}
+
+	ctx.Response().Header().Set(headerNextUpdateAt, snapshot.NextUpdateAt.Format(time.RFC3339))
+}
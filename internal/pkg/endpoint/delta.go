@@ -0,0 +1,179 @@
+package endpoint
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	apimodels "github.com/mrumyantsev/currency-converter-app/internal/pkg/api-models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	memcache "github.com/mrumyantsev/currency-converter-app/internal/pkg/mem-cache"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+const querySince = "since"
+
+// A deltaResponse is the currencies, which values have changed since
+// the update, and the char codes of the ones, that are not served
+// anymore. The update id is the one of the current currencies, which
+// the client syncs with next time.
+type deltaResponse struct {
+	UpdateId   int                  `json:"update_id"`
+	Since      int                  `json:"since"`
+	RateDate   string               `json:"rate_date"`
+	Currencies []apimodels.Currency `json:"currencies"`
+	Removed    []string             `json:"removed"`
+}
+
+type DeltaEndpoint struct {
+	config   *config.Config
+	memCache *memcache.MemCache
+	storage  storage.Storage
+}
+
+func NewDeltaEndpoint(cfg *config.Config, mc *memcache.MemCache, st storage.Storage) *DeltaEndpoint {
+	return &DeltaEndpoint{
+		config:   cfg,
+		memCache: mc,
+		storage:  st,
+	}
+}
+
+// Delta sends the current currencies, which values have changed since
+// the update, that the client has synced with last time, e.g. for the
+// query ?since=42, or no content, if none of them have changed. The
+// currencies of the update are taken from the memory cache, or from the
+// storage, if they are not there.
+func (e *DeltaEndpoint) Delta(ctx echo.Context) error {
+	lang, err := requestLang(ctx)
+	if err != nil {
+		return err
+	}
+
+	since, err := strconv.Atoi(ctx.QueryParam(querySince))
+	if (err != nil) || (since <= 0) {
+		return echo.NewHTTPError(http.StatusBadRequest, "since must be a positive update id")
+	}
+
+	snapshot := e.memCache.Snapshot()
+
+	if (snapshot.Currencies == nil) || (snapshot.UpdateDatetime == nil) {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "currencies are not loaded yet")
+	}
+
+	ctx.Response().Header().Set(headerRateDate, snapshot.UpdateDatetime.RateDate)
+
+	setNextUpdateAtHeader(ctx, snapshot)
+
+	if since == snapshot.UpdateDatetime.Id {
+		return ctx.NoContent(http.StatusNoContent)
+	}
+
+	previous, err := e.currenciesOfUpdate(ctx.Request().Context(), since)
+	if err != nil {
+		errMsg := "could not get currencies of update"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	if previous == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "unknown update "+strconv.Itoa(since))
+	}
+
+	response := deltaResponse{
+		UpdateId:   snapshot.UpdateDatetime.Id,
+		Since:      since,
+		RateDate:   snapshot.UpdateDatetime.RateDate,
+		Currencies: []apimodels.Currency{},
+		Removed:    []string{},
+	}
+
+	previousValues := make(map[string]models.Value, len(previous.Currencies))
+
+	for _, currency := range previous.Currencies {
+		previousValues[currency.CharCode] = currency.UnitValue
+	}
+
+	for _, currency := range snapshot.Currencies.Currencies {
+		value, ok := previousValues[currency.CharCode]
+
+		delete(previousValues, currency.CharCode)
+
+		if ok && equalValues(value, currency.UnitValue) {
+			continue
+		}
+
+		if calculatedCurrency, ok := snapshot.LookupCharCode(currency.CharCode); ok {
+			response.Currencies = append(response.Currencies, apimodels.NewCurrency(localizeCurrency(calculatedCurrency, lang)))
+		}
+	}
+
+	// The currencies, that are left, are the ones of the update, which
+	// are not served anymore, e.g. filtered out.
+	for _, currency := range previous.Currencies {
+		if _, ok := previousValues[currency.CharCode]; ok {
+			response.Removed = append(response.Removed, currency.CharCode)
+		}
+	}
+
+	if (len(response.Currencies) == 0) && (len(response.Removed) == 0) {
+		return ctx.NoContent(http.StatusNoContent)
+	}
+
+	if err = ctx.JSON(http.StatusOK, response); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
+
+// currenciesOfUpdate returns the currencies of the update by the id of
+// it, or nil, if the update is unknown.
+func (e *DeltaEndpoint) currenciesOfUpdate(ctx context.Context, updateDatetimeId int) (*models.Currencies, error) {
+	for _, snapshot := range e.memCache.History() {
+		if (snapshot.UpdateDatetime != nil) && (snapshot.UpdateDatetime.Id == updateDatetimeId) && (snapshot.Currencies != nil) {
+			return snapshot.Currencies, nil
+		}
+	}
+
+	currencies, err := e.storage.GetLatestCurrencies(ctx, updateDatetimeId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(currencies.Currencies) == 0 {
+		return nil, nil
+	}
+
+	return &currencies, nil
+}
+
+// equalValues reports, whether the values are the same number, e.g.
+// the ones, that differ by the trailing zeros only.
+func equalValues(a models.Value, b models.Value) bool {
+	if a == b {
+		return true
+	}
+
+	x, err := decimal.NewFromString(string(a))
+	if err != nil {
+		return false
+	}
+
+	y, err := decimal.NewFromString(string(b))
+	if err != nil {
+		return false
+	}
+
+	return x.Equal(y)
+}
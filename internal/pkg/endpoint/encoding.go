@@ -0,0 +1,157 @@
+package endpoint
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/msgpack"
+	"github.com/mrumyantsev/go-errlib"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	mimeProtobuf = "application/x-protobuf"
+	mimeMsgpack  = "application/msgpack"
+)
+
+// The encodings of the responses besides JSON, that the clients may
+// ask for by the Accept header. The aliases of the types are accepted
+// too.
+const (
+	encodingJson = iota
+	encodingProtobuf
+	encodingMsgpack
+)
+
+// responseEncoding returns the encoding of the response, that the
+// client accepts. It is JSON, unless the client asks for the other one.
+func responseEncoding(ctx echo.Context) int {
+	accept := ctx.Request().Header.Get(echo.HeaderAccept)
+
+	switch {
+	case strings.Contains(accept, mimeProtobuf), strings.Contains(accept, "application/protobuf"):
+		return encodingProtobuf
+	case strings.Contains(accept, mimeMsgpack), strings.Contains(accept, "application/x-msgpack"):
+		return encodingMsgpack
+	default:
+		return encodingJson
+	}
+}
+
+// sendEncoded sends the value in the encoding, that the client accepts.
+// The value is encoded in protobuf by the function, as the messages are
+// described by the schema in docs/api, and in JSON by the other one, so
+// the encoded JSON may be reused.
+func sendEncoded(ctx echo.Context, v any, marshalProto func() []byte, sendJson func() error) error {
+	ctx.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+
+	switch responseEncoding(ctx) {
+	case encodingProtobuf:
+		return ctx.Blob(http.StatusOK, mimeProtobuf, marshalProto())
+	case encodingMsgpack:
+		data, err := msgpack.Marshal(v)
+		if err != nil {
+			return errlib.Wrap(err, "could not encode value to msgpack")
+		}
+
+		return ctx.Blob(http.StatusOK, mimeMsgpack, data)
+	default:
+		return sendJson()
+	}
+}
+
+// The numbers of the fields of the messages in the schema.
+const (
+	protoCurrenciesCurrencies = 1
+
+	protoCurrencyName       = 1
+	protoCurrencyCharCode   = 2
+	protoCurrencyUnitValue  = 3
+	protoCurrencyRatio      = 4
+	protoCurrencySymbol     = 5
+	protoCurrencyMinorUnits = 6
+	protoCurrencyCountries  = 7
+
+	protoConversionAmount         = 1
+	protoConversionFrom           = 2
+	protoConversionTo             = 3
+	protoConversionRate           = 4
+	protoConversionMarkupPercent  = 5
+	protoConversionAppliedRate    = 6
+	protoConversionResult         = 7
+	protoConversionOfficialResult = 8
+	protoConversionPrecision      = 9
+	protoConversionRounding       = 10
+	protoConversionRateDate       = 11
+)
+
+func currenciesProto(currencies []models.CalculatedCurrency) []byte {
+	var b []byte
+
+	for _, currency := range currencies {
+		b = protowire.AppendTag(b, protoCurrenciesCurrencies, protowire.BytesType)
+		b = protowire.AppendBytes(b, currencyProto(currency))
+	}
+
+	return b
+}
+
+func currencyProto(currency models.CalculatedCurrency) []byte {
+	var b []byte
+
+	b = appendProtoString(b, protoCurrencyName, currency.Name)
+	b = appendProtoString(b, protoCurrencyCharCode, currency.CharCode)
+	b = appendProtoString(b, protoCurrencyUnitValue, currency.UnitValue)
+	b = appendProtoString(b, protoCurrencyRatio, currency.Ratio)
+	b = appendProtoString(b, protoCurrencySymbol, currency.Symbol)
+
+	// The minor units are optional, so the zero ones are sent too.
+	if currency.MinorUnits != nil {
+		b = protowire.AppendTag(b, protoCurrencyMinorUnits, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(*currency.MinorUnits))
+	}
+
+	for _, country := range currency.Countries {
+		b = protowire.AppendTag(b, protoCurrencyCountries, protowire.BytesType)
+		b = protowire.AppendString(b, country)
+	}
+
+	return b
+}
+
+func conversionProto(conversion conversionResponse) []byte {
+	var b []byte
+
+	b = appendProtoString(b, protoConversionAmount, conversion.Amount.String())
+	b = appendProtoString(b, protoConversionFrom, conversion.From)
+	b = appendProtoString(b, protoConversionTo, conversion.To)
+	b = appendProtoString(b, protoConversionRate, conversion.Rate.String())
+	b = appendProtoString(b, protoConversionMarkupPercent, conversion.MarkupPercent.String())
+	b = appendProtoString(b, protoConversionAppliedRate, conversion.AppliedRate.String())
+	b = appendProtoString(b, protoConversionResult, conversion.Result)
+	b = appendProtoString(b, protoConversionOfficialResult, conversion.OfficialResult)
+
+	if conversion.Precision != 0 {
+		b = protowire.AppendTag(b, protoConversionPrecision, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(conversion.Precision))
+	}
+
+	b = appendProtoString(b, protoConversionRounding, conversion.Rounding)
+	b = appendProtoString(b, protoConversionRateDate, conversion.RateDate)
+
+	return b
+}
+
+// appendProtoString appends the string field, unless it is empty, as
+// the empty strings are the defaults in proto3.
+func appendProtoString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+
+	return protowire.AppendString(b, s)
+}
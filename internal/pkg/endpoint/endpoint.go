@@ -1,32 +1,291 @@
 package endpoint
 
 import (
+	"context"
+	"time"
+
 	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/alerting"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/audit"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	fsops "github.com/mrumyantsev/currency-converter-app/internal/pkg/fs-ops"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/health"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/maintenance"
 	memcache "github.com/mrumyantsev/currency-converter-app/internal/pkg/mem-cache"
-	"github.com/mrumyantsev/currency-converter-app/internal/pkg/service"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
+	"github.com/rs/zerolog"
 )
 
 type CurrenciesFromSource interface {
-	CurrenciesFromSource() ([]byte, error)
+	CurrenciesFromSource(ctx context.Context) ([]byte, error)
+	CurrenciesFromUrl(ctx context.Context, rawUrl string, format string) ([]byte, error)
+	CurrenciesOnDate(ctx context.Context, date time.Time) ([]byte, error)
 }
 
 type Currencies interface {
 	Currencies(ctx echo.Context) error
+	CurrenciesHead(ctx echo.Context) error
+	Currency(ctx echo.Context) error
+}
+
+type Wait interface {
+	Wait(ctx echo.Context) error
+	Close()
+}
+
+type Changes interface {
+	Changes(ctx echo.Context) error
+}
+
+type Compare interface {
+	Compare(ctx echo.Context) error
+}
+
+type Delta interface {
+	Delta(ctx echo.Context) error
+}
+
+type Stats interface {
+	Stats(ctx echo.Context) error
+}
+
+type Chart interface {
+	Chart(ctx echo.Context) error
+}
+
+type Convert interface {
+	Convert(ctx echo.Context) error
+}
+
+type History interface {
+	History(ctx echo.Context) error
+}
+
+type MetalsFromSource interface {
+	MetalsFromSource(ctx context.Context) ([]byte, error)
+}
+
+type Metals interface {
+	Metals(ctx echo.Context) error
+}
+
+type Feed interface {
+	Feed(ctx echo.Context) error
+}
+
+type UpdateDatetime interface {
+	UpdateDatetime(ctx echo.Context) error
+}
+
+type Archive interface {
+	CurrencyData(ctx echo.Context) error
+	LatestCurrencyData(ctx echo.Context) error
+}
+
+type Metrics interface {
+	Metrics(ctx echo.Context) error
+}
+
+type Health interface {
+	Health(ctx echo.Context) error
+}
+
+type Version interface {
+	Version(ctx echo.Context) error
+}
+
+type SigningKey interface {
+	SigningKey(ctx echo.Context) error
+}
+
+type Usage interface {
+	Usage(ctx echo.Context) error
+}
+
+type Admin interface {
+	Refresh(ctx echo.Context) error
+	Audit(ctx echo.Context) error
+}
+
+type Maintenance interface {
+	Maintenance(ctx echo.Context) error
+	EnableMaintenance(ctx echo.Context) error
+	DisableMaintenance(ctx echo.Context) error
+}
+
+type Gaps interface {
+	Gaps(ctx echo.Context) error
+}
+
+type Anomalies interface {
+	Anomalies(ctx echo.Context) error
+	AcceptAnomalies(ctx echo.Context) error
+}
+
+type Alerts interface {
+	Rules(ctx echo.Context) error
+}
+
+type AlertRules interface {
+	Rules(ctx echo.Context) error
+	AddRule(ctx echo.Context) error
+	DeleteRule(ctx echo.Context) error
 }
 
 type Endpoint struct {
 	CurrenciesFromSource CurrenciesFromSource
 	Currencies           Currencies
+	Wait                 Wait
+	Changes              Changes
+	Compare              Compare
+	Delta                Delta
+	Stats                Stats
+	Chart                Chart
+	Convert              Convert
+	History              History
+	MetalsFromSource     MetalsFromSource
+	Metals               Metals
+	Feed                 Feed
+	UpdateDatetime       UpdateDatetime
+	Archive              Archive
+	Metrics              Metrics
+	Health               Health
+	Version              Version
+
+	// Alerts is nil, unless the alert rules may be viewed by the API.
+	Alerts Alerts
+
+	// SigningKey is nil, unless the responses of the API are signed.
+	SigningKey SigningKey
+
+	// Usage, Admin, Maintenance and Gaps are nil, unless the admin
+	// endpoints are enabled. Anomalies is nil also, unless the anomalies are
+	// detected, and AlertRules, unless the alerts are enabled.
+	Usage       Usage
+	Admin       Admin
+	Maintenance Maintenance
+	Gaps        Gaps
+	Anomalies   Anomalies
+	AlertRules  AlertRules
+
+	// The middleware of the routes of the API, e.g. the authentication,
+	// and of the admin ones. The routes of the monitoring have none.
+	ApiMiddleware   []echo.MiddlewareFunc
+	AdminMiddleware []echo.MiddlewareFunc
 }
 
-func New(cfg *config.Config, mc *memcache.MemCache, svc *service.Service) *Endpoint {
-	return &Endpoint{
+func New(
+	cfg *config.Config,
+	mc *memcache.MemCache,
+	st storage.Storage,
+	fo *fsops.FsOps,
+	hm *health.Monitor,
+	mm *maintenance.Mode,
+	al *alerting.Alerter,
+	au *audit.Log,
+	cl clock.Clock,
+) *Endpoint {
+	e := &Endpoint{
 		CurrenciesFromSource: NewCurrenciesFromSourceEndpoint(cfg),
-		Currencies:           NewCurrenciesEndpoint(cfg, mc, svc.Currencies),
+		Currencies:           NewCurrenciesEndpoint(cfg, mc, st),
+		Wait:                 NewWaitEndpoint(cfg, mc),
+		Changes:              NewChangesEndpoint(cfg, mc, st),
+		Compare:              NewCompareEndpoint(cfg, st),
+		Delta:                NewDeltaEndpoint(cfg, mc, st),
+		Stats:                NewStatsEndpoint(cfg, st, cl),
+		Chart:                NewChartEndpoint(cfg, st, cl),
+		Convert:              NewConvertEndpoint(cfg, mc, st),
+		History:              NewHistoryEndpoint(cfg, st, cl),
+		MetalsFromSource:     NewMetalsFromSourceEndpoint(cfg, cl),
+		Metals:               NewMetalsEndpoint(cfg, mc),
+		Feed:                 NewFeedEndpoint(cfg, st, cl),
+		UpdateDatetime:       NewUpdateDatetimeEndpoint(cfg, mc),
+		Archive:              NewArchiveEndpoint(cfg, mc, fo),
+		Metrics:              NewMetricsEndpoint(),
+		Health:               NewHealthEndpoint(cfg, hm, mm, mc, cl),
+		Version:              NewVersionEndpoint(),
+	}
+
+	if (al != nil) && cfg.IsEnableAlertsApi {
+		e.Alerts = NewAlertsEndpoint(al, au)
 	}
+
+	return e
 }
 
 func (e *Endpoint) InitRoutes(echo *echo.Echo) {
-	echo.GET("/currencies", e.Currencies.Currencies)
+	api := echo.Group("", e.ApiMiddleware...)
+
+	api.GET("/currencies", e.Currencies.Currencies)
+	api.HEAD("/currencies", e.Currencies.CurrenciesHead)
+	api.GET("/currencies/wait", e.Wait.Wait)
+	api.GET("/currencies/changes", e.Changes.Changes)
+	api.GET("/currencies/compare", e.Compare.Compare)
+	api.GET("/currencies/delta", e.Delta.Delta)
+	api.GET("/currencies/:code", e.Currencies.Currency)
+	api.GET("/currencies/:code/stats", e.Stats.Stats)
+	api.GET("/currencies/:code/chart.svg", e.Chart.Chart)
+	api.GET("/convert", e.Convert.Convert)
+	api.GET("/history", e.History.History)
+	api.GET("/update-datetime", e.UpdateDatetime.UpdateDatetime)
+	api.GET("/feed.atom", e.Feed.Feed)
+	api.GET("/metals", e.Metals.Metals)
+	api.GET("/archive/:id", e.Archive.CurrencyData)
+	api.GET("/raw/latest.xml", e.Archive.LatestCurrencyData)
+
+	if e.Alerts != nil {
+		api.GET("/alerts/rules", e.Alerts.Rules)
+	}
+
+	echo.GET("/metrics", e.Metrics.Metrics)
+	echo.GET("/healthz", e.Health.Health)
+	echo.GET("/version", e.Version.Version)
+
+	if e.SigningKey != nil {
+		echo.GET("/signing-key", e.SigningKey.SigningKey)
+	}
+
+	if (e.Usage == nil) && (e.Admin == nil) && (e.Maintenance == nil) && (e.Gaps == nil) &&
+		(e.Anomalies == nil) && (e.AlertRules == nil) {
+		return
+	}
+
+	admin := echo.Group("/admin", e.AdminMiddleware...)
+
+	if e.Usage != nil {
+		admin.GET("/usage", e.Usage.Usage)
+	}
+
+	if e.Admin != nil {
+		admin.POST("/refresh", e.Admin.Refresh)
+		admin.GET("/audit", e.Admin.Audit)
+	}
+
+	if e.Maintenance != nil {
+		admin.GET("/maintenance", e.Maintenance.Maintenance)
+		admin.PUT("/maintenance", e.Maintenance.EnableMaintenance)
+		admin.DELETE("/maintenance", e.Maintenance.DisableMaintenance)
+	}
+
+	if e.Gaps != nil {
+		admin.GET("/gaps", e.Gaps.Gaps)
+	}
+
+	if e.Anomalies != nil {
+		admin.GET("/anomalies", e.Anomalies.Anomalies)
+		admin.POST("/anomalies/accept", e.Anomalies.AcceptAnomalies)
+	}
+
+	if e.AlertRules != nil {
+		admin.GET("/alerts/rules", e.AlertRules.Rules)
+		admin.POST("/alerts/rules", e.AlertRules.AddRule)
+		admin.DELETE("/alerts/rules/:id", e.AlertRules.DeleteRule)
+	}
+}
+
+// requestLogger returns the logger of the request, that logs the
+// messages with the id of it.
+func requestLogger(ctx echo.Context) *zerolog.Logger {
+	return zerolog.Ctx(ctx.Request().Context())
 }
@@ -0,0 +1,314 @@
+package endpoint
+
+import (
+	"encoding/xml"
+	"html"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	mimeAtom = "application/atom+xml"
+
+	atomNamespace = "http://www.w3.org/2005/Atom"
+
+	feedTitle  = "Курсы валют ЦБ РФ"
+	feedAuthor = "currency-converter"
+
+	// feedDays is the length of the period, the updates of which are
+	// the entries of the feed, and feedMaxEntries is the number of the
+	// latest of them, that are sent.
+	feedDays       = 60
+	feedMaxEntries = 30
+
+	// feedNotablePercent is the smallest percentage change of the rate
+	// since the previous update, that is notable in the entry.
+	feedNotablePercent = 1
+)
+
+// An atomFeed is the feed in the Atom format (RFC 4287).
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	Id      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	Id      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Summary string      `xml:"summary"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// A feedUpdate is the update with the rates of it.
+type feedUpdate struct {
+	updateDatetime models.UpdateDatetime
+	rateDate       string
+	currencies     models.Currencies
+}
+
+type FeedEndpoint struct {
+	config  *config.Config
+	storage storage.Storage
+	clock   clock.Clock
+}
+
+func NewFeedEndpoint(cfg *config.Config, st storage.Storage, cl clock.Clock) *FeedEndpoint {
+	return &FeedEndpoint{
+		config:  cfg,
+		storage: st,
+		clock:   cl,
+	}
+}
+
+// Feed sends the Atom feed of the updates, the entry of each of which
+// has the new rates and the notable changes of them since the previous
+// update, from the latest update to the oldest one.
+func (e *FeedEndpoint) Feed(ctx echo.Context) error {
+	updates, err := e.updates(ctx)
+	if err != nil {
+		errMsg := "could not get updates"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	selfUrl := ctx.Scheme() + "://" + ctx.Request().Host + ctx.Request().URL.Path
+
+	feed := atomFeed{
+		Xmlns:   atomNamespace,
+		Title:   feedTitle,
+		Id:      selfUrl,
+		Updated: e.clock.Now().UTC().Format(time.RFC3339),
+		Link:    atomLink{Rel: "self", Href: selfUrl},
+		Author:  atomAuthor{Name: feedAuthor},
+	}
+
+	for i := len(updates) - 1; (i >= 0) && (len(feed.Entries) < feedMaxEntries); i-- {
+		var previous *feedUpdate
+
+		if i > 0 {
+			previous = &updates[i-1]
+		}
+
+		entry, err := feedEntry(selfUrl, updates[i], previous)
+		if err != nil {
+			errMsg := "could not make feed entry"
+
+			requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+			return errlib.Wrap(err, errMsg)
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	// The feed is updated, when the latest of the entries is, as the
+	// updates of the same rate date are updated in place.
+	if len(feed.Entries) > 0 {
+		feed.Updated = feed.Entries[0].Updated
+
+		for _, entry := range feed.Entries {
+			if entry.Updated > feed.Updated {
+				feed.Updated = entry.Updated
+			}
+		}
+	}
+
+	data, err := xml.Marshal(feed)
+	if err != nil {
+		errMsg := "could not encode feed"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	if err = ctx.Blob(http.StatusOK, mimeAtom, append([]byte(xml.Header), data...)); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
+
+// updates returns the updates of the period of the feed in the order of
+// the rate dates.
+func (e *FeedEndpoint) updates(ctx echo.Context) ([]feedUpdate, error) {
+	to := e.clock.Now()
+	fromDate, toDate := to.AddDate(0, 0, -feedDays).Format(models.RateDateLayout), to.Format(models.RateDateLayout)
+
+	updateDatetimes, err := e.storage.GetUpdateDatetimes(ctx.Request().Context(), fromDate, toDate)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not get update datetimes")
+	}
+
+	history, err := e.storage.GetCurrencyHistory(ctx.Request().Context(), fromDate, toDate)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not get currency history")
+	}
+
+	indices := make(map[int]int, len(updateDatetimes))
+	updates := make([]feedUpdate, 0, len(updateDatetimes))
+
+	for _, updateDatetime := range updateDatetimes {
+		indices[updateDatetime.Id] = len(updates)
+		updates = append(updates, feedUpdate{updateDatetime: updateDatetime})
+	}
+
+	for _, currency := range history {
+		i, ok := indices[currency.UpdateDatetimeId]
+		if !ok {
+			continue
+		}
+
+		updates[i].rateDate = currency.RateDate
+		updates[i].currencies.Currencies = append(updates[i].currencies.Currencies, currency.Currency)
+	}
+
+	// The updates without the currencies have nothing to show.
+	filled := updates[:0]
+
+	for _, update := range updates {
+		if len(update.currencies.Currencies) > 0 {
+			filled = append(filled, update)
+		}
+	}
+
+	sort.SliceStable(filled, func(i, j int) bool {
+		if filled[i].rateDate != filled[j].rateDate {
+			return filled[i].rateDate < filled[j].rateDate
+		}
+
+		return filled[i].updateDatetime.Id < filled[j].updateDatetime.Id
+	})
+
+	return filled, nil
+}
+
+// feedEntry returns the entry of the update with the rates of it and
+// the notable changes of them since the previous update, which may be
+// nil.
+func feedEntry(feedId string, update feedUpdate, previous *feedUpdate) (atomEntry, error) {
+	currentRates, err := rates.Of(update.currencies)
+	if err != nil {
+		return atomEntry{}, err
+	}
+
+	var changes []rates.Change
+
+	if previous != nil {
+		previousRates, err := rates.Of(previous.currencies)
+		if err != nil {
+			return atomEntry{}, err
+		}
+
+		notablePercent := decimal.NewFromInt(feedNotablePercent)
+
+		for _, change := range rates.Changes(previousRates, currentRates) {
+			if change.ChangePercent.Abs().LessThan(notablePercent) {
+				// The changes are ordered from the largest one.
+				break
+			}
+
+			changes = append(changes, change)
+		}
+	}
+
+	updated := update.updateDatetime.UpdateDatetime
+
+	if datetime, err := time.Parse(time.RFC3339, updated); err == nil {
+		updated = datetime.UTC().Format(time.RFC3339)
+	}
+
+	summary := "Курсы валют на " + update.rateDate + "."
+
+	if len(changes) > 0 {
+		notable := make([]string, 0, len(changes))
+
+		for _, change := range changes {
+			notable = append(notable, change.CharCode+" "+signedPercent(change.ChangePercent))
+		}
+
+		summary += " Заметные изменения: " + strings.Join(notable, ", ") + "."
+	}
+
+	var content strings.Builder
+
+	if len(changes) > 0 {
+		content.WriteString("<p>Заметные изменения:</p><ul>")
+
+		for _, change := range changes {
+			content.WriteString("<li>" + html.EscapeString(change.Name) + " (" + change.CharCode + "): " +
+				change.Previous.String() + " → " + change.Current.String() +
+				" (" + signedPercent(change.ChangePercent) + ")</li>")
+		}
+
+		content.WriteString("</ul>")
+	}
+
+	content.WriteString("<table><tr><th>Код</th><th>Валюта</th><th>Курс, " + rates.BaseCharCode + "</th></tr>")
+
+	for _, rate := range currentRates.Rates {
+		content.WriteString("<tr><td>" + rate.CharCode + "</td><td>" + html.EscapeString(rate.Name) +
+			"</td><td>" + rate.Value.String() + "</td></tr>")
+	}
+
+	content.WriteString("</table>")
+
+	return atomEntry{
+		Title:   "Курсы валют на " + update.rateDate,
+		Id:      feedId + "#" + strconv.Itoa(update.updateDatetime.Id),
+		Updated: updated,
+		Summary: summary,
+		Content: atomContent{Type: "html", Body: content.String()},
+	}, nil
+}
+
+// signedPercent returns the percentage change with the sign, e.g.
+// +1.25%.
+func signedPercent(percent decimal.Decimal) string {
+	text := percent.StringFixed(2) + "%"
+
+	if percent.IsPositive() {
+		text = "+" + text
+	}
+
+	return text
+}
@@ -0,0 +1,38 @@
+package endpoint
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/projection"
+)
+
+const queryFields = "fields"
+
+// requestProjection returns the projection of the values of the type of
+// the value to the fields, that are requested, e.g. by the query
+// ?fields=char_code,unit_value, or nil, if they are not requested.
+func requestProjection(ctx echo.Context, v any) (*projection.Projection, error) {
+	fields := ctx.QueryParam(queryFields)
+	if fields == "" {
+		return nil, nil
+	}
+
+	p, err := projection.New(v, strings.Split(fields, ","))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid fields: "+err.Error())
+	}
+
+	return p, nil
+}
+
+// projected returns the values with the fields of the projection only,
+// or the values, if the projection is nil.
+func projected[T any](p *projection.Projection, values []T) any {
+	if p == nil {
+		return values
+	}
+
+	return projection.Apply(p, values)
+}
@@ -0,0 +1,77 @@
+package endpoint
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// A GapFinder finds the rate dates, that the rates are missing on in
+// the storage.
+type GapFinder interface {
+	MissingRateDates(ctx context.Context, fromDate string, toDate string) ([]string, error)
+}
+
+// A gapsResponse is the rate dates of the period from the first date to
+// the last one inclusive, that the rates are missing on.
+type gapsResponse struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Missing []string `json:"missing"`
+}
+
+type GapsEndpoint struct {
+	gapFinder GapFinder
+	clock     clock.Clock
+}
+
+func NewGapsEndpoint(gf GapFinder, cl clock.Clock) *GapsEndpoint {
+	return &GapsEndpoint{
+		gapFinder: gf,
+		clock:     cl,
+	}
+}
+
+// Gaps sends the rate dates of the period, that the rates are expected
+// on according to the calendar of the source, but are missing in the
+// storage, e.g. for the query ?from=2024-01-01&to=2024-01-31. The period
+// is the current month up to today by default.
+func (e *GapsEndpoint) Gaps(ctx echo.Context) error {
+	fromDate, toDate, err := monthPeriod(ctx, e.clock.Now().Format(models.RateDateLayout))
+	if err != nil {
+		return err
+	}
+
+	missing, err := e.gapFinder.MissingRateDates(ctx.Request().Context(), fromDate, toDate)
+	if err != nil {
+		errMsg := "could not find missing rate dates"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	if missing == nil {
+		missing = []string{}
+	}
+
+	response := gapsResponse{
+		From:    fromDate,
+		To:      toDate,
+		Missing: missing,
+	}
+
+	if err = ctx.JSON(http.StatusOK, response); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
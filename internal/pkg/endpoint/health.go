@@ -0,0 +1,146 @@
+package endpoint
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	apimodels "github.com/mrumyantsev/currency-converter-app/internal/pkg/api-models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/health"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/maintenance"
+	memcache "github.com/mrumyantsev/currency-converter-app/internal/pkg/mem-cache"
+	timechecks "github.com/mrumyantsev/currency-converter-app/internal/pkg/time-checks"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/version"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	healthStatusOk          = "ok"
+	healthStatusUnavailable = "unavailable"
+)
+
+type healthResponse struct {
+	Status        string                   `json:"status"`
+	Version       apimodels.Version        `json:"version"`
+	Database      apimodels.DatabaseStatus `json:"database"`
+	Data          dataStatus               `json:"data"`
+	IsMaintenance bool                     `json:"is_maintenance"`
+}
+
+// A dataStatus describes the currencies, that are served.
+type dataStatus struct {
+	Source        string                   `json:"source"`
+	FetchedAt     time.Time                `json:"fetched_at"`
+	IsStale       bool                     `json:"is_stale"`
+	UpdateFailure *apimodels.UpdateFailure `json:"update_failure,omitempty"`
+	UnchangedData *apimodels.UnchangedData `json:"unchanged_data,omitempty"`
+	NextUpdateAt  *time.Time               `json:"next_update_at,omitempty"`
+
+	// The metals are updated apart from the currencies, so the failure
+	// of them does not make the currencies stale.
+	MetalsUpdateFailure *apimodels.UpdateFailure `json:"metals_update_failure,omitempty"`
+}
+
+type HealthEndpoint struct {
+	config      *config.Config
+	monitor     *health.Monitor
+	maintenance *maintenance.Mode
+	memCache    *memcache.MemCache
+	timeChecks  *timechecks.TimeChecks
+	clock       clock.Clock
+}
+
+func NewHealthEndpoint(cfg *config.Config, hm *health.Monitor, mm *maintenance.Mode, mc *memcache.MemCache, cl clock.Clock) *HealthEndpoint {
+	return &HealthEndpoint{
+		config:      cfg,
+		monitor:     hm,
+		maintenance: mm,
+		memCache:    mc,
+		timeChecks:  timechecks.New(cfg, cl),
+		clock:       cl,
+	}
+}
+
+// Health sends the status of the application along with the result of
+// the latest check of the database and the metadata of the currencies.
+// It responds with the status 503, when the database is unreachable.
+// The stale currencies and the maintenance mode are only reported, as
+// the application is still up.
+func (e *HealthEndpoint) Health(ctx echo.Context) error {
+	snapshot := e.memCache.Snapshot()
+
+	response := healthResponse{
+		Status:   healthStatusOk,
+		Version:  apimodels.NewVersion(version.Get()),
+		Database: apimodels.NewDatabaseStatus(e.monitor.Status()),
+		Data: dataStatus{
+			Source:    snapshot.Source,
+			FetchedAt: snapshot.FetchedAt,
+			IsStale:   e.isStale(snapshot),
+		},
+		IsMaintenance: e.maintenance.IsEnabled(),
+	}
+
+	if failure := snapshot.UpdateFailure; failure != nil {
+		response.Data.UpdateFailure = &apimodels.UpdateFailure{
+			Error:    failure.Error,
+			FailedAt: failure.FailedAt,
+		}
+	}
+
+	if failure := snapshot.MetalsUpdateFailure; failure != nil {
+		response.Data.MetalsUpdateFailure = &apimodels.UpdateFailure{
+			Error:    failure.Error,
+			FailedAt: failure.FailedAt,
+		}
+	}
+
+	if unchanged := snapshot.UnchangedData; unchanged != nil {
+		response.Data.UnchangedData = &apimodels.UnchangedData{
+			Checksum:  unchanged.Checksum,
+			CheckedAt: unchanged.CheckedAt,
+		}
+	}
+
+	if !snapshot.NextUpdateAt.IsZero() {
+		response.Data.NextUpdateAt = &snapshot.NextUpdateAt
+	}
+
+	code := http.StatusOK
+
+	if !response.Database.IsUp {
+		response.Status = healthStatusUnavailable
+		code = http.StatusServiceUnavailable
+	}
+
+	if err := ctx.JSON(code, response); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
+
+// isStale reports, whether the currencies of the snapshot were fetched
+// longer than the stale data age ago, not counting the non-publishing
+// days, or the latest update failed, or are not loaded at all.
+func (e *HealthEndpoint) isStale(snapshot *memcache.Snapshot) bool {
+	if snapshot.FetchedAt.IsZero() || (snapshot.UpdateFailure != nil) {
+		return true
+	}
+
+	age, err := e.timeChecks.PublishingAge(snapshot.FetchedAt, e.clock.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("could not get age of data")
+
+		return false
+	}
+
+	return age > e.config.StaleDataAge
+}
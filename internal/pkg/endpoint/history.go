@@ -0,0 +1,193 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	historyio "github.com/mrumyantsev/currency-converter-app/internal/pkg/history-io"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/projection"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+const (
+	queryCode   = "code"
+	queryFormat = "format"
+
+	mimeNdjson = "application/x-ndjson"
+
+	// historyDefaultDays is the length of the period of the history,
+	// when the first date is not requested.
+	historyDefaultDays = 30
+)
+
+// A historyRecord is the rate of a currency on a date in the past.
+type historyRecord struct {
+	RateDate  string `json:"rate_date"`
+	CharCode  string `json:"char_code"`
+	Name      string `json:"name"`
+	UnitValue string `json:"unit_value"`
+}
+
+type HistoryEndpoint struct {
+	config  *config.Config
+	storage storage.Storage
+	clock   clock.Clock
+}
+
+func NewHistoryEndpoint(cfg *config.Config, st storage.Storage, cl clock.Clock) *HistoryEndpoint {
+	return &HistoryEndpoint{
+		config:  cfg,
+		storage: st,
+		clock:   cl,
+	}
+}
+
+// History sends the stored rates of the period from the first date to
+// the last one inclusive, e.g. for the query
+// ?from=2024-01-01&to=2024-01-31&code=USD. The period ends today and
+// lasts 30 days by default, and the rates of all currencies are sent,
+// if the code is not requested. The rates are streamed as the
+// newline-delimited JSON, if it is requested by the query &format=ndjson
+// or by the Accept header, so the history of many years is never kept in
+// memory at once. The rates have only the fields, that are requested,
+// if any.
+func (e *HistoryEndpoint) History(ctx echo.Context) error {
+	fields, err := requestProjection(ctx, historyRecord{})
+	if err != nil {
+		return err
+	}
+
+	toDate := ctx.QueryParam(queryTo)
+	if toDate == "" {
+		toDate = e.clock.Now().Format(models.RateDateLayout)
+	}
+
+	to, err := time.Parse(models.RateDateLayout, toDate)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid last date, expected YYYY-MM-DD")
+	}
+
+	fromDate := ctx.QueryParam(queryFrom)
+	if fromDate == "" {
+		fromDate = to.AddDate(0, 0, -historyDefaultDays).Format(models.RateDateLayout)
+	}
+
+	if _, err = time.Parse(models.RateDateLayout, fromDate); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid first date, expected YYYY-MM-DD")
+	}
+
+	if fromDate > toDate {
+		return echo.NewHTTPError(http.StatusBadRequest, "first date is after last date")
+	}
+
+	code := ctx.QueryParam(queryCode)
+
+	if (ctx.QueryParam(queryFormat) == historyio.FormatNdjson) ||
+		strings.Contains(ctx.Request().Header.Get(echo.HeaderAccept), mimeNdjson) {
+		return e.streamHistory(ctx, fromDate, toDate, code, fields)
+	}
+
+	history, err := e.storage.GetCurrencyHistory(ctx.Request().Context(), fromDate, toDate)
+	if err != nil {
+		errMsg := "could not get currency history"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	records := historyRecords(history, code)
+
+	if err = ctx.JSON(http.StatusOK, projected(fields, records)); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
+
+// streamHistory sends the rates of the period as the newline-delimited
+// JSON, a record per line, fetching them window by window. The status
+// is sent with the first window, so the failure to fetch it is still
+// responded with the error.
+func (e *HistoryEndpoint) streamHistory(
+	ctx echo.Context,
+	fromDate string,
+	toDate string,
+	code string,
+	fields *projection.Projection,
+) error {
+	response := ctx.Response()
+	encoder := json.NewEncoder(response)
+
+	err := historyio.ForEachWindow(ctx.Request().Context(), e.storage.GetCurrencyHistory, fromDate, toDate,
+		func(history []models.HistoryCurrency) error {
+			if !response.Committed {
+				response.Header().Set(echo.HeaderContentType, mimeNdjson)
+				response.WriteHeader(http.StatusOK)
+			}
+
+			for _, record := range historyRecords(history, code) {
+				var v any = record
+
+				if fields != nil {
+					v = fields.Value(record)
+				}
+
+				if err := encoder.Encode(v); err != nil {
+					return errlib.Wrap(err, "could not write record")
+				}
+			}
+
+			response.Flush()
+
+			return nil
+		})
+	if err != nil {
+		// The stream, that has been started, is cut short.
+		errMsg := "could not stream currency history"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	if !response.Committed {
+		return ctx.Blob(http.StatusOK, mimeNdjson, nil)
+	}
+
+	return nil
+}
+
+// historyRecords returns the records of the currencies of the history,
+// or of the one by the code, if it is set.
+func historyRecords(history []models.HistoryCurrency, code string) []historyRecord {
+	records := make([]historyRecord, 0, len(history))
+
+	for _, currency := range history {
+		if (code != "") && !strings.EqualFold(currency.CharCode, code) {
+			continue
+		}
+
+		currency.NormalizeUnitValue()
+
+		records = append(records, historyRecord{
+			RateDate:  currency.RateDate,
+			CharCode:  currency.CharCode,
+			Name:      currency.Name,
+			UnitValue: string(currency.UnitValue),
+		})
+	}
+
+	return records
+}
@@ -0,0 +1,78 @@
+package endpoint
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/iso4217"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+)
+
+// The languages of the names of the currencies and the metals. The
+// names in Russian are the ones of the source.
+const (
+	queryLang = "lang"
+
+	langRu = "ru"
+	langEn = "en"
+)
+
+// metalCharCodes are the ISO 4217 codes of the metals by the codes of
+// the source.
+var metalCharCodes = map[int]string{
+	1: "XAU",
+	2: "XAG",
+	3: "XPT",
+	4: "XPD",
+}
+
+// requestLang returns the language of the names, that is requested in
+// the query, or the default one.
+func requestLang(ctx echo.Context) (string, error) {
+	switch lang := ctx.QueryParam(queryLang); lang {
+	case "":
+		return langRu, nil
+	case langRu, langEn:
+		return lang, nil
+	default:
+		return "", echo.NewHTTPError(http.StatusBadRequest, "lang must be ru or en")
+	}
+}
+
+// localizeCurrency returns the currency with the name in the language.
+// The name of the source is kept, if there is no name in the language.
+func localizeCurrency(currency models.CalculatedCurrency, lang string) models.CalculatedCurrency {
+	if lang == langEn {
+		if info, ok := iso4217.Lookup(currency.CharCode); ok {
+			currency.Name = info.NameEn
+		}
+	}
+
+	return currency
+}
+
+func localizeCurrencies(currencies []models.CalculatedCurrency, lang string) []models.CalculatedCurrency {
+	localized := make([]models.CalculatedCurrency, 0, len(currencies))
+
+	for _, currency := range currencies {
+		localized = append(localized, localizeCurrency(currency, lang))
+	}
+
+	return localized
+}
+
+func localizeMetals(metals []models.CalculatedMetal, lang string) []models.CalculatedMetal {
+	localized := make([]models.CalculatedMetal, 0, len(metals))
+
+	for _, metal := range metals {
+		if lang == langEn {
+			if info, ok := iso4217.Lookup(metalCharCodes[metal.Code]); ok {
+				metal.Name = info.NameEn
+			}
+		}
+
+		localized = append(localized, metal)
+	}
+
+	return localized
+}
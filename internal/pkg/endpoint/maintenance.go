@@ -0,0 +1,81 @@
+package endpoint
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/audit"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/maintenance"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// A maintenanceResponse is whether the maintenance mode is on and since
+// when.
+type maintenanceResponse struct {
+	IsEnabled bool       `json:"is_enabled"`
+	Since     *time.Time `json:"since,omitempty"`
+}
+
+type MaintenanceEndpoint struct {
+	maintenance *maintenance.Mode
+	audit       *audit.Log
+}
+
+// NewMaintenanceEndpoint creates the endpoint, that records the switches
+// of the maintenance mode in the audit log, which may be nil to record
+// nothing.
+func NewMaintenanceEndpoint(mm *maintenance.Mode, au *audit.Log) *MaintenanceEndpoint {
+	return &MaintenanceEndpoint{
+		maintenance: mm,
+		audit:       au,
+	}
+}
+
+// Maintenance sends whether the maintenance mode is on.
+func (e *MaintenanceEndpoint) Maintenance(ctx echo.Context) error {
+	return e.sendStatus(ctx)
+}
+
+// EnableMaintenance turns the maintenance mode on, so the API responds
+// with the status 503, while the data are still updated.
+func (e *MaintenanceEndpoint) EnableMaintenance(ctx echo.Context) error {
+	if e.maintenance.Set(true) {
+		requestLogger(ctx).Warn().Msg("maintenance mode enabled")
+
+		recordAudit(ctx, e.audit, audit.ActionEnableMaintenance, nil)
+	}
+
+	return e.sendStatus(ctx)
+}
+
+// DisableMaintenance turns the maintenance mode off.
+func (e *MaintenanceEndpoint) DisableMaintenance(ctx echo.Context) error {
+	if e.maintenance.Set(false) {
+		requestLogger(ctx).Info().Msg("maintenance mode disabled")
+
+		recordAudit(ctx, e.audit, audit.ActionDisableMaintenance, nil)
+	}
+
+	return e.sendStatus(ctx)
+}
+
+func (e *MaintenanceEndpoint) sendStatus(ctx echo.Context) error {
+	status := e.maintenance.Status()
+
+	response := maintenanceResponse{IsEnabled: status.IsEnabled}
+
+	if status.IsEnabled {
+		response.Since = &status.Since
+	}
+
+	if err := ctx.JSON(http.StatusOK, response); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package endpoint
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+const (
+	metalsDateLayout   = "02/01/2006"
+	queryMetalsFromDay = "date_req1"
+	queryMetalsToDay   = "date_req2"
+)
+
+type MetalsFromSourceEndpoint struct {
+	config *config.Config
+	client *http.Client
+	clock  clock.Clock
+}
+
+func NewMetalsFromSourceEndpoint(cfg *config.Config, cl clock.Clock) *MetalsFromSourceEndpoint {
+	return &MetalsFromSourceEndpoint{
+		config: cfg,
+		client: newSourceClient(cfg),
+		clock:  cl,
+	}
+}
+
+// MetalsFromSource gets precious metals quotations for the last days.
+// The range of days is needed, because the source does not publish
+// quotations on weekends and holidays. The days are of the update
+// timezone, as the ones of the currencies.
+func (e *MetalsFromSourceEndpoint) MetalsFromSource(ctx context.Context) ([]byte, error) {
+	url, err := url.Parse(e.config.MetalSourceUrl)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not parse url")
+	}
+
+	location, err := time.LoadLocation(e.config.UpdateTimezone)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not load update timezone")
+	}
+
+	toDay := e.clock.Now().In(location)
+	fromDay := toDay.AddDate(0, 0, -e.config.MetalSourceDaysRange)
+
+	query := url.Query()
+	query.Set(queryMetalsFromDay, fromDay.Format(metalsDateLayout))
+	query.Set(queryMetalsToDay, toDay.Format(metalsDateLayout))
+
+	url.RawQuery = query.Encode()
+
+	data, err := fetchFromSource(ctx, e.config, e.client, url, formatXml)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not fetch metals from source")
+	}
+
+	return data, nil
+}
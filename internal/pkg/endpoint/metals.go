@@ -0,0 +1,52 @@
+package endpoint
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	apimodels "github.com/mrumyantsev/currency-converter-app/internal/pkg/api-models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	memcache "github.com/mrumyantsev/currency-converter-app/internal/pkg/mem-cache"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+type MetalsEndpoint struct {
+	config   *config.Config
+	memCache *memcache.MemCache
+}
+
+func NewMetalsEndpoint(cfg *config.Config, mc *memcache.MemCache) *MetalsEndpoint {
+	return &MetalsEndpoint{
+		config:   cfg,
+		memCache: mc,
+	}
+}
+
+// Metals sends the latest quotations of the metals with the names in
+// the requested language. They have only the fields, that are
+// requested, if any.
+func (e *MetalsEndpoint) Metals(ctx echo.Context) error {
+	lang, err := requestLang(ctx)
+	if err != nil {
+		return err
+	}
+
+	fields, err := requestProjection(ctx, apimodels.Metal{})
+	if err != nil {
+		return err
+	}
+
+	snapshot := e.memCache.Snapshot()
+
+	setNextUpdateAtHeader(ctx, snapshot)
+
+	if err = ctx.JSON(http.StatusOK, projected(fields, apimodels.NewMetals(localizeMetals(snapshot.CalculatedMetals, lang)))); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package endpoint
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type MetricsEndpoint struct {
+	handler echo.HandlerFunc
+}
+
+func NewMetricsEndpoint() *MetricsEndpoint {
+	return &MetricsEndpoint{
+		handler: echo.WrapHandler(promhttp.Handler()),
+	}
+}
+
+// Metrics serves the application metrics in the Prometheus format.
+func (e *MetricsEndpoint) Metrics(ctx echo.Context) error {
+	return e.handler(ctx)
+}
@@ -0,0 +1,44 @@
+package endpoint
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/signer"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// A signingKeyResponse is the public key, that verifies the signatures
+// of the responses.
+type signingKeyResponse struct {
+	Algorithm string `json:"algorithm"`
+	KeyId     string `json:"key_id"`
+	PublicKey string `json:"public_key"`
+}
+
+type SigningKeyEndpoint struct {
+	signer *signer.Signer
+}
+
+func NewSigningKeyEndpoint(s *signer.Signer) *SigningKeyEndpoint {
+	return &SigningKeyEndpoint{signer: s}
+}
+
+// SigningKey sends the base64 encoded public key, that verifies the
+// signatures of the responses of the API, along with the id of it.
+func (e *SigningKeyEndpoint) SigningKey(ctx echo.Context) error {
+	err := ctx.JSON(http.StatusOK, signingKeyResponse{
+		Algorithm: signer.Algorithm,
+		KeyId:     e.signer.KeyId(),
+		PublicKey: e.signer.PublicKey(),
+	})
+	if err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package endpoint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	methodGet         = "GET"
+	headerUserAgent   = "User-Agent"
+	headerContentType = "Content-Type"
+)
+
+var (
+	ErrUnexpectedStatus      = errors.New("unexpected response status")
+	ErrUnexpectedContentType = errors.New("unexpected response content type")
+	ErrResponseTooLarge      = errors.New("response body is too large")
+	ErrResponseTruncated     = errors.New("response body is truncated")
+)
+
+// A sourceFormat describes how the data of the source is expected to
+// look like.
+type sourceFormat struct {
+	contentTypes []string
+	closingChar  byte
+}
+
+var (
+	formatXml = sourceFormat{
+		contentTypes: []string{"xml"},
+		closingChar:  '>',
+	}
+	formatJson = sourceFormat{
+		contentTypes: []string{"json", "javascript"},
+		closingChar:  '}',
+	}
+)
+
+// sourceFormatOf returns the format of the data of the currency source
+// format.
+func sourceFormatOf(currencySourceFormat string) sourceFormat {
+	if currencySourceFormat == config.SourceFormatCbrJson {
+		return formatJson
+	}
+
+	return formatXml
+}
+
+func (f sourceFormat) isContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+
+	for _, expected := range f.contentTypes {
+		if strings.Contains(contentType, expected) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newSourceClient creates the client of the sources, that gives up on
+// the source, which does not respond in the configured timeout, so it
+// does not block the update.
+func newSourceClient(cfg *config.Config) *http.Client {
+	return &http.Client{Timeout: cfg.SourceTimeout}
+}
+
+// fetchFromSource sends a request to the source by the url and returns
+// the response body data. The response is rejected, if it does not
+// match the format or its body exceeds the configured size. The request
+// is aborted, when the context is canceled.
+func fetchFromSource(
+	ctx context.Context,
+	cfg *config.Config,
+	client *http.Client,
+	url *url.URL,
+	format sourceFormat,
+) ([]byte, error) {
+	startTime := time.Now()
+
+	req, err := request(ctx, cfg, url, methodGet)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not create request")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not send request to server")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errlib.Wrap(ErrUnexpectedStatus, resp.Status)
+	}
+
+	contentType := resp.Header.Get(headerContentType)
+
+	if !format.isContentType(contentType) {
+		return nil, errlib.Wrap(ErrUnexpectedContentType, contentType)
+	}
+
+	if resp.ContentLength > cfg.SourceMaxResponseSize {
+		return nil, ErrResponseTooLarge
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, cfg.SourceMaxResponseSize+1))
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not read data from response body")
+	}
+
+	if int64(len(data)) > cfg.SourceMaxResponseSize {
+		return nil, ErrResponseTooLarge
+	}
+
+	if isTruncated(data, resp.ContentLength, format) {
+		return nil, ErrResponseTruncated
+	}
+
+	elapsedTime := time.Since(startTime)
+
+	log.Debug().Msg("getting http data time overall: " + elapsedTime.String())
+
+	return data, nil
+}
+
+func request(ctx context.Context, cfg *config.Config, url *url.URL, method string) (*http.Request, error) {
+	log.Debug().Msg(fmt.Sprintf("using %s protocol in request", cfg.HttpRequestProtocol))
+	log.Debug().Msg(fmt.Sprintf("using user-agent header: %s", cfg.FakeUserAgentHeaderValue))
+
+	if method == "" {
+		method = methodGet
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Proto = cfg.HttpRequestProtocol
+	req.Header.Set(headerUserAgent, cfg.FakeUserAgentHeaderValue)
+
+	return req, nil
+}
+
+func isTruncated(data []byte, contentLength int64, format sourceFormat) bool {
+	if (contentLength >= 0) && (int64(len(data)) != contentLength) {
+		return true
+	}
+
+	data = bytes.TrimSpace(data)
+
+	return (len(data) == 0) || (data[len(data)-1] != format.closingChar)
+}
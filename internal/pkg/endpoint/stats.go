@@ -0,0 +1,185 @@
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	paramCode   = "code"
+	queryPeriod = "period"
+
+	statsDefaultPeriod = "30d"
+
+	// statsPrecision is the number of decimal places of the statistics.
+	statsPrecision = 4
+)
+
+var errInvalidPeriod = errors.New("invalid period")
+
+// A statsResponse is the statistics of the rates of the currency over
+// the period from the first date to the last one inclusive.
+type statsResponse struct {
+	CharCode string `json:"char_code"`
+	Name     string `json:"name"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	rates.Stats
+}
+
+type StatsEndpoint struct {
+	config  *config.Config
+	storage storage.Storage
+	clock   clock.Clock
+}
+
+func NewStatsEndpoint(cfg *config.Config, st storage.Storage, cl clock.Clock) *StatsEndpoint {
+	return &StatsEndpoint{
+		config:  cfg,
+		storage: st,
+		clock:   cl,
+	}
+}
+
+// Stats sends the minimum, the maximum, the mean and the volatility of
+// the stored rates of the currency by the char code or the numeric code
+// over the period, that ends today, e.g. for the query ?period=30d. The
+// period is a number of days, weeks, months or years, e.g. 2w, 6m or 1y,
+// and it is 30 days by default.
+func (e *StatsEndpoint) Stats(ctx echo.Context) error {
+	period := ctx.QueryParam(queryPeriod)
+	if period == "" {
+		period = statsDefaultPeriod
+	}
+
+	to := e.clock.Now()
+
+	from, err := periodStart(to, period)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid period, expected a number of days, weeks, months or years, e.g. 30d")
+	}
+
+	fromDate, toDate := from.Format(models.RateDateLayout), to.Format(models.RateDateLayout)
+
+	history, err := codeHistory(ctx.Request().Context(), e.storage, ctx.Param(paramCode), fromDate, toDate)
+	if err != nil {
+		errMsg := "could not get currency history"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	if len(history) == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "no rates of currency over period")
+	}
+
+	values := make([]decimal.Decimal, 0, len(history))
+
+	for _, currency := range history {
+		value, err := currency.UnitValueDecimal()
+		if err != nil {
+			errMsg := "could not get rates of currency"
+
+			requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+			return errlib.Wrap(err, errMsg)
+		}
+
+		values = append(values, value)
+	}
+
+	stats := rates.Summarize(values)
+
+	stats.Mean = stats.Mean.Round(statsPrecision)
+	stats.Volatility = stats.Volatility.Round(statsPrecision)
+
+	latest := history[len(history)-1]
+
+	err = ctx.JSON(http.StatusOK, statsResponse{
+		CharCode: latest.CharCode,
+		Name:     latest.Name,
+		From:     fromDate,
+		To:       toDate,
+		Stats:    stats,
+	})
+	if err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
+
+// periodStart returns the date, the period of a number of days, weeks,
+// months or years, e.g. 30d, 2w, 6m or 1y, ending at the date, starts
+// at.
+func periodStart(end time.Time, period string) (time.Time, error) {
+	if len(period) < 2 {
+		return time.Time{}, errInvalidPeriod
+	}
+
+	count, err := strconv.Atoi(period[:len(period)-1])
+	if (err != nil) || (count <= 0) {
+		return time.Time{}, errInvalidPeriod
+	}
+
+	switch period[len(period)-1] {
+	case 'd':
+		return end.AddDate(0, 0, -count), nil
+	case 'w':
+		return end.AddDate(0, 0, -7*count), nil
+	case 'm':
+		return end.AddDate(0, -count, 0), nil
+	case 'y':
+		return end.AddDate(-count, 0, 0), nil
+	default:
+		return time.Time{}, errInvalidPeriod
+	}
+}
+
+// codeHistory returns the stored rates of the currency by the char code
+// or the numeric code of the period from the first date to the last one
+// inclusive, in the order of the dates.
+func codeHistory(ctx context.Context, st storage.Storage, code string, fromDate string, toDate string) ([]models.HistoryCurrency, error) {
+	history, err := st.GetCurrencyHistory(ctx, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	numCode, err := strconv.Atoi(code)
+	if err != nil {
+		numCode = 0
+	}
+
+	var currencyHistory []models.HistoryCurrency
+
+	for _, currency := range history {
+		if strings.EqualFold(currency.CharCode, code) || ((numCode != 0) && (currency.NumCode == numCode)) {
+			currencyHistory = append(currencyHistory, currency)
+		}
+	}
+
+	sort.SliceStable(currencyHistory, func(i, j int) bool {
+		return currencyHistory[i].RateDate < currencyHistory[j].RateDate
+	})
+
+	return currencyHistory, nil
+}
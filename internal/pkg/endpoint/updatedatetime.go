@@ -0,0 +1,45 @@
+package endpoint
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	apimodels "github.com/mrumyantsev/currency-converter-app/internal/pkg/api-models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	memcache "github.com/mrumyantsev/currency-converter-app/internal/pkg/mem-cache"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+type UpdateDatetimeEndpoint struct {
+	config   *config.Config
+	memCache *memcache.MemCache
+}
+
+func NewUpdateDatetimeEndpoint(cfg *config.Config, mc *memcache.MemCache) *UpdateDatetimeEndpoint {
+	return &UpdateDatetimeEndpoint{
+		config:   cfg,
+		memCache: mc,
+	}
+}
+
+// UpdateDatetime sends when the currencies were updated and the date,
+// they are effective on according to the source, along with the id of
+// the update, that the client syncs with.
+func (e *UpdateDatetimeEndpoint) UpdateDatetime(ctx echo.Context) error {
+	snapshot := e.memCache.Snapshot()
+	if snapshot.UpdateDatetime == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "currencies are not loaded yet")
+	}
+
+	setNextUpdateAtHeader(ctx, snapshot)
+
+	if err := ctx.JSON(http.StatusOK, apimodels.NewUpdateDatetime(*snapshot.UpdateDatetime)); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package endpoint
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/quota"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// A usageResponse is the usage of the API keys over the period from the
+// first date to the last one inclusive.
+type usageResponse struct {
+	From string     `json:"from"`
+	To   string     `json:"to"`
+	Keys []keyUsage `json:"keys"`
+}
+
+// A keyUsage is the usage of the API key by the dates along with the
+// quotas of it. The quota of zero is unlimited.
+type keyUsage struct {
+	KeyName      string     `json:"key_name"`
+	Requests     int64      `json:"requests"`
+	DailyQuota   int64      `json:"daily_quota"`
+	MonthlyQuota int64      `json:"monthly_quota"`
+	Days         []dayUsage `json:"days"`
+}
+
+type dayUsage struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+}
+
+type UsageEndpoint struct {
+	config *config.Config
+	quota  *quota.Quota
+}
+
+func NewUsageEndpoint(cfg *config.Config, q *quota.Quota) *UsageEndpoint {
+	return &UsageEndpoint{
+		config: cfg,
+		quota:  q,
+	}
+}
+
+// Usage sends the numbers of the requests of the API keys by the dates
+// of the period, e.g. for the query ?from=2024-01-01&to=2024-01-31. The
+// period is the current month up to today by default. The configured
+// keys are sent, even if they are not used, and so are the removed ones,
+// that have been used over the period.
+func (e *UsageEndpoint) Usage(ctx echo.Context) error {
+	fromDate, toDate, err := monthPeriod(ctx, e.quota.Today())
+	if err != nil {
+		return err
+	}
+
+	usages, err := e.quota.Usage(ctx.Request().Context(), fromDate, toDate)
+	if err != nil {
+		errMsg := "could not get api key usage"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	keys := make(map[string]*keyUsage, len(e.config.ApiKeys))
+
+	key := func(keyName string) *keyUsage {
+		if keys[keyName] == nil {
+			daily, monthly := e.quota.Quotas(keyName)
+
+			keys[keyName] = &keyUsage{
+				KeyName:      keyName,
+				DailyQuota:   daily,
+				MonthlyQuota: monthly,
+				Days:         []dayUsage{},
+			}
+		}
+
+		return keys[keyName]
+	}
+
+	for keyName := range e.config.ApiKeys {
+		key(keyName)
+	}
+
+	for _, usage := range usages {
+		k := key(usage.KeyName)
+
+		k.Requests += usage.Requests
+		k.Days = append(k.Days, dayUsage{Date: usage.Date, Requests: usage.Requests})
+	}
+
+	response := usageResponse{
+		From: fromDate,
+		To:   toDate,
+		Keys: make([]keyUsage, 0, len(keys)),
+	}
+
+	for _, k := range keys {
+		response.Keys = append(response.Keys, *k)
+	}
+
+	sort.Slice(response.Keys, func(i, j int) bool {
+		return response.Keys[i].KeyName < response.Keys[j].KeyName
+	})
+
+	if err = ctx.JSON(http.StatusOK, response); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package endpoint
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	apimodels "github.com/mrumyantsev/currency-converter-app/internal/pkg/api-models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/version"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+type VersionEndpoint struct{}
+
+func NewVersionEndpoint() *VersionEndpoint {
+	return &VersionEndpoint{}
+}
+
+// Version sends the build metadata of the running binary.
+func (e *VersionEndpoint) Version(ctx echo.Context) error {
+	if err := ctx.JSON(http.StatusOK, apimodels.NewVersion(version.Get())); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package endpoint
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	apimodels "github.com/mrumyantsev/currency-converter-app/internal/pkg/api-models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	memcache "github.com/mrumyantsev/currency-converter-app/internal/pkg/mem-cache"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+const (
+	queryTimeout = "timeout"
+
+	defaultWaitTimeout = 30 * time.Second
+)
+
+// A waitResponse is the currencies of the update, that is the other one,
+// than the client has synced with, along with the id of it, which the
+// client waits since next time.
+type waitResponse struct {
+	apimodels.UpdateDatetime
+	Currencies []apimodels.Currency `json:"currencies"`
+}
+
+type WaitEndpoint struct {
+	config    *config.Config
+	memCache  *memcache.MemCache
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func NewWaitEndpoint(cfg *config.Config, mc *memcache.MemCache) *WaitEndpoint {
+	return &WaitEndpoint{
+		config:   cfg,
+		memCache: mc,
+		closed:   make(chan struct{}),
+	}
+}
+
+// Close ends the waiting of all clients with no content, so the server
+// is shut down without waiting for the timeouts of them. The clients
+// are not made to wait afterwards.
+func (e *WaitEndpoint) Close() {
+	e.closeOnce.Do(func() { close(e.closed) })
+}
+
+// Wait sends the currencies, as soon as the update is the other one,
+// than the client has synced with, e.g. for the query
+// ?since=42&timeout=60s, or no content, if the timeout elapses first.
+// The currencies of the other update are sent at once. The timeout is
+// 30 seconds by default and is limited by the config.
+func (e *WaitEndpoint) Wait(ctx echo.Context) error {
+	lang, err := requestLang(ctx)
+	if err != nil {
+		return err
+	}
+
+	since, err := strconv.Atoi(ctx.QueryParam(querySince))
+	if (err != nil) || (since <= 0) {
+		return echo.NewHTTPError(http.StatusBadRequest, "since must be a positive update id")
+	}
+
+	timeout := defaultWaitTimeout
+
+	if rawTimeout := ctx.QueryParam(queryTimeout); rawTimeout != "" {
+		timeout, err = time.ParseDuration(rawTimeout)
+		if (err != nil) || (timeout <= 0) {
+			return echo.NewHTTPError(http.StatusBadRequest, "timeout must be a positive duration, e.g. 60s")
+		}
+	}
+
+	if timeout > e.config.LongPollMaxTimeout {
+		timeout = e.config.LongPollMaxTimeout
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	snapshot, updated := e.memCache.Watch()
+
+	for (snapshot.UpdateDatetime == nil) || (snapshot.UpdateDatetime.Id == since) {
+		select {
+		case <-updated:
+			snapshot, updated = e.memCache.Watch()
+		case <-timer.C:
+			setNextUpdateAtHeader(ctx, snapshot)
+
+			return ctx.NoContent(http.StatusNoContent)
+		case <-e.closed:
+			return ctx.NoContent(http.StatusNoContent)
+		case <-ctx.Request().Context().Done():
+			// The client is gone, so nothing is sent.
+			return nil
+		}
+	}
+
+	ctx.Response().Header().Set(headerRateDate, snapshot.UpdateDatetime.RateDate)
+
+	setNextUpdateAtHeader(ctx, snapshot)
+
+	currencies := snapshot.CalculatedCurrencies
+
+	if lang != langRu {
+		currencies = localizeCurrencies(currencies, lang)
+	}
+
+	response := waitResponse{
+		UpdateDatetime: apimodels.NewUpdateDatetime(*snapshot.UpdateDatetime),
+		Currencies:     apimodels.NewCurrencies(currencies),
+	}
+
+	if err = ctx.JSON(http.StatusOK, response); err != nil {
+		errMsg := "could not send reponse data"
+
+		requestLogger(ctx).Error().Err(err).Msg(errMsg)
+
+		return errlib.Wrap(err, errMsg)
+	}
+
+	return nil
+}
@@ -5,17 +5,26 @@ import (
 	"io"
 	"os"
 	"path"
+	"strconv"
 
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
 	"github.com/mrumyantsev/go-errlib"
 )
 
 const (
-	saveDir  = "./save"
-	filePerm = 0644
-	dirPerm  = 0755
+	saveDir        = "./save"
+	archiveFileExt = ".xml"
+	snapshotExt    = ".json"
+	usageFile      = "usage.json"
+	auditFile      = "audit.jsonl"
+	filePerm       = 0644
+	dirPerm        = 0755
 )
 
+// ErrNotArchived is returned, when there is no archived data for the
+// requested update.
+var ErrNotArchived = errors.New("data is not archived")
+
 type FsOps struct {
 	config *config.Config
 }
@@ -62,6 +71,190 @@ func (f *FsOps) OverwriteCurrencyDataFile(data []byte) error {
 	return nil
 }
 
+// ArchiveCurrencyData saves the raw currency data, fetched from the
+// source, in the archive directory under the update datetime id.
+func (f *FsOps) ArchiveCurrencyData(updateDatetimeId int, data []byte) error {
+	archiveDir := path.Join(saveDir, f.config.ArchiveDir)
+
+	if err := os.MkdirAll(archiveDir, dirPerm); err != nil {
+		return errlib.Wrap(err, "could not make archive directory")
+	}
+
+	err := os.WriteFile(
+		path.Join(archiveDir, archiveFileName(updateDatetimeId)),
+		data,
+		filePerm,
+	)
+	if err != nil {
+		return errlib.Wrap(err, "could not write archive file")
+	}
+
+	return nil
+}
+
+// ArchivedCurrencyData returns the raw currency data, that was saved
+// for the update datetime id.
+func (f *FsOps) ArchivedCurrencyData(updateDatetimeId int) ([]byte, error) {
+	data, err := os.ReadFile(path.Join(
+		saveDir,
+		f.config.ArchiveDir,
+		archiveFileName(updateDatetimeId),
+	))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotArchived
+		}
+
+		return nil, errlib.Wrap(err, "could not read archive file")
+	}
+
+	return data, nil
+}
+
+// SaveSnapshot saves the encoded snapshot of the update in the
+// snapshots directory under the update datetime id.
+func (f *FsOps) SaveSnapshot(updateDatetimeId int, data []byte) error {
+	snapshotsDir := path.Join(saveDir, f.config.SnapshotsDir)
+
+	if err := os.MkdirAll(snapshotsDir, dirPerm); err != nil {
+		return errlib.Wrap(err, "could not make snapshots directory")
+	}
+
+	err := os.WriteFile(
+		path.Join(snapshotsDir, snapshotFileName(updateDatetimeId)),
+		data,
+		filePerm,
+	)
+	if err != nil {
+		return errlib.Wrap(err, "could not write snapshot file")
+	}
+
+	return nil
+}
+
+// Snapshots returns all encoded snapshots, that are saved in the
+// snapshots directory.
+func (f *FsOps) Snapshots() ([][]byte, error) {
+	snapshotsDir := path.Join(saveDir, f.config.SnapshotsDir)
+
+	entries, err := os.ReadDir(snapshotsDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, errlib.Wrap(err, "could not read snapshots directory")
+	}
+
+	snapshots := make([][]byte, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() || (path.Ext(entry.Name()) != snapshotExt) {
+			continue
+		}
+
+		data, err := os.ReadFile(path.Join(snapshotsDir, entry.Name()))
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not read snapshot file")
+		}
+
+		snapshots = append(snapshots, data)
+	}
+
+	return snapshots, nil
+}
+
+// DeleteSnapshot deletes the saved snapshot of the update.
+func (f *FsOps) DeleteSnapshot(updateDatetimeId int) error {
+	err := os.Remove(path.Join(
+		saveDir,
+		f.config.SnapshotsDir,
+		snapshotFileName(updateDatetimeId),
+	))
+	if (err != nil) && !errors.Is(err, os.ErrNotExist) {
+		return errlib.Wrap(err, "could not delete snapshot file")
+	}
+
+	return nil
+}
+
+// SaveUsage saves the encoded usage of the API keys, overwriting the
+// saved one.
+func (f *FsOps) SaveUsage(data []byte) error {
+	if err := os.MkdirAll(saveDir, dirPerm); err != nil {
+		return errlib.Wrap(err, "could not make save directory")
+	}
+
+	if err := os.WriteFile(path.Join(saveDir, usageFile), data, filePerm); err != nil {
+		return errlib.Wrap(err, "could not write usage file")
+	}
+
+	return nil
+}
+
+// Usage returns the saved encoded usage of the API keys, or nil, if it
+// is not saved yet.
+func (f *FsOps) Usage() ([]byte, error) {
+	data, err := os.ReadFile(path.Join(saveDir, usageFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, errlib.Wrap(err, "could not read usage file")
+	}
+
+	return data, nil
+}
+
+// AppendAudit appends the encoded audit entry to the saved ones as a
+// line, so the entries are never overwritten.
+func (f *FsOps) AppendAudit(data []byte) error {
+	if err := os.MkdirAll(saveDir, dirPerm); err != nil {
+		return errlib.Wrap(err, "could not make save directory")
+	}
+
+	file, err := os.OpenFile(path.Join(saveDir, auditFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, filePerm)
+	if err != nil {
+		return errlib.Wrap(err, "could not open audit file")
+	}
+
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+
+		return errlib.Wrap(err, "could not write audit file")
+	}
+
+	if err = file.Close(); err != nil {
+		return errlib.Wrap(err, "could not close audit file")
+	}
+
+	return nil
+}
+
+// Audit returns the saved encoded audit entries, one per line, or nil,
+// if none is saved yet.
+func (f *FsOps) Audit() ([]byte, error) {
+	data, err := os.ReadFile(path.Join(saveDir, auditFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, errlib.Wrap(err, "could not read audit file")
+	}
+
+	return data, nil
+}
+
+func snapshotFileName(updateDatetimeId int) string {
+	return strconv.Itoa(updateDatetimeId) + snapshotExt
+}
+
+func archiveFileName(updateDatetimeId int) string {
+	return strconv.Itoa(updateDatetimeId) + archiveFileExt
+}
+
 func makeDirIfNotExist(path string) error {
 	_, err := os.Stat(path)
 	if !errors.Is(err, os.ErrNotExist) {
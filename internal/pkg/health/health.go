@@ -0,0 +1,131 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/metrics"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
+	"github.com/rs/zerolog"
+)
+
+// A Status is the result of the latest check of the storage.
+type Status struct {
+	IsUp      bool      `json:"isUp"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// A Monitor pings the storage periodically. When the storage goes
+// down, it is pinged with an exponential backoff until it is reachable
+// again, so the work, that waits for it, may resume without restarting
+// the application.
+type Monitor struct {
+	config  *config.Config
+	storage storage.Storage
+	mu      sync.RWMutex
+	status  Status
+	up      chan struct{}
+}
+
+func New(cfg *config.Config, st storage.Storage) *Monitor {
+	up := make(chan struct{})
+	close(up)
+
+	return &Monitor{
+		config:  cfg,
+		storage: st,
+		status:  Status{IsUp: true},
+		up:      up,
+	}
+}
+
+// Run checks the storage until the context is canceled.
+func (m *Monitor) Run(ctx context.Context) {
+	backoff := m.config.DbReconnectMinBackoff
+
+	for {
+		delay := m.config.DbHealthCheckInterval
+
+		if m.Check(ctx) {
+			backoff = m.config.DbReconnectMinBackoff
+		} else {
+			delay = backoff
+
+			backoff *= 2
+			if backoff > m.config.DbReconnectMaxBackoff {
+				backoff = m.config.DbReconnectMaxBackoff
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Check pings the storage, updates the status and reports, whether
+// the storage is up.
+func (m *Monitor) Check(ctx context.Context) bool {
+	err := m.storage.Ping(ctx)
+
+	status := Status{
+		IsUp:      err == nil,
+		CheckedAt: time.Now(),
+	}
+
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case m.status.IsUp && !status.IsUp:
+		zerolog.Ctx(ctx).Error().Err(err).Msg("database is unreachable")
+
+		m.up = make(chan struct{})
+
+		metrics.DatabaseUp.Set(0)
+	case !m.status.IsUp && status.IsUp:
+		zerolog.Ctx(ctx).Info().Msg("database is reachable again")
+
+		close(m.up)
+
+		metrics.DatabaseUp.Set(1)
+		metrics.DatabaseReconnects.Inc()
+	case status.IsUp:
+		metrics.DatabaseUp.Set(1)
+	}
+
+	m.status = status
+
+	return status.IsUp
+}
+
+// Status returns the result of the latest check.
+func (m *Monitor) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.status
+}
+
+// WaitUp blocks, until the storage is up or the context is canceled.
+func (m *Monitor) WaitUp(ctx context.Context) error {
+	m.mu.RLock()
+	up := m.up
+	m.mu.RUnlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-up:
+		return nil
+	}
+}
@@ -0,0 +1,146 @@
+package historyio
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+const (
+	FormatCsv    = "csv"
+	FormatJson   = "json"
+	FormatNdjson = "ndjson"
+)
+
+// ErrUnknownFormat is returned, when the format of the history is not
+// supported.
+var ErrUnknownFormat = errors.New("unknown history format")
+
+// csvHeader is the header of the history in the CSV format.
+var csvHeader = []string{
+	"rate_date",
+	"num_code",
+	"char_code",
+	"multiplier",
+	"name",
+	"value",
+	"unit_value",
+}
+
+// A record is a currency of the history, as it is exported.
+type record struct {
+	RateDate   string `json:"rateDate"`
+	NumCode    int    `json:"numCode"`
+	CharCode   string `json:"charCode"`
+	Multiplier int    `json:"multiplier"`
+	Name       string `json:"name"`
+	Value      string `json:"value"`
+	UnitValue  string `json:"unitValue"`
+}
+
+func newRecord(currency models.HistoryCurrency) record {
+	return record{
+		RateDate:   currency.RateDate,
+		NumCode:    currency.NumCode,
+		CharCode:   currency.CharCode,
+		Multiplier: currency.Multiplier,
+		Name:       currency.Name,
+		Value:      string(currency.Value),
+		UnitValue:  string(currency.UnitValue),
+	}
+}
+
+// Write writes the currency history in the format, record by record.
+func Write(w io.Writer, format string, history []models.HistoryCurrency) error {
+	switch format {
+	case FormatCsv:
+		return writeCsv(w, history)
+	case FormatJson:
+		return writeJson(w, history)
+	case FormatNdjson:
+		return writeNdjson(w, history)
+	}
+
+	return errlib.Wrap(ErrUnknownFormat, format)
+}
+
+func writeCsv(w io.Writer, history []models.HistoryCurrency) error {
+	csvWriter := csv.NewWriter(w)
+
+	if err := csvWriter.Write(csvHeader); err != nil {
+		return errlib.Wrap(err, "could not write csv header")
+	}
+
+	for _, currency := range history {
+		err := csvWriter.Write([]string{
+			currency.RateDate,
+			strconv.Itoa(currency.NumCode),
+			currency.CharCode,
+			strconv.Itoa(currency.Multiplier),
+			currency.Name,
+			string(currency.Value),
+			string(currency.UnitValue),
+		})
+		if err != nil {
+			return errlib.Wrap(err, "could not write csv record")
+		}
+	}
+
+	csvWriter.Flush()
+
+	if err := csvWriter.Error(); err != nil {
+		return errlib.Wrap(err, "could not flush csv records")
+	}
+
+	return nil
+}
+
+// writeNdjson writes the history as the newline-delimited JSON, a
+// record per line, so the history of several writes is a valid stream.
+func writeNdjson(w io.Writer, history []models.HistoryCurrency) error {
+	encoder := json.NewEncoder(w)
+
+	for _, currency := range history {
+		if err := encoder.Encode(newRecord(currency)); err != nil {
+			return errlib.Wrap(err, "could not write json record")
+		}
+	}
+
+	return nil
+}
+
+// writeJson writes the history as a JSON array, encoding one record at
+// a time, so the whole document is never kept in memory.
+func writeJson(w io.Writer, history []models.HistoryCurrency) error {
+	if _, err := io.WriteString(w, "[\n"); err != nil {
+		return errlib.Wrap(err, "could not write json array start")
+	}
+
+	for i, currency := range history {
+		data, err := json.Marshal(newRecord(currency))
+		if err != nil {
+			return errlib.Wrap(err, "could not encode json record")
+		}
+
+		if i > 0 {
+			if _, err = io.WriteString(w, ",\n"); err != nil {
+				return errlib.Wrap(err, "could not write json record separator")
+			}
+		}
+
+		if _, err = w.Write(data); err != nil {
+			return errlib.Wrap(err, "could not write json record")
+		}
+	}
+
+	if _, err := io.WriteString(w, "\n]\n"); err != nil {
+		return errlib.Wrap(err, "could not write json array end")
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package historyio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// sourceDateLayout is the layout of the dates of the source snapshots.
+const sourceDateLayout = "02.01.2006"
+
+// requiredColumns are the columns, every imported history must have.
+var requiredColumns = []string{"rate_date", "num_code", "char_code", "value"}
+
+// ErrMissingColumn is returned, when the imported history has no
+// required column.
+var ErrMissingColumn = errors.New("missing required column")
+
+// ReadCsv reads the currency history in the CSV format, that is written
+// by Write, and returns the snapshots of it ordered by the rate date.
+// Only the columns rate_date, num_code, char_code and value are
+// required. The multiplier is 1, when it is not set.
+func ReadCsv(r io.Reader) ([]models.Currencies, error) {
+	csvReader := csv.NewReader(r)
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not read csv header")
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, errlib.Wrap(ErrMissingColumn, name)
+		}
+	}
+
+	snapshots := make(map[string]*models.Currencies)
+
+	for line := 2; ; line++ {
+		fields, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not read csv record")
+		}
+
+		rateDate, currency, err := parseRecord(columns, fields)
+		if err != nil {
+			return nil, errlib.Wrap(err, fmt.Sprintf("invalid record on line %d", line))
+		}
+
+		snapshot, ok := snapshots[rateDate.Format(models.RateDateLayout)]
+		if !ok {
+			snapshot = &models.Currencies{
+				Date:     rateDate.Format(sourceDateLayout),
+				RateDate: rateDate,
+			}
+
+			snapshots[rateDate.Format(models.RateDateLayout)] = snapshot
+		}
+
+		snapshot.Currencies = append(snapshot.Currencies, currency)
+	}
+
+	result := make([]models.Currencies, 0, len(snapshots))
+
+	for _, snapshot := range snapshots {
+		snapshot.NormalizeUnitValues()
+
+		result = append(result, *snapshot)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].RateDate.Before(result[j].RateDate)
+	})
+
+	return result, nil
+}
+
+func parseRecord(columns map[string]int, fields []string) (time.Time, models.Currency, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || (i >= len(fields)) {
+			return ""
+		}
+
+		return strings.TrimSpace(fields[i])
+	}
+
+	var currency models.Currency
+
+	rateDate, err := time.Parse(models.RateDateLayout, field("rate_date"))
+	if err != nil {
+		return rateDate, currency, errlib.Wrap(err, "could not parse rate date")
+	}
+
+	if currency.NumCode, err = strconv.Atoi(field("num_code")); err != nil {
+		return rateDate, currency, errlib.Wrap(err, "could not parse num code")
+	}
+
+	currency.Multiplier = 1
+
+	if multiplier := field("multiplier"); multiplier != "" {
+		if currency.Multiplier, err = strconv.Atoi(multiplier); err != nil {
+			return rateDate, currency, errlib.Wrap(err, "could not parse multiplier")
+		}
+	}
+
+	currency.CharCode = field("char_code")
+	currency.Name = field("name")
+	currency.Value = models.Value(strings.Replace(field("value"), ",", ".", 1))
+	currency.UnitValue = models.Value(strings.Replace(field("unit_value"), ",", ".", 1))
+
+	return rateDate, currency, nil
+}
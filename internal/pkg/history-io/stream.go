@@ -0,0 +1,61 @@
+package historyio
+
+import (
+	"context"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// WindowDays is the number of the days of the history, that are fetched
+// at once, when the history is streamed.
+const WindowDays = 31
+
+// A Fetcher returns the currency history of the period from the first
+// date to the last one inclusive, e.g. from the storage.
+type Fetcher func(ctx context.Context, fromDate string, toDate string) ([]models.HistoryCurrency, error)
+
+// ForEachWindow fetches the currency history of the period from the
+// first date to the last one inclusive window by window in the order of
+// the dates, and calls the function with the history of every window.
+// The next window is fetched only after the function returns, so only
+// one window is kept in memory, and the slow consumer, e.g. the client
+// of the stream, holds the fetching back.
+func ForEachWindow(ctx context.Context, fetch Fetcher, fromDate string, toDate string, f func(history []models.HistoryCurrency) error) error {
+	from, err := time.Parse(models.RateDateLayout, fromDate)
+	if err != nil {
+		return errlib.Wrap(err, "could not parse first date")
+	}
+
+	to, err := time.Parse(models.RateDateLayout, toDate)
+	if err != nil {
+		return errlib.Wrap(err, "could not parse last date")
+	}
+
+	for windowFrom := from; !windowFrom.After(to); windowFrom = windowFrom.AddDate(0, 0, WindowDays) {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		windowTo := windowFrom.AddDate(0, 0, WindowDays-1)
+		if windowTo.After(to) {
+			windowTo = to
+		}
+
+		history, err := fetch(ctx, windowFrom.Format(models.RateDateLayout), windowTo.Format(models.RateDateLayout))
+		if err != nil {
+			return errlib.Wrap(err, "could not fetch currency history of "+windowFrom.Format(models.RateDateLayout))
+		}
+
+		if len(history) == 0 {
+			continue
+		}
+
+		if err = f(history); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
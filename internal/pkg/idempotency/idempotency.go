@@ -0,0 +1,109 @@
+// Package idempotency keeps the outcomes of the write requests by the
+// idempotency keys of them, so the retried requests get the same
+// responses instead of being done again.
+package idempotency
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+)
+
+var (
+	ErrInProgress = errors.New("request with idempotency key is in progress")
+	ErrMismatch   = errors.New("idempotency key is used by another request")
+)
+
+// A Response is the outcome of the request, that is replayed to the
+// retried ones.
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+type entry struct {
+	fingerprint string
+	response    *Response
+	expiresAt   time.Time
+}
+
+// A Store keeps the outcomes in memory for the time to live, so the
+// keys are not shared by the instances and are lost on restart.
+type Store struct {
+	ttl     time.Duration
+	clock   clock.Clock
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+func New(cfg *config.Config, cl clock.Clock) *Store {
+	return &Store{
+		ttl:     cfg.IdempotencyKeyTtl,
+		clock:   cl,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Begin starts the request by the key. It returns the outcome, if the
+// request is done already, or nil, if the request is to be done and
+// then completed or aborted. The fingerprint tells the requests apart,
+// so the key of the other request is not replayed.
+func (s *Store) Begin(key string, fingerprint string) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+
+	s.prune(now)
+
+	if e, ok := s.entries[key]; ok {
+		switch {
+		case e.fingerprint != fingerprint:
+			return nil, ErrMismatch
+		case e.response == nil:
+			return nil, ErrInProgress
+		default:
+			return e.response, nil
+		}
+	}
+
+	s.entries[key] = &entry{
+		fingerprint: fingerprint,
+		expiresAt:   now.Add(s.ttl),
+	}
+
+	return nil, nil
+}
+
+// Complete keeps the outcome of the request by the key for the retried
+// ones.
+func (s *Store) Complete(key string, response Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.response = &response
+		e.expiresAt = s.clock.Now().Add(s.ttl)
+	}
+}
+
+// Abort forgets the request by the key, that has failed, so it may be
+// retried.
+func (s *Store) Abort(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+}
+
+func (s *Store) prune(now time.Time) {
+	for key, e := range s.entries {
+		if (e.response != nil) && now.After(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Package ipfilter restricts the clients of the endpoints by the IP
+// addresses of them, so the access can be limited on the network level
+// without a reverse proxy.
+package ipfilter
+
+import (
+	"net/netip"
+)
+
+// A Filter lets through the addresses of the allowlist, if it is set,
+// except the ones of the denylist. The lists are of the networks in the
+// CIDR notation or of the single addresses.
+type Filter struct {
+	allowlist []netip.Prefix
+	denylist  []netip.Prefix
+}
+
+// New creates the filter by the lists, that are validated by the
+// config, so the invalid entries are skipped.
+func New(allowlist []string, denylist []string) *Filter {
+	return &Filter{
+		allowlist: prefixes(allowlist),
+		denylist:  prefixes(denylist),
+	}
+}
+
+// IsEnabled reports, whether any address may be filtered out.
+func (f *Filter) IsEnabled() bool {
+	return (len(f.allowlist) > 0) || (len(f.denylist) > 0)
+}
+
+// IsAllowed reports, whether the client of the address is let through.
+func (f *Filter) IsAllowed(addr netip.Addr) bool {
+	// The IPv4 clients of the dual-stack listener have the addresses
+	// mapped to IPv6.
+	addr = addr.Unmap()
+
+	if (len(f.allowlist) > 0) && !contains(f.allowlist, addr) {
+		return false
+	}
+
+	return !contains(f.denylist, addr)
+}
+
+// ParsePrefix parses the network in the CIDR notation or the single
+// address, that is the network of it alone.
+func ParsePrefix(entry string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(entry); err == nil {
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func prefixes(entries []string) []netip.Prefix {
+	result := make([]netip.Prefix, 0, len(entries))
+
+	for _, entry := range entries {
+		if prefix, err := ParsePrefix(entry); err == nil {
+			result = append(result, prefix)
+		}
+	}
+
+	return result
+}
+
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
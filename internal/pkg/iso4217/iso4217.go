@@ -0,0 +1,83 @@
+// Package iso4217 provides the metadata of the currencies by the
+// ISO 4217 standard, that the sources of the rates do not publish: the
+// English names, the symbols, the issuing countries and the number of
+// the digits after the decimal separator.
+package iso4217
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A Currency is the metadata of the currency by ISO 4217. The minor
+// units are nil for the currencies, that have none, e.g. the metals.
+type Currency struct {
+	CharCode   string
+	NumCode    int
+	NameEn     string
+	Symbol     string
+	MinorUnits *int
+	Countries  []string
+}
+
+//go:embed currencies.csv
+var data string
+
+var (
+	loadOnce   sync.Once
+	byCharCode map[string]Currency
+)
+
+// Lookup returns the metadata of the currency by the char code in any
+// case.
+func Lookup(charCode string) (Currency, bool) {
+	loadOnce.Do(load)
+
+	currency, ok := byCharCode[strings.ToUpper(charCode)]
+
+	return currency, ok
+}
+
+// load parses the embedded table. The table is a part of the binary,
+// so a malformed row is a programming error.
+func load() {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic("iso4217: could not read currencies: " + err.Error())
+	}
+
+	byCharCode = make(map[string]Currency, len(records))
+
+	// The first record is the header.
+	for _, record := range records[1:] {
+		numCode, err := strconv.Atoi(record[1])
+		if err != nil {
+			panic("iso4217: invalid numeric code of " + record[0])
+		}
+
+		currency := Currency{
+			CharCode: record[0],
+			NumCode:  numCode,
+			NameEn:   record[2],
+			Symbol:   record[3],
+		}
+
+		if record[4] != "" {
+			minorUnits, err := strconv.Atoi(record[4])
+			if err != nil {
+				panic("iso4217: invalid minor units of " + record[0])
+			}
+
+			currency.MinorUnits = &minorUnits
+		}
+
+		if record[5] != "" {
+			currency.Countries = strings.Split(record[5], ";")
+		}
+
+		byCharCode[record[0]] = currency
+	}
+}
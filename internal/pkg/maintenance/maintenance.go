@@ -0,0 +1,68 @@
+package maintenance
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/metrics"
+)
+
+// A Status is whether the maintenance mode is on and since when.
+type Status struct {
+	IsEnabled bool
+	Since     time.Time
+}
+
+// A Mode is the switch of the maintenance mode, in which the API is
+// unavailable, while the data are still updated in the background, e.g.
+// during the migrations or the incidents of the source.
+type Mode struct {
+	clock  clock.Clock
+	mu     sync.RWMutex
+	status Status
+}
+
+// New creates the switch, that is on, if it is set by the config.
+func New(cfg *config.Config, cl clock.Clock) *Mode {
+	m := &Mode{clock: cl}
+
+	m.Set(cfg.IsMaintenanceMode)
+
+	return m
+}
+
+// Set turns the maintenance mode on or off and reports, whether it has
+// been switched, i.e. it was not in that state already.
+func (m *Mode) Set(isEnabled bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.status.IsEnabled == isEnabled {
+		return false
+	}
+
+	m.status = Status{IsEnabled: isEnabled}
+
+	if isEnabled {
+		m.status.Since = m.clock.Now()
+
+		metrics.MaintenanceMode.Set(1)
+	} else {
+		metrics.MaintenanceMode.Set(0)
+	}
+
+	return true
+}
+
+func (m *Mode) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.status
+}
+
+func (m *Mode) IsEnabled() bool {
+	return m.Status().IsEnabled
+}
@@ -0,0 +1,44 @@
+package memcache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// encodeJson returns the JSON of the value, as it is sent in response,
+// along with the gzipped variant of it.
+func encodeJson(v any) ([]byte, []byte, error) {
+	var plain bytes.Buffer
+
+	// The encoder ends the JSON with a newline, the same as the echo
+	// does in the responses.
+	if err := json.NewEncoder(&plain).Encode(v); err != nil {
+		return nil, nil, errlib.Wrap(err, "could not encode json")
+	}
+
+	var gzipped bytes.Buffer
+
+	writer := gzip.NewWriter(&gzipped)
+
+	if _, err := writer.Write(plain.Bytes()); err != nil {
+		return nil, nil, errlib.Wrap(err, "could not compress json")
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, nil, errlib.Wrap(err, "could not compress json")
+	}
+
+	return plain.Bytes(), gzipped.Bytes(), nil
+}
+
+// weakEtag returns the weak entity tag of the data by the hash of them.
+func weakEtag(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
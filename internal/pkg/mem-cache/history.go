@@ -0,0 +1,62 @@
+package memcache
+
+// A history is the ring buffer of the recent snapshots of currencies.
+type history struct {
+	snapshots []*Snapshot
+	next      int
+	count     int
+}
+
+func newHistory(size int) *history {
+	return &history{snapshots: make([]*Snapshot, size)}
+}
+
+// put adds the snapshot to the history, overwriting the oldest one, if
+// the history is full. The snapshot of the same update as the latest
+// one replaces it.
+func (h *history) put(snapshot *Snapshot) {
+	if latest := h.at(0); (latest != nil) && sameUpdate(latest, snapshot) {
+		h.snapshots[h.index(0)] = snapshot
+		return
+	}
+
+	h.snapshots[h.next] = snapshot
+	h.next = (h.next + 1) % len(h.snapshots)
+
+	if h.count < len(h.snapshots) {
+		h.count++
+	}
+}
+
+// at returns the snapshot, that is the number of updates back from the
+// latest one, or nil, if there is no such snapshot.
+func (h *history) at(back int) *Snapshot {
+	if (back < 0) || (back >= h.count) {
+		return nil
+	}
+
+	return h.snapshots[h.index(back)]
+}
+
+// list returns the snapshots from the latest one to the oldest one.
+func (h *history) list() []*Snapshot {
+	snapshots := make([]*Snapshot, 0, h.count)
+
+	for back := 0; back < h.count; back++ {
+		snapshots = append(snapshots, h.at(back))
+	}
+
+	return snapshots
+}
+
+func (h *history) index(back int) int {
+	return (h.next - 1 - back + 2*len(h.snapshots)) % len(h.snapshots)
+}
+
+func sameUpdate(snapshot *Snapshot, other *Snapshot) bool {
+	if (snapshot.UpdateDatetime == nil) || (other.UpdateDatetime == nil) {
+		return false
+	}
+
+	return snapshot.UpdateDatetime.Id == other.UpdateDatetime.Id
+}
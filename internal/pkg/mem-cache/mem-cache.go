@@ -1,37 +1,310 @@
 package memcache
 
-import "github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	apimodels "github.com/mrumyantsev/currency-converter-app/internal/pkg/api-models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// The sources of the currencies in memory cache.
+const (
+	SourceWeb  = "web"
+	SourceFile = "file"
+	SourceDb   = "db"
+)
+
+// ErrIncompleteSnapshot is returned, when the currencies are put in the
+// cache without the update or the data calculated from them.
+var ErrIncompleteSnapshot = errors.New("incomplete snapshot")
+
+// A Metadata describes where the currencies of the snapshot came from
+// and when they were fetched from the source.
+type Metadata struct {
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// An UpdateFailure describes the failed update, after which the data
+// of the previous one are served.
+type UpdateFailure struct {
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failedAt"`
+}
+
+// An UnchangedData describes the latest update, that has got the raw
+// data, which are byte-identical to the ones of the current currencies,
+// so they have been neither parsed nor stored again.
+type UnchangedData struct {
+	Checksum  string    `json:"checksum"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// A Snapshot is the state of the memory cache at some moment. It is
+// never changed after it is put in the cache, so it can be read
+// without locking.
+type Snapshot struct {
+	UpdateDatetime       *models.UpdateDatetime
+	Currencies           *models.Currencies
+	CalculatedCurrencies []models.CalculatedCurrency
+	CalculatedMetals     []models.CalculatedMetal
+	NextUpdateAt         time.Time
+	Metadata
+
+	// The failure of the latest update, or nil, if it succeeded.
+	UpdateFailure *UpdateFailure
+
+	// The failure of the latest update of the metals, or nil, if it
+	// succeeded. The metals are updated apart from the currencies, so
+	// it does not make the currencies stale.
+	MetalsUpdateFailure *UpdateFailure
+
+	// The latest update without changes, or nil, if the currencies have
+	// been updated since.
+	UnchangedData *UnchangedData
+
+	// The calculated currencies, encoded to be sent in response as is.
+	CalculatedCurrenciesJson     []byte
+	CalculatedCurrenciesJsonGzip []byte
+
+	// The weak entity tag of the calculated currencies, that is the same
+	// for all of the representations of them.
+	CalculatedCurrenciesEtag string
+
+	// The indices of the currencies in Currencies and in
+	// CalculatedCurrencies by the char codes and the numeric codes.
+	charCodes map[string]int
+	numCodes  map[int]int
+}
+
+// Lookup returns the calculated currency by the char code, in any
+// case, or by the numeric code.
+func (s *Snapshot) Lookup(code string) (models.CalculatedCurrency, bool) {
+	if numCode, err := strconv.Atoi(code); err == nil {
+		return s.LookupNumCode(numCode)
+	}
+
+	return s.LookupCharCode(code)
+}
+
+func (s *Snapshot) LookupCharCode(charCode string) (models.CalculatedCurrency, bool) {
+	return calculatedCurrency(s, s.charCodes, strings.ToUpper(charCode))
+}
+
+func (s *Snapshot) LookupNumCode(numCode int) (models.CalculatedCurrency, bool) {
+	return calculatedCurrency(s, s.numCodes, numCode)
+}
+
+// A MemCache keeps the current snapshot of the data. The snapshot is
+// replaced as a whole, so the readers never see a partial update. The
+// recent snapshots of currencies are kept in the history, so the
+// changes between the updates are known without the storage.
 type MemCache struct {
-	currencies           *models.Currencies
-	updateDatetime       *models.UpdateDatetime
-	calculatedCurrencies []models.CalculatedCurrency
+	mu        sync.Mutex
+	snapshot  atomic.Pointer[Snapshot]
+	historyMu sync.RWMutex
+	history   *history
+
+	// updated is closed, when the currencies are set next time, and is
+	// replaced by the new one then.
+	updated chan struct{}
+}
+
+func New(cfg *config.Config) *MemCache {
+	m := &MemCache{
+		history: newHistory(cfg.MemCacheHistorySize),
+		updated: make(chan struct{}),
+	}
+
+	m.snapshot.Store(new(Snapshot))
+
+	return m
 }
 
-func New() *MemCache {
-	return new(MemCache)
+// Snapshot returns the current snapshot. The fields of it are
+// consistent with each other and must not be modified.
+func (m *MemCache) Snapshot() *Snapshot {
+	return m.snapshot.Load()
 }
 
-func (m *MemCache) Currencies() *models.Currencies {
-	return m.currencies
+// Watch returns the current snapshot along with the channel, that is
+// closed, when the currencies are set next time, so the update after
+// the snapshot is never missed.
+func (m *MemCache) Watch() (*Snapshot, <-chan struct{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.snapshot.Load(), m.updated
 }
 
-func (m *MemCache) SetCurrencies(currencies *models.Currencies) {
-	m.currencies = currencies
+// History returns the recent snapshots of currencies from the latest
+// one to the oldest one.
+func (m *MemCache) History() []*Snapshot {
+	m.historyMu.RLock()
+	defer m.historyMu.RUnlock()
+
+	return m.history.list()
 }
 
-func (m *MemCache) UpdateDatetime() *models.UpdateDatetime {
-	return m.updateDatetime
+// Previous returns the snapshot of currencies of the update before the
+// latest one, or nil, if there is no such snapshot in the history.
+func (m *MemCache) Previous() *Snapshot {
+	m.historyMu.RLock()
+	defer m.historyMu.RUnlock()
+
+	return m.history.at(1)
 }
 
-func (m *MemCache) SetUpdateDatetime(updateDatetime *models.UpdateDatetime) {
-	m.updateDatetime = updateDatetime
+func (m *MemCache) Currencies() *models.Currencies {
+	return m.Snapshot().Currencies
+}
+
+func (m *MemCache) UpdateDatetime() *models.UpdateDatetime {
+	return m.Snapshot().UpdateDatetime
 }
 
 func (m *MemCache) CalculatedCurrencies() []models.CalculatedCurrency {
-	return m.calculatedCurrencies
+	return m.Snapshot().CalculatedCurrencies
+}
+
+func (m *MemCache) CalculatedMetals() []models.CalculatedMetal {
+	return m.Snapshot().CalculatedMetals
+}
+
+// SetCurrencies replaces the currencies of the update along with the
+// output data, calculated from them, and the metadata at once. The
+// calculated currencies must be in the same order as the currencies.
+// They are encoded here, as they change much more rarely, than they
+// are requested.
+func (m *MemCache) SetCurrencies(
+	updateDatetime *models.UpdateDatetime,
+	currencies *models.Currencies,
+	calculatedCurrencies []models.CalculatedCurrency,
+	metadata Metadata,
+) error {
+	if (updateDatetime == nil) || (currencies == nil) {
+		return ErrIncompleteSnapshot
+	}
+
+	if len(calculatedCurrencies) != len(currencies.Currencies) {
+		return errlib.Wrap(ErrIncompleteSnapshot, "calculated currencies do not match currencies")
+	}
+
+	calculatedJson, calculatedJsonGzip, err := encodeJson(apimodels.NewCurrencies(calculatedCurrencies))
+	if err != nil {
+		return errlib.Wrap(err, "could not encode calculated currencies")
+	}
+
+	var (
+		charCodes = make(map[string]int, len(currencies.Currencies))
+		numCodes  = make(map[int]int, len(currencies.Currencies))
+	)
+
+	for i, currency := range currencies.Currencies {
+		charCodes[strings.ToUpper(currency.CharCode)] = i
+
+		// The synthetic currencies, e.g. the baskets, have no numeric
+		// codes.
+		if currency.NumCode != 0 {
+			numCodes[currency.NumCode] = i
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := m.store(func(snapshot *Snapshot) {
+		snapshot.UpdateDatetime = updateDatetime
+		snapshot.Currencies = currencies
+		snapshot.CalculatedCurrencies = calculatedCurrencies
+		snapshot.charCodes = charCodes
+		snapshot.numCodes = numCodes
+		snapshot.Metadata = metadata
+		snapshot.CalculatedCurrenciesJson = calculatedJson
+		snapshot.CalculatedCurrenciesJsonGzip = calculatedJsonGzip
+		snapshot.CalculatedCurrenciesEtag = weakEtag(calculatedJson)
+	})
+
+	m.historyMu.Lock()
+	m.history.put(snapshot)
+	m.historyMu.Unlock()
+
+	close(m.updated)
+	m.updated = make(chan struct{})
+
+	return nil
+}
+
+// SetNextUpdateAt sets when the next scheduled update is going to be.
+func (m *MemCache) SetNextUpdateAt(nextUpdateAt time.Time) {
+	m.swap(func(snapshot *Snapshot) {
+		snapshot.NextUpdateAt = nextUpdateAt
+	})
 }
 
-func (m *MemCache) SetCalculatedCurrencies(calculatedCurrencies []models.CalculatedCurrency) {
-	m.calculatedCurrencies = calculatedCurrencies
+// SetUpdateFailure marks the data as the ones of the update before the
+// failed one. The nil failure clears the mark.
+func (m *MemCache) SetUpdateFailure(failure *UpdateFailure) {
+	m.swap(func(snapshot *Snapshot) {
+		snapshot.UpdateFailure = failure
+	})
+}
+
+// SetUnchangedData marks the latest update as the one without changes.
+// The nil data clears the mark.
+func (m *MemCache) SetUnchangedData(unchanged *UnchangedData) {
+	m.swap(func(snapshot *Snapshot) {
+		snapshot.UnchangedData = unchanged
+	})
+}
+
+// SetMetalsUpdateFailure marks the metals as the ones of the update
+// before the failed one. The nil failure clears the mark.
+func (m *MemCache) SetMetalsUpdateFailure(failure *UpdateFailure) {
+	m.swap(func(snapshot *Snapshot) {
+		snapshot.MetalsUpdateFailure = failure
+	})
+}
+
+func (m *MemCache) SetCalculatedMetals(calculatedMetals []models.CalculatedMetal) {
+	m.swap(func(snapshot *Snapshot) {
+		snapshot.CalculatedMetals = calculatedMetals
+	})
+}
+
+// swap puts a copy of the current snapshot, changed by the function,
+// in place of it. The writers are serialized, so no change is lost.
+func (m *MemCache) swap(change func(snapshot *Snapshot)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.store(change)
+}
+
+// store puts a copy of the current snapshot, changed by the function,
+// in place of it, and returns the copy. The caller must hold the lock.
+func (m *MemCache) store(change func(snapshot *Snapshot)) *Snapshot {
+	snapshot := *m.snapshot.Load()
+
+	change(&snapshot)
+
+	m.snapshot.Store(&snapshot)
+
+	return &snapshot
+}
+
+func calculatedCurrency[K comparable](s *Snapshot, index map[K]int, key K) (models.CalculatedCurrency, bool) {
+	i, ok := index[key]
+	if !ok || (i >= len(s.CalculatedCurrencies)) {
+		return models.CalculatedCurrency{}, false
+	}
+
+	return s.CalculatedCurrencies[i], true
 }
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "currency_converter"
+
+// PrunedUpdates counts the updates, that are deleted from the storage
+// by the retention policy.
+var PrunedUpdates = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "pruned_updates_total",
+	Help:      "Number of updates deleted from the storage by the retention policy.",
+})
+
+// DataStale is 1, when the latest update failed and the data of the
+// previous one are served, and 0 otherwise.
+var DataStale = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "data_stale",
+	Help:      "Whether the latest update failed and the previous data are served.",
+})
+
+// MaintenanceMode is 1, when the API is unavailable for the
+// maintenance, and 0 otherwise.
+var MaintenanceMode = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "maintenance_mode",
+	Help:      "Whether the API is unavailable for the maintenance.",
+})
+
+// UpdateFailures counts the failed update cycles.
+var UpdateFailures = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "update_failures_total",
+	Help:      "Number of failed update cycles.",
+})
+
+// MetalsStale is 1, when the latest update of the metals failed and the
+// previous ones are served, and 0 otherwise.
+var MetalsStale = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "metals_stale",
+	Help:      "Whether the latest update of the metals failed and the previous ones are served.",
+})
+
+// MetalsUpdateFailures counts the failed updates of the metals.
+var MetalsUpdateFailures = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "metals_update_failures_total",
+	Help:      "Number of failed updates of the metals.",
+})
+
+// UnchangedUpdates counts the updates, that have got the same raw data
+// from the source as the previous ones.
+var UnchangedUpdates = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "unchanged_updates_total",
+	Help:      "Number of updates skipped as the source data were unchanged.",
+})
+
+// AnomalousUpdates counts the data of the source, that are held back,
+// as the rates of them have changed too much since the stored ones.
+var AnomalousUpdates = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "anomalous_updates_total",
+	Help:      "Number of updates held back as the rates changed anomalously.",
+})
+
+// Iso4217Mismatches counts the parsed currencies, that have the codes
+// unknown to ISO 4217 or not matching it.
+var Iso4217Mismatches = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "iso4217_mismatches_total",
+	Help:      "Number of parsed currencies with codes not matching ISO 4217.",
+})
+
+// LastUpdateSuccess is the unix time of the latest successful update
+// cycle.
+var LastUpdateSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "last_update_success_timestamp_seconds",
+	Help:      "Unix time of the latest successful update cycle.",
+})
+
+// DatabaseUp is 1, when the latest ping of the storage succeeded, and
+// 0 otherwise.
+var DatabaseUp = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "database_up",
+	Help:      "Whether the latest ping of the storage succeeded.",
+})
+
+// DatabaseReconnects counts the times, the storage became reachable
+// after it went down.
+var DatabaseReconnects = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "database_reconnects_total",
+	Help:      "Number of times the storage became reachable again.",
+})
+
+// StorageOperationDuration observes the durations of the operations of
+// the storage by the operation and its result.
+var StorageOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "storage_operation_duration_seconds",
+	Help:      "Durations of the storage operations.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"operation", "result"})
@@ -0,0 +1,214 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/database"
+	"github.com/mrumyantsev/currency-converter-app/schema"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/rs/zerolog"
+)
+
+const (
+	upSuffix       = ".up.sql"
+	versionDivider = "_"
+	rootDir        = "."
+)
+
+// ErrDirty is returned, when the previous migration has failed and the
+// schema must be fixed manually.
+var ErrDirty = errors.New("database schema is dirty")
+
+// A Migrator applies the embedded schema migrations to the database.
+// It keeps the schema version in the same table as the migrate
+// utility does, so both of them may be used on the same database.
+type Migrator struct {
+	config   *config.Config
+	database *database.Database
+}
+
+func New(cfg *config.Config, db *database.Database) *Migrator {
+	return &Migrator{
+		config:   cfg,
+		database: db,
+	}
+}
+
+type migration struct {
+	version int
+	file    string
+}
+
+// Up applies all migrations, that are newer than the database schema.
+func (m *Migrator) Up(ctx context.Context) error {
+	migrations, err := m.migrations()
+	if err != nil {
+		return errlib.Wrap(err, "could not list migrations")
+	}
+
+	db, err := m.database.OpenForMigrations()
+	if err != nil {
+		return errlib.Wrap(err, "could not open migration connection")
+	}
+	defer func() { _ = db.Close() }()
+
+	if err = m.createVersionTable(ctx, db); err != nil {
+		return errlib.Wrap(err, "could not create schema version table")
+	}
+
+	version, err := m.version(ctx, db)
+	if err != nil {
+		return errlib.Wrap(err, "could not get schema version")
+	}
+
+	for _, migration := range migrations {
+		if migration.version <= version {
+			continue
+		}
+
+		zerolog.Ctx(ctx).Info().Msg("applying migration " + migration.file)
+
+		if err = m.apply(ctx, db, migration); err != nil {
+			return errlib.Wrap(err, "could not apply migration "+migration.file)
+		}
+	}
+
+	zerolog.Ctx(ctx).Debug().Msg("database schema is up to date")
+
+	return nil
+}
+
+func (m *Migrator) migrations() ([]migration, error) {
+	dir := rootDir
+
+	if m.config.DbDriver != config.DbDriverPostgres {
+		dir = m.config.DbDriver
+	}
+
+	files, err := fs.Glob(schema.Migrations, path.Join(dir, "*"+upSuffix))
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not find migration files")
+	}
+
+	migrations := make([]migration, 0, len(files))
+
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(path.Base(file), versionDivider)
+
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not parse version of "+file)
+		}
+
+		migrations = append(migrations, migration{
+			version: version,
+			file:    file,
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
+
+func (m *Migrator) createVersionTable(ctx context.Context, db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version BIGINT  NOT NULL PRIMARY KEY,
+	dirty   BOOLEAN NOT NULL
+);
+	`
+
+	_, err := db.ExecContext(ctx, query)
+
+	return err
+}
+
+func (m *Migrator) version(ctx context.Context, db *sql.DB) (int, error) {
+	query := `SELECT version, dirty
+FROM schema_migrations
+LIMIT 1;
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, errlib.Wrap(err, "could not perform select of schema version")
+	}
+	defer func() { _ = rows.Close() }()
+
+	var (
+		version int
+		isDirty bool
+	)
+
+	for rows.Next() {
+		if err = rows.Scan(&version, &isDirty); err != nil {
+			return 0, errlib.Wrap(err, "could not scan schema version from a row")
+		}
+	}
+
+	if isDirty {
+		return 0, errlib.Wrap(ErrDirty, fmt.Sprintf("version %d", version))
+	}
+
+	return version, nil
+}
+
+// apply runs the migration. The version is marked dirty, until the
+// migration succeeds, as some databases can not run schema changes in
+// a transaction.
+func (m *Migrator) apply(ctx context.Context, db *sql.DB, migration migration) error {
+	query, err := fs.ReadFile(schema.Migrations, migration.file)
+	if err != nil {
+		return errlib.Wrap(err, "could not read migration file")
+	}
+
+	if err = m.setVersion(ctx, db, migration.version, true); err != nil {
+		return errlib.Wrap(err, "could not mark schema version dirty")
+	}
+
+	if _, err = db.ExecContext(ctx, string(query)); err != nil {
+		return errlib.Wrap(err, "could not execute migration")
+	}
+
+	if err = m.setVersion(ctx, db, migration.version, false); err != nil {
+		return errlib.Wrap(err, "could not set schema version")
+	}
+
+	return nil
+}
+
+func (m *Migrator) setVersion(ctx context.Context, db *sql.DB, version int, isDirty bool) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errlib.Wrap(err, "could not begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations;`); err != nil {
+		return errlib.Wrap(err, "could not delete schema version")
+	}
+
+	// Literals are used, as the drivers differ in placeholders.
+	query := fmt.Sprintf(
+		"INSERT INTO schema_migrations (version, dirty) VALUES (%d, %t);",
+		version,
+		isDirty,
+	)
+
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return errlib.Wrap(err, "could not insert schema version")
+	}
+
+	return tx.Commit()
+}
@@ -1,9 +1,56 @@
 package models
 
-import "encoding/xml"
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	charComma = ","
+	charDot   = "."
+)
+
+// A Value is a decimal number of the source data. The source may use
+// a comma as the decimal separator, so it is replaced with a dot while
+// unmarshaling.
+type Value string
+
+func (v *Value) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var raw string
+
+	if err := d.DecodeElement(&raw, &start); err != nil {
+		return errlib.Wrap(err, "could not decode value")
+	}
+
+	raw = strings.Replace(strings.TrimSpace(raw), charComma, charDot, 1)
+
+	if _, err := decimal.NewFromString(raw); err != nil {
+		return errlib.Wrap(err, "could not parse value of "+start.Name.Local)
+	}
+
+	*v = Value(raw)
+
+	return nil
+}
+
+// Decimal returns the value as a decimal number, so it is computed with
+// no binary floating point errors.
+func (v Value) Decimal() (decimal.Decimal, error) {
+	return decimal.NewFromString(string(v))
+}
 
 type Currencies struct {
 	XMLName    xml.Name   `xml:"ValCurs"`
+	Date       string     `xml:"Date,attr"`
+	Name       string     `xml:"name,attr"`
+	RateDate   time.Time  `xml:"-"`
 	Currencies []Currency `xml:"Valute"`
 }
 
@@ -12,16 +59,151 @@ type Currency struct {
 	CharCode   string `xml:"CharCode"`
 	Multiplier int    `xml:"Nominal"`
 	Name       string `xml:"Name"`
-	Value      string `xml:"Value"`
+	Value      Value  `xml:"Value"`
+	UnitValue  Value  `xml:"VunitRate"`
+}
+
+// NormalizeUnitValue sets the value of a single unit of the currency,
+// if it is not set yet. The currency with a value or multiplier, which
+// is invalid, is left as it is.
+func (c *Currency) NormalizeUnitValue() {
+	if (c.UnitValue != "") || (c.Multiplier <= 0) {
+		return
+	}
+
+	value, err := c.Value.Decimal()
+	if err != nil {
+		return
+	}
+
+	precision := len(strconv.Itoa(c.Multiplier)) - 1
+
+	if dot := strings.Index(string(c.Value), charDot); dot >= 0 {
+		precision += len(c.Value) - dot - 1
+	}
+
+	c.UnitValue = Value(value.
+		DivRound(decimal.NewFromInt(int64(c.Multiplier)), int32(precision)).
+		StringFixed(int32(precision)))
+}
+
+// UnitValueDecimal returns the value of a single unit of the currency.
+// It is an error, if the value is invalid or not positive.
+func (c Currency) UnitValueDecimal() (decimal.Decimal, error) {
+	c.NormalizeUnitValue()
+
+	value, err := c.UnitValue.Decimal()
+	if err != nil {
+		return decimal.Zero, errlib.Wrap(err, "could not parse value of "+c.CharCode)
+	}
+
+	if !value.IsPositive() {
+		return decimal.Zero, errors.New("non-positive value of " + c.CharCode)
+	}
+
+	return value, nil
+}
+
+// NormalizeUnitValues sets the values of single units of currencies.
+func (c *Currencies) NormalizeUnitValues() {
+	for i := range c.Currencies {
+		c.Currencies[i].NormalizeUnitValue()
+	}
 }
 
 type UpdateDatetime struct {
-	Id             int    `sql:"id"`
-	UpdateDatetime string `sql:"update_datetime"`
+	Id             int    `sql:"id" json:"id"`
+	UpdateDatetime string `sql:"update_datetime" json:"updateDatetime"`
+	RateDate       string `sql:"rate_date" json:"rateDate"`
+}
+
+// A Usage is the number of the requests, that are made with the API key
+// on the date.
+type Usage struct {
+	KeyName  string `sql:"key_name" json:"keyName"`
+	Date     string `sql:"usage_date" json:"date"`
+	Requests int64  `sql:"requests" json:"requests"`
+}
+
+// A UsageCount is the number of the requests, that are made with the
+// API key on the date and in the month of the date up to it.
+type UsageCount struct {
+	Day   int64
+	Month int64
+}
+
+// An AuditEntry is the action of the admin, that is recorded along with
+// the actor, who made it, and the payload of it. The time of creation
+// is in RFC 3339 in UTC.
+type AuditEntry struct {
+	Id        int             `sql:"id" json:"id"`
+	Actor     string          `sql:"actor" json:"actor"`
+	Action    string          `sql:"action" json:"action"`
+	Payload   json.RawMessage `sql:"payload" json:"payload"`
+	CreatedAt string          `sql:"created_at" json:"createdAt"`
+}
+
+// A HistoryCurrency is a currency of some update in the past.
+type HistoryCurrency struct {
+	UpdateDatetimeId int
+	RateDate         string
+	Currency
+}
+
+// RateDateLayout is the layout of the dates, the rates are effective on.
+const RateDateLayout = time.DateOnly
+
+// RateDateString returns the date, the rates are effective on, or an
+// empty string, if the source has not provided it.
+func (c *Currencies) RateDateString() string {
+	if c.RateDate.IsZero() {
+		return ""
+	}
+
+	return c.RateDate.Format(RateDateLayout)
 }
 
 type CalculatedCurrency struct {
-	Name     string `json:"name"`
-	CharCode string `json:"charCode"`
-	Ratio    string `json:"ratio"`
+	Name       string   `json:"name"`
+	CharCode   string   `json:"charCode"`
+	UnitValue  string   `json:"unitValue"`
+	Ratio      string   `json:"ratio"`
+	Symbol     string   `json:"symbol,omitempty"`
+	MinorUnits *int     `json:"minorUnits,omitempty"`
+	Countries  []string `json:"countries,omitempty"`
+}
+
+type Metals struct {
+	XMLName xml.Name `xml:"Metall"`
+	Metals  []Metal  `xml:"Record"`
+}
+
+type Metal struct {
+	Date string `xml:"Date,attr"`
+	Code int    `xml:"Code,attr"`
+	Buy  Value  `xml:"Buy"`
+	Sell Value  `xml:"Sell"`
+}
+
+// metalNames are the names of the metals by the codes of the source,
+// which does not publish them.
+var metalNames = map[int]string{
+	1: "Золото",
+	2: "Серебро",
+	3: "Платина",
+	4: "Палладий",
+}
+
+// Name returns the name of the metal, or an empty string, if the code
+// of it is unknown.
+func (m Metal) Name() string {
+	return metalNames[m.Code]
+}
+
+type CalculatedMetal struct {
+	Name string `json:"name"`
+	Code int    `json:"code"`
+	Date string `json:"date"`
+	Buy  string `json:"buy"`
+	Sell string `json:"sell"`
 }
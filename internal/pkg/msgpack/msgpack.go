@@ -0,0 +1,166 @@
+// Package msgpack encodes the values in MessagePack. The values are
+// encoded the same way as in JSON, e.g. by the json tags of the fields
+// and the JSON marshalers, so the encodings are interchangeable.
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// ErrUnsupportedValue is returned, when the value can not be encoded.
+var ErrUnsupportedValue = errors.New("unsupported value")
+
+// Marshal returns the MessagePack encoding of the value.
+func Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not encode value to json")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var generic any
+
+	if err = decoder.Decode(&generic); err != nil {
+		return nil, errlib.Wrap(err, "could not decode json of value")
+	}
+
+	var buf bytes.Buffer
+
+	if err = encode(&buf, generic); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encode writes the value, that is decoded from JSON, to the buffer.
+// The keys of the maps are sorted, so the encoding is the same every
+// time.
+func encode(buf *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		return encodeNumber(buf, v)
+	case string:
+		encodeString(buf, v)
+	case []any:
+		encodeLength(buf, len(v), 0x90, 0xdc, 0xdd)
+
+		for _, item := range v {
+			if err := encode(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+
+		for key := range v {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		encodeLength(buf, len(keys), 0x80, 0xde, 0xdf)
+
+		for _, key := range keys {
+			encodeString(buf, key)
+
+			if err := encode(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return ErrUnsupportedValue
+	}
+
+	return nil
+}
+
+// encodeNumber writes the integer in the smallest format, that fits it,
+// and the other numbers as the 64-bit floats.
+func encodeNumber(buf *bytes.Buffer, n json.Number) error {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		encodeInt(buf, i)
+
+		return nil
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return errlib.Wrap(ErrUnsupportedValue, "number "+string(n)+" is out of range")
+	}
+
+	buf.WriteByte(0xcb)
+	_ = binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+
+	return nil
+}
+
+func encodeInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case (i >= 0) && (i <= 0x7f):
+		buf.WriteByte(byte(i))
+	case (i < 0) && (i >= -32):
+		buf.WriteByte(byte(int8(i)))
+	case (i >= math.MinInt8) && (i <= math.MaxInt8):
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(int8(i)))
+	case (i >= math.MinInt16) && (i <= math.MaxInt16):
+		buf.WriteByte(0xd1)
+		_ = binary.Write(buf, binary.BigEndian, int16(i))
+	case (i >= math.MinInt32) && (i <= math.MaxInt32):
+		buf.WriteByte(0xd2)
+		_ = binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		_ = binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+func encodeString(buf *bytes.Buffer, s string) {
+	switch {
+	case len(s) <= 31:
+		buf.WriteByte(0xa0 | byte(len(s)))
+	case len(s) <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(len(s)))
+	default:
+		encodeLength(buf, len(s), 0, 0xda, 0xdb)
+	}
+
+	buf.WriteString(s)
+}
+
+// encodeLength writes the length of the array, the map or the string
+// with the prefix of the fixed format, that is used for the lengths up
+// to 15, or with the ones of the 16-bit and the 32-bit formats. The zero
+// fixed prefix means, that the fixed format is not used.
+func encodeLength(buf *bytes.Buffer, length int, fixed byte, prefix16 byte, prefix32 byte) {
+	switch {
+	case (fixed != 0) && (length <= 15):
+		buf.WriteByte(fixed | byte(length))
+	case length <= math.MaxUint16:
+		buf.WriteByte(prefix16)
+		_ = binary.Write(buf, binary.BigEndian, uint16(length))
+	default:
+		buf.WriteByte(prefix32)
+		_ = binary.Write(buf, binary.BigEndian, uint32(length))
+	}
+}
@@ -0,0 +1,360 @@
+// Package oidc verifies the ID tokens of the OpenID Connect provider,
+// so the admins are authenticated by the provider of the organization
+// instead of the static keys.
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/tracing"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+
+	// keysRefreshInterval is how often the keys of the provider may be
+	// fetched again, when the token is signed by the unknown key, so
+	// the forged tokens do not flood the provider with requests.
+	keysRefreshInterval = time.Minute
+
+	// clockSkew is the difference of the clocks of the provider and
+	// of the service, that is tolerated by the checks of the time.
+	clockSkew = time.Minute
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid id token")
+	ErrUnknownKey   = errors.New("id token is signed by unknown key")
+	ErrNoScope      = errors.New("no group of id token has scope")
+)
+
+// signingMethods are the algorithms of the signatures of the ID tokens,
+// that are accepted. The symmetric ones are not, as the keys of the
+// provider are public.
+var signingMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+}
+
+// An Identity is the admin, who is authenticated by the ID token, along
+// with the scope, that is granted to the groups of the admin.
+type Identity struct {
+	Subject string
+	Email   string
+	Groups  []string
+	Scope   string
+}
+
+// Name returns the name of the admin, that is the email, if the token
+// has it, or the subject otherwise.
+func (i Identity) Name() string {
+	if i.Email != "" {
+		return i.Email
+	}
+
+	return i.Subject
+}
+
+// A Verifier checks the ID tokens of the provider by the public keys,
+// that are discovered from the issuer on the first use and are fetched
+// again, when the provider rotates them.
+type Verifier struct {
+	issuer      string
+	clientId    string
+	groupsClaim string
+	groupScopes map[string]string
+	client      *http.Client
+	clock       clock.Clock
+
+	mu        sync.Mutex
+	keysUrl   string
+	keys      map[string]any
+	fetchedAt time.Time
+}
+
+func New(cfg *config.Config, cl clock.Clock) *Verifier {
+	return &Verifier{
+		issuer:      cfg.OidcIssuerUrl,
+		clientId:    cfg.OidcClientId,
+		groupsClaim: cfg.OidcGroupsClaim,
+		groupScopes: cfg.OidcGroupScopes,
+		client:      &http.Client{Timeout: cfg.OidcTimeout},
+		clock:       cl,
+	}
+}
+
+// Verify checks the signature, the issuer, the audience and the time of
+// the ID token and returns the identity of the admin. The scope of the
+// admin is the widest one of the groups.
+func (v *Verifier) Verify(ctx context.Context, rawToken string) (Identity, error) {
+	parser := jwt.Parser{ValidMethods: signingMethods, SkipClaimsValidation: true}
+
+	token, err := parser.Parse(rawToken, func(token *jwt.Token) (any, error) {
+		keyId, _ := token.Header["kid"].(string)
+
+		return v.key(ctx, keyId)
+	})
+	if err != nil {
+		return Identity{}, errlib.Wrap(ErrInvalidToken, err.Error())
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return Identity{}, ErrInvalidToken
+	}
+
+	now := v.clock.Now()
+
+	switch {
+	case !claims.VerifyIssuer(v.issuer, true):
+		return Identity{}, errlib.Wrap(ErrInvalidToken, "unexpected issuer")
+	case !claims.VerifyAudience(v.clientId, true):
+		return Identity{}, errlib.Wrap(ErrInvalidToken, "unexpected audience")
+	case !claims.VerifyExpiresAt(now.Add(-clockSkew).Unix(), true):
+		return Identity{}, errlib.Wrap(ErrInvalidToken, "token is expired")
+	case !claims.VerifyNotBefore(now.Add(clockSkew).Unix(), false):
+		return Identity{}, errlib.Wrap(ErrInvalidToken, "token is not valid yet")
+	}
+
+	identity := Identity{Groups: stringsOf(claims[v.groupsClaim])}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+
+	if identity.Subject == "" {
+		return Identity{}, errlib.Wrap(ErrInvalidToken, "no subject")
+	}
+
+	for _, group := range identity.Groups {
+		scope := v.groupScopes[group]
+
+		if (scope == config.ScopeAdmin) || (identity.Scope == "") {
+			identity.Scope = scope
+		}
+
+		if identity.Scope == config.ScopeAdmin {
+			break
+		}
+	}
+
+	if identity.Scope == "" {
+		return identity, ErrNoScope
+	}
+
+	return identity, nil
+}
+
+// key returns the public key by the id. The keys are fetched, if the
+// key is unknown and they have not been fetched recently.
+func (v *Verifier) key(ctx context.Context, keyId string) (any, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, ok := v.keys[keyId]; ok {
+		return key, nil
+	}
+
+	if v.clock.Now().Sub(v.fetchedAt) < keysRefreshInterval {
+		return nil, ErrUnknownKey
+	}
+
+	// The failed fetches are limited too, so the provider, that is
+	// down, is not requested by every admin request.
+	v.fetchedAt = v.clock.Now()
+
+	if err := v.fetchKeys(ctx); err != nil {
+		return nil, errlib.Wrap(err, "could not fetch keys of provider")
+	}
+
+	if key, ok := v.keys[keyId]; ok {
+		return key, nil
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// fetchKeys discovers the URL of the keys of the provider, if it is not
+// known yet, and fetches the keys by it.
+func (v *Verifier) fetchKeys(ctx context.Context) error {
+	if v.keysUrl == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JwksUri string `json:"jwks_uri"`
+		}
+
+		if err := v.getJson(ctx, strings.TrimSuffix(v.issuer, "/")+discoveryPath, &discovery); err != nil {
+			return errlib.Wrap(err, "could not discover provider")
+		}
+
+		if discovery.Issuer != v.issuer {
+			return errors.New("provider has issuer " + discovery.Issuer + " instead of " + v.issuer)
+		}
+
+		if discovery.JwksUri == "" {
+			return errors.New("provider has no jwks uri")
+		}
+
+		v.keysUrl = discovery.JwksUri
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := v.getJson(ctx, v.keysUrl, &set); err != nil {
+		return errlib.Wrap(err, "could not get key set")
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+
+	for _, jwk := range set.Keys {
+		// The keys of the other use, e.g. of the encryption, and of
+		// the unsupported types are skipped.
+		if (jwk.Use != "") && (jwk.Use != "sig") {
+			continue
+		}
+
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.KeyId] = key
+		}
+	}
+
+	v.keys = keys
+
+	return nil
+}
+
+func (v *Verifier) getJson(ctx context.Context, url string, dest any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return errlib.Wrap(err, "could not create request")
+	}
+
+	tracing.Inject(ctx, req.Header)
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return errlib.Wrap(err, "could not send request")
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.New("unexpected response status " + strconv.Itoa(res.StatusCode))
+	}
+
+	if err = json.NewDecoder(res.Body).Decode(dest); err != nil {
+		return errlib.Wrap(err, "could not decode json")
+	}
+
+	return nil
+}
+
+// A jsonWebKey is the public key of the provider by RFC 7517.
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyId   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() {
+			return nil, errors.New("rsa exponent is too large")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.New("unsupported curve " + k.Curve)
+		}
+
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, errors.New("unsupported key type " + k.KeyType)
+	}
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not decode key")
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
+
+// stringsOf returns the strings of the claim, that is either a string
+// or a list of them.
+func stringsOf(claim any) []string {
+	switch value := claim.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		result := make([]string, 0, len(value))
+
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+
+		return result
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+const (
+	cbrJsonDateLayout = "02.01.2006"
+	cbrJsonName       = "Foreign Currency Market"
+)
+
+type cbrJsonDocument struct {
+	Date   time.Time                  `json:"Date"`
+	Valute map[string]cbrJsonCurrency `json:"Valute"`
+}
+
+type cbrJsonCurrency struct {
+	NumCode  string      `json:"NumCode"`
+	CharCode string      `json:"CharCode"`
+	Nominal  int         `json:"Nominal"`
+	Name     string      `json:"Name"`
+	Value    json.Number `json:"Value"`
+}
+
+// A CbrJsonParser parses the daily JSON feed of CBR rates.
+type CbrJsonParser struct {
+	config *config.Config
+}
+
+func NewCbrJsonParser(cfg *config.Config) *CbrJsonParser {
+	return &CbrJsonParser{config: cfg}
+}
+
+func (p *CbrJsonParser) Parse(data []byte) (models.Currencies, error) {
+	currencies := models.Currencies{
+		Currencies: make([]models.Currency, 0, p.config.InitialCurrenciesCapacity),
+	}
+
+	var document cbrJsonDocument
+
+	if err := json.Unmarshal(data, &document); err != nil {
+		return currencies, errlib.Wrap(err, "could not decode json data")
+	}
+
+	currencies.Name = cbrJsonName
+	currencies.Date = document.Date.Format(cbrJsonDateLayout)
+	currencies.RateDate = time.Date(
+		document.Date.Year(),
+		document.Date.Month(),
+		document.Date.Day(),
+		0, 0, 0, 0,
+		time.UTC,
+	)
+
+	for _, currency := range document.Valute {
+		numCode, err := strconv.Atoi(currency.NumCode)
+		if err != nil {
+			return currencies, errlib.Wrap(err, "could not parse num code of "+currency.CharCode)
+		}
+
+		currencies.Currencies = append(currencies.Currencies, models.Currency{
+			NumCode:    numCode,
+			CharCode:   currency.CharCode,
+			Multiplier: currency.Nominal,
+			Name:       currency.Name,
+			Value:      models.Value(currency.Value.String()),
+		})
+	}
+
+	sort.Slice(currencies.Currencies, func(i, j int) bool {
+		return currencies.Currencies[i].CharCode < currencies.Currencies[j].CharCode
+	})
+
+	currencies.NormalizeUnitValues()
+
+	return currencies, nil
+}
@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ecbDateLayout     = "2006-01-02"
+	ecbRateDateLayout = "02.01.2006"
+	ecbName           = "Euro foreign exchange reference rates"
+	ecbValuePrecision = 4
+)
+
+// ecbNumCodes maps the currencies, published by ECB, to their numeric
+// ISO 4217 codes, which ECB does not provide.
+var ecbNumCodes = map[string]int{
+	"AUD": 36, "BGN": 975, "BRL": 986, "CAD": 124, "CHF": 756,
+	"CNY": 156, "CZK": 203, "DKK": 208, "GBP": 826, "HKD": 344,
+	"HUF": 348, "IDR": 360, "ILS": 376, "INR": 356, "ISK": 352,
+	"JPY": 392, "KRW": 410, "MXN": 484, "MYR": 458, "NOK": 578,
+	"NZD": 554, "PHP": 608, "PLN": 985, "RON": 946, "SEK": 752,
+	"SGD": 702, "THB": 764, "TRY": 949, "USD": 840, "ZAR": 710,
+}
+
+type ecbDocument struct {
+	Cube struct {
+		Cube struct {
+			Time  string    `xml:"time,attr"`
+			Rates []ecbRate `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+type ecbRate struct {
+	Currency string `xml:"currency,attr"`
+	Rate     string `xml:"rate,attr"`
+}
+
+// An EcbXmlParser parses the daily reference rates of ECB. ECB quotes
+// currencies per euro, so the values are inverted to be euros per the
+// multiplier of currency units, as CBR does with rubles.
+type EcbXmlParser struct {
+	config *config.Config
+}
+
+func NewEcbXmlParser(cfg *config.Config) *EcbXmlParser {
+	return &EcbXmlParser{config: cfg}
+}
+
+func (p *EcbXmlParser) Parse(data []byte) (models.Currencies, error) {
+	currencies := models.Currencies{
+		Currencies: make([]models.Currency, 0, p.config.InitialCurrenciesCapacity),
+	}
+
+	var document ecbDocument
+
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&document); err != nil {
+		return currencies, errlib.Wrap(err, "could not decode xml data")
+	}
+
+	rateDate, err := time.Parse(ecbDateLayout, document.Cube.Cube.Time)
+	if err != nil {
+		return currencies, errlib.Wrap(err, "could not parse rate date")
+	}
+
+	currencies.Name = ecbName
+	currencies.Date = rateDate.Format(ecbRateDateLayout)
+	currencies.RateDate = rateDate
+
+	for _, rate := range document.Cube.Cube.Rates {
+		perEuro, err := decimal.NewFromString(rate.Rate)
+		if err != nil {
+			return currencies, errlib.Wrap(err, "could not parse rate of "+rate.Currency)
+		}
+
+		if !perEuro.IsPositive() {
+			return currencies, errors.New("non-positive rate of " + rate.Currency)
+		}
+
+		multiplier := ecbMultiplier(perEuro.InexactFloat64())
+
+		currencies.Currencies = append(currencies.Currencies, models.Currency{
+			NumCode:    ecbNumCodes[rate.Currency],
+			CharCode:   rate.Currency,
+			Multiplier: multiplier,
+			Name:       rate.Currency,
+			Value: models.Value(decimal.NewFromInt(int64(multiplier)).
+				DivRound(perEuro, ecbValuePrecision).
+				StringFixed(ecbValuePrecision)),
+		})
+	}
+
+	currencies.NormalizeUnitValues()
+
+	return currencies, nil
+}
+
+// ecbMultiplier returns the power of ten, that makes the value of the
+// currency units to be at least one euro.
+func ecbMultiplier(perEuro float64) int {
+	if perEuro <= 1 {
+		return 1
+	}
+
+	return int(math.Pow(10, math.Ceil(math.Log10(perEuro))))
+}
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"errors"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	xmlparser "github.com/mrumyantsev/currency-converter-app/internal/pkg/xml-parser"
+)
+
+const (
+	FormatCbrXml  = config.SourceFormatCbrXml
+	FormatCbrJson = config.SourceFormatCbrJson
+	FormatEcbXml  = config.SourceFormatEcbXml
+
+	floatBitSize = 64
+)
+
+// A Parser parses currency data of a source format.
+type Parser interface {
+	Parse(data []byte) (models.Currencies, error)
+}
+
+// A Constructor creates a parser of some source format.
+type Constructor func(cfg *config.Config) Parser
+
+var registry = map[string]Constructor{
+	FormatCbrXml: func(cfg *config.Config) Parser {
+		return xmlparser.New(cfg)
+	},
+	FormatCbrJson: func(cfg *config.Config) Parser {
+		return NewCbrJsonParser(cfg)
+	},
+	FormatEcbXml: func(cfg *config.Config) Parser {
+		return NewEcbXmlParser(cfg)
+	},
+}
+
+// Register adds the parser constructor of the source format. It
+// replaces the constructor, that was registered for the format before.
+func Register(format string, constructor Constructor) {
+	registry[format] = constructor
+}
+
+// New creates the parser of the configured source format.
+func New(cfg *config.Config) (Parser, error) {
+	return NewOfFormat(cfg, cfg.CurrencySourceFormat)
+}
+
+// NewOfFormat creates the parser of the source format, that may differ
+// from the configured one, e.g. of the extra source.
+func NewOfFormat(cfg *config.Config, format string) (Parser, error) {
+	constructor, ok := registry[format]
+	if !ok {
+		return nil, errors.New("unknown currency source format: " + format)
+	}
+
+	return constructor(cfg), nil
+}
@@ -0,0 +1,168 @@
+// Package projection selects the fields of the structs, that are
+// encoded in JSON, so the clients receive only the ones they need.
+package projection
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/mrumyantsev/go-errlib"
+)
+
+var (
+	// ErrUnknownField is returned, when the struct has no field by the
+	// name.
+	ErrUnknownField = errors.New("unknown field")
+
+	// ErrNoFields is returned, when no fields are selected.
+	ErrNoFields = errors.New("no fields")
+)
+
+// A field is the field of the struct by the name of it in JSON.
+type field struct {
+	index       int
+	name        string
+	isOmitEmpty bool
+}
+
+// A Projection selects the fields of the structs of a type by the names
+// of them in JSON. The selected fields are encoded in the order of the
+// struct and by the tags of them, so the projected value is the same as
+// the whole one without the other fields.
+type Projection struct {
+	typ    reflect.Type
+	fields []field
+}
+
+// New returns the projection of the structs of the type of the value
+// to the fields by the names, e.g. charCode,unitValue.
+func New(v any, names []string) (*Projection, error) {
+	typ := reflect.TypeOf(v)
+
+	byName := make(map[string]field, typ.NumField())
+	known := make([]string, 0, typ.NumField())
+
+	for i := 0; i < typ.NumField(); i++ {
+		name, options, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if (name == "") || (name == "-") {
+			continue
+		}
+
+		byName[name] = field{
+			index:       i,
+			name:        name,
+			isOmitEmpty: strings.Contains(options, "omitempty"),
+		}
+
+		known = append(known, name)
+	}
+
+	selected := make(map[int]bool, len(names))
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		f, ok := byName[name]
+		if !ok {
+			return nil, errlib.Wrap(ErrUnknownField, "field "+name+" is not any of "+strings.Join(known, ","))
+		}
+
+		selected[f.index] = true
+	}
+
+	if len(selected) == 0 {
+		return nil, ErrNoFields
+	}
+
+	p := &Projection{typ: typ}
+
+	for _, name := range known {
+		if f := byName[name]; selected[f.index] {
+			p.fields = append(p.fields, f)
+		}
+	}
+
+	return p, nil
+}
+
+// A Value is the struct, that is encoded in JSON with the fields of the
+// projection only.
+type Value struct {
+	projection *Projection
+	value      reflect.Value
+}
+
+// Value returns the value, that is encoded with the fields of the
+// projection only. The value must be of the type of the projection.
+func (p *Projection) Value(v any) Value {
+	return Value{
+		projection: p,
+		value:      reflect.ValueOf(v),
+	}
+}
+
+// Apply returns the values, that are encoded with the fields of the
+// projection only.
+func Apply[T any](p *Projection, values []T) []Value {
+	projected := make([]Value, 0, len(values))
+
+	for _, value := range values {
+		projected = append(projected, p.Value(value))
+	}
+
+	return projected
+}
+
+func (v Value) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+
+	isFirst := true
+
+	for _, f := range v.projection.fields {
+		fieldValue := v.value.Field(f.index)
+
+		if f.isOmitEmpty && isEmpty(fieldValue) {
+			continue
+		}
+
+		data, err := json.Marshal(fieldValue.Interface())
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not encode field "+f.name)
+		}
+
+		if !isFirst {
+			buf.WriteByte(',')
+		}
+
+		isFirst = false
+
+		// The names are the ones of the tags, that need no escaping.
+		buf.WriteString(`"` + f.name + `":`)
+		buf.Write(data)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// isEmpty reports, whether the value is omitted by the omitempty option
+// of the field in JSON.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
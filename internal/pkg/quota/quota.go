@@ -0,0 +1,161 @@
+// Package quota counts the requests, that are made with the API keys,
+// and checks them against the daily and the monthly quotas of the keys.
+package quota
+
+import (
+	"context"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/storage"
+)
+
+// The windows of the quotas, that the requests are counted in.
+const (
+	WindowDay   = "day"
+	WindowMonth = "month"
+)
+
+// A Status is the usage of the key at the time of the request along
+// with the quotas of it. The quota of zero is unlimited.
+type Status struct {
+	KeyName      string
+	Count        models.UsageCount
+	DailyQuota   int64
+	MonthlyQuota int64
+	At           time.Time
+}
+
+// A Limit is the quota of the window along with the number of the
+// requests, that are left in it, and the time, when it is reset.
+type Limit struct {
+	Window    string
+	Quota     int64
+	Remaining int64
+	ResetAt   time.Time
+}
+
+// IsDailyExceeded reports, whether there are more requests today, than
+// the daily quota allows.
+func (s Status) IsDailyExceeded() bool {
+	return (s.DailyQuota > 0) && (s.Count.Day > s.DailyQuota)
+}
+
+// IsMonthlyExceeded reports, whether there are more requests this
+// month, than the monthly quota allows.
+func (s Status) IsMonthlyExceeded() bool {
+	return (s.MonthlyQuota > 0) && (s.Count.Month > s.MonthlyQuota)
+}
+
+// Limit returns the limit of the window, that has the fewest requests
+// left, or of the one, that is reset later, if they have as many. It
+// returns false, if the key has no quotas.
+func (s Status) Limit() (Limit, bool) {
+	day := s.At.UTC().Truncate(24 * time.Hour)
+
+	var limits []Limit
+
+	if s.DailyQuota > 0 {
+		limits = append(limits, Limit{
+			Window:    WindowDay,
+			Quota:     s.DailyQuota,
+			Remaining: remaining(s.DailyQuota, s.Count.Day),
+			ResetAt:   day.AddDate(0, 0, 1),
+		})
+	}
+
+	if s.MonthlyQuota > 0 {
+		limits = append(limits, Limit{
+			Window:    WindowMonth,
+			Quota:     s.MonthlyQuota,
+			Remaining: remaining(s.MonthlyQuota, s.Count.Month),
+			ResetAt:   day.AddDate(0, 1, 1-day.Day()),
+		})
+	}
+
+	if len(limits) == 0 {
+		return Limit{}, false
+	}
+
+	limit := limits[0]
+
+	for _, other := range limits[1:] {
+		if (other.Remaining < limit.Remaining) ||
+			((other.Remaining == limit.Remaining) && other.ResetAt.After(limit.ResetAt)) {
+			limit = other
+		}
+	}
+
+	return limit, true
+}
+
+func remaining(quota int64, count int64) int64 {
+	if count >= quota {
+		return 0
+	}
+
+	return quota - count
+}
+
+// A Quota counts the requests in the storage, so the usage is kept
+// across the restarts and is shared by the instances. The days and the
+// months are the ones of UTC.
+type Quota struct {
+	config  *config.Config
+	tracker storage.UsageTracker
+	clock   clock.Clock
+}
+
+func New(cfg *config.Config, tr storage.UsageTracker, cl clock.Clock) *Quota {
+	return &Quota{
+		config:  cfg,
+		tracker: tr,
+		clock:   cl,
+	}
+}
+
+// Use counts the request, that is made with the key, and returns the
+// usage of the key including the request.
+func (q *Quota) Use(ctx context.Context, keyName string) (Status, error) {
+	status := Status{KeyName: keyName, At: q.clock.Now()}
+
+	status.DailyQuota, status.MonthlyQuota = q.Quotas(keyName)
+
+	count, err := q.tracker.IncrementUsage(ctx, keyName, q.Today())
+	if err != nil {
+		return status, err
+	}
+
+	status.Count = count
+
+	return status, nil
+}
+
+// Quotas returns the daily and the monthly quotas of the key, which are
+// the ones of the key or the default ones.
+func (q *Quota) Quotas(keyName string) (int64, int64) {
+	daily, ok := q.config.ApiKeyDailyQuotas[keyName]
+	if !ok {
+		daily = q.config.ApiKeyDailyQuota
+	}
+
+	monthly, ok := q.config.ApiKeyMonthlyQuotas[keyName]
+	if !ok {
+		monthly = q.config.ApiKeyMonthlyQuota
+	}
+
+	return daily, monthly
+}
+
+// Usage returns the numbers of the requests of the keys by the dates of
+// the period from the first date to the last one inclusive.
+func (q *Quota) Usage(ctx context.Context, fromDate string, toDate string) ([]models.Usage, error) {
+	return q.tracker.GetUsage(ctx, fromDate, toDate)
+}
+
+// Today returns the date of today, that the requests are counted on.
+func (q *Quota) Today() string {
+	return q.clock.Now().UTC().Format(models.RateDateLayout)
+}
@@ -0,0 +1,116 @@
+package rates
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+// basketPrecision is the number of the decimal places of the values of
+// the baskets, as of the values of the source.
+const basketPrecision = 4
+
+var ErrInvalidBasket = errors.New("invalid basket")
+
+// A Component is the currency of the basket along with the number of
+// the units of it in the basket.
+type Component struct {
+	CharCode string
+	Weight   decimal.Decimal
+}
+
+// A Basket is the synthetic currency, a unit of which is worth the sum
+// of the values of the components, e.g. the basket of 0.5 USD and 0.5
+// EUR. The char code of it is the pseudo-code, that it is served by.
+type Basket struct {
+	CharCode   string
+	Components []Component
+}
+
+// ParseBasket parses the basket of the form CODE:CURRENCY=WEIGHT+...,
+// e.g. BSK:USD=0.5+EUR=0.5.
+func ParseBasket(text string) (Basket, error) {
+	charCode, components, ok := strings.Cut(text, ":")
+	if !ok || (charCode == "") || (components == "") {
+		return Basket{}, errlib.Wrap(ErrInvalidBasket, text+": expected CODE:CURRENCY=WEIGHT+...")
+	}
+
+	basket := Basket{CharCode: strings.ToUpper(charCode)}
+
+	seen := make(map[string]bool)
+
+	for _, component := range strings.Split(components, "+") {
+		componentCode, rawWeight, _ := strings.Cut(component, "=")
+
+		componentCode = strings.ToUpper(componentCode)
+
+		weight, err := decimal.NewFromString(rawWeight)
+		if err != nil {
+			return Basket{}, errlib.Wrap(ErrInvalidBasket, text+": invalid weight of "+componentCode)
+		}
+
+		if (componentCode == "") || (componentCode == basket.CharCode) || seen[componentCode] {
+			return Basket{}, errlib.Wrap(ErrInvalidBasket, text+": currencies must be different from each other and the basket")
+		}
+
+		if !weight.IsPositive() {
+			return Basket{}, errlib.Wrap(ErrInvalidBasket, text+": weight of "+componentCode+" must be positive")
+		}
+
+		seen[componentCode] = true
+
+		basket.Components = append(basket.Components, Component{CharCode: componentCode, Weight: weight})
+	}
+
+	return basket, nil
+}
+
+// Name returns the name of the basket, that lists the components, e.g.
+// "Корзина 0.5 USD + 0.5 EUR".
+func (b Basket) Name() string {
+	parts := make([]string, 0, len(b.Components))
+
+	for _, component := range b.Components {
+		parts = append(parts, component.Weight.String()+" "+component.CharCode)
+	}
+
+	return "Корзина " + strings.Join(parts, " + ")
+}
+
+// Value returns the value of a unit of the basket in the base currency.
+func (b Basket) Value(r *Rates) (decimal.Decimal, error) {
+	value := decimal.Zero
+
+	for _, component := range b.Components {
+		unitValue, err := r.UnitValue(component.CharCode)
+		if err != nil {
+			return decimal.Zero, err
+		}
+
+		value = value.Add(unitValue.Mul(component.Weight))
+	}
+
+	return value, nil
+}
+
+// Currency returns the basket as the currency of the rates, which is
+// served along with the ones of the source. It has no numeric code.
+func (b Basket) Currency(r *Rates) (models.Currency, error) {
+	value, err := b.Value(r)
+	if err != nil {
+		return models.Currency{}, errlib.Wrap(err, "could not calculate value of basket "+b.CharCode)
+	}
+
+	rounded := models.Value(value.StringFixed(basketPrecision))
+
+	return models.Currency{
+		CharCode:   b.CharCode,
+		Multiplier: 1,
+		Name:       b.Name(),
+		Value:      rounded,
+		UnitValue:  rounded,
+	}, nil
+}
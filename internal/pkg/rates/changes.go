@@ -0,0 +1,55 @@
+package rates
+
+import (
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// A Change is the change of the rate of a currency from the previous
+// rates to the current ones.
+type Change struct {
+	CharCode      string          `json:"charCode"`
+	Name          string          `json:"name"`
+	Previous      decimal.Decimal `json:"previous"`
+	Current       decimal.Decimal `json:"current"`
+	Change        decimal.Decimal `json:"change"`
+	ChangePercent decimal.Decimal `json:"changePercent"`
+}
+
+// Changes returns the changes of the rates of the currencies, that are
+// in both the previous rates and the current ones, from the largest
+// percentage change in either direction to the smallest one.
+func Changes(previous *Rates, current *Rates) []Change {
+	previousValues := make(map[string]decimal.Decimal, len(previous.Rates))
+
+	for _, rate := range previous.Rates {
+		previousValues[rate.CharCode] = rate.Value
+	}
+
+	changes := make([]Change, 0, len(current.Rates))
+
+	for _, rate := range current.Rates {
+		previousValue, ok := previousValues[rate.CharCode]
+		if !ok || previousValue.IsZero() {
+			continue
+		}
+
+		change := rate.Value.Sub(previousValue)
+
+		changes = append(changes, Change{
+			CharCode:      rate.CharCode,
+			Name:          rate.Name,
+			Previous:      previousValue,
+			Current:       rate.Value,
+			Change:        change,
+			ChangePercent: change.Div(previousValue).Mul(hundredPercent),
+		})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].ChangePercent.Abs().GreaterThan(changes[j].ChangePercent.Abs())
+	})
+
+	return changes
+}
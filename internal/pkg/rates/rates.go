@@ -0,0 +1,101 @@
+package rates
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+// BaseCharCode is the char code of the currency, which the values of
+// the rates are in.
+const BaseCharCode = "RUB"
+
+var hundredPercent = decimal.NewFromInt(100)
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrNoRates         = errors.New("no rates")
+)
+
+// A Rate is the value of a single unit of a currency in the base
+// currency.
+type Rate struct {
+	NumCode  int             `json:"numCode"`
+	CharCode string          `json:"charCode"`
+	Name     string          `json:"name"`
+	Value    decimal.Decimal `json:"value"`
+}
+
+// Rates are the rates of the currencies, that are effective on the
+// date.
+type Rates struct {
+	Date  time.Time `json:"date"`
+	Rates []Rate    `json:"rates"`
+}
+
+// UnitValue returns the value of a single unit of the currency with the
+// char code in the base currency. The char code is case-insensitive.
+func (r *Rates) UnitValue(charCode string) (decimal.Decimal, error) {
+	if strings.EqualFold(charCode, BaseCharCode) {
+		return decimal.NewFromInt(1), nil
+	}
+
+	for _, rate := range r.Rates {
+		if strings.EqualFold(rate.CharCode, charCode) {
+			return rate.Value, nil
+		}
+	}
+
+	return decimal.Zero, errlib.Wrap(ErrUnknownCurrency, charCode)
+}
+
+// Convert converts the amount of the currency with the char code from
+// to the currency with the char code to. The result is exact up to the
+// decimal.DivisionPrecision places.
+func (r *Rates) Convert(amount decimal.Decimal, from string, to string) (decimal.Decimal, error) {
+	fromValue, err := r.UnitValue(from)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	toValue, err := r.UnitValue(to)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return amount.Mul(fromValue).Div(toValue), nil
+}
+
+// ApplyMarkup returns the value less the markup percent of it, e.g. the
+// rate, that is quoted instead of the official one.
+func ApplyMarkup(value decimal.Decimal, markupPercent decimal.Decimal) decimal.Decimal {
+	return value.Mul(hundredPercent.Sub(markupPercent)).Div(hundredPercent)
+}
+
+// Of returns the rates of the currencies.
+func Of(currencies models.Currencies) (*Rates, error) {
+	rates := &Rates{
+		Date:  currencies.RateDate,
+		Rates: make([]Rate, 0, len(currencies.Currencies)),
+	}
+
+	for _, currency := range currencies.Currencies {
+		value, err := currency.UnitValueDecimal()
+		if err != nil {
+			return nil, err
+		}
+
+		rates.Rates = append(rates.Rates, Rate{
+			NumCode:  currency.NumCode,
+			CharCode: currency.CharCode,
+			Name:     currency.Name,
+			Value:    value,
+		})
+	}
+
+	return rates, nil
+}
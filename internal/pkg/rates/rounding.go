@@ -0,0 +1,49 @@
+package rates
+
+import (
+	"errors"
+
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+// The rounding modes of the converted amounts. The half-up mode rounds
+// the halves away from zero, and the half-even one, which is also known
+// as the bankers one, rounds them to the even digit.
+const (
+	RoundingHalfUp   = "half-up"
+	RoundingHalfEven = "half-even"
+	RoundingBankers  = "bankers"
+	RoundingDown     = "down"
+	RoundingUp       = "up"
+)
+
+// MaxPrecision is the largest number of the decimal places, the amount
+// is rounded to.
+const MaxPrecision = 16
+
+var ErrUnknownRounding = errors.New("unknown rounding mode")
+
+// Round rounds the value to the number of the decimal places by the
+// rounding mode. The down and up modes round towards and away from zero.
+func Round(value decimal.Decimal, places int32, mode string) (decimal.Decimal, error) {
+	switch mode {
+	case RoundingHalfUp:
+		return value.Round(places), nil
+	case RoundingHalfEven, RoundingBankers:
+		return value.RoundBank(places), nil
+	case RoundingDown:
+		return value.RoundDown(places), nil
+	case RoundingUp:
+		return value.RoundUp(places), nil
+	default:
+		return decimal.Zero, errlib.Wrap(ErrUnknownRounding, mode)
+	}
+}
+
+// IsRounding reports, whether the mode is one of the rounding modes.
+func IsRounding(mode string) bool {
+	_, err := Round(decimal.Zero, 0, mode)
+
+	return err == nil
+}
@@ -0,0 +1,68 @@
+package rates
+
+import (
+	"math"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stats are the statistics of the series of the rates. The volatility
+// is the standard deviation of the percentage changes between the
+// consecutive rates.
+type Stats struct {
+	Count      int             `json:"count"`
+	Min        decimal.Decimal `json:"min"`
+	Max        decimal.Decimal `json:"max"`
+	Mean       decimal.Decimal `json:"mean"`
+	Volatility decimal.Decimal `json:"volatility"`
+}
+
+// Summarize returns the statistics of the values, which are in the
+// order of the dates. The values must not be empty or contain zeros.
+func Summarize(values []decimal.Decimal) Stats {
+	stats := Stats{
+		Count: len(values),
+		Min:   values[0],
+		Max:   values[0],
+	}
+
+	sum := decimal.Zero
+
+	for _, value := range values {
+		stats.Min = decimal.Min(stats.Min, value)
+		stats.Max = decimal.Max(stats.Max, value)
+		sum = sum.Add(value)
+	}
+
+	stats.Mean = sum.Div(decimal.NewFromInt(int64(len(values))))
+
+	if len(values) < 3 {
+		return stats
+	}
+
+	changes := make([]decimal.Decimal, 0, len(values)-1)
+	changesSum := decimal.Zero
+
+	for i := 1; i < len(values); i++ {
+		change := values[i].Sub(values[i-1]).Div(values[i-1]).Mul(hundredPercent)
+
+		changes = append(changes, change)
+		changesSum = changesSum.Add(change)
+	}
+
+	changesMean := changesSum.Div(decimal.NewFromInt(int64(len(changes))))
+	squaresSum := decimal.Zero
+
+	for _, change := range changes {
+		deviation := change.Sub(changesMean)
+		squaresSum = squaresSum.Add(deviation.Mul(deviation))
+	}
+
+	// The sample variance, as the changes are of the period only.
+	variance := squaresSum.Div(decimal.NewFromInt(int64(len(changes) - 1)))
+
+	// The square root is taken of the float, as it is not exact anyway.
+	stats.Volatility = decimal.NewFromFloat(math.Sqrt(variance.InexactFloat64()))
+
+	return stats
+}
@@ -0,0 +1,141 @@
+package reconciler
+
+import (
+	"context"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PolicyPrimary = "primary"
+	PolicyAverage = "average"
+
+	valuePrecision = 4
+)
+
+var percents = decimal.NewFromInt(100)
+
+// A Set is the currencies, that were got from the source by the name,
+// e.g. the url of it.
+type Set struct {
+	Source     string
+	Currencies models.Currencies
+}
+
+// A Reconciler merges currencies, that were got from different sources,
+// into a single set of currencies.
+type Reconciler struct {
+	config *config.Config
+}
+
+func New(cfg *config.Config) *Reconciler {
+	return &Reconciler{config: cfg}
+}
+
+// Reconcile compares the currencies of the sets and logs the ones, that
+// differ more than the configured tolerance, by the names of the
+// sources. The first set is the base one: the result contains exactly
+// its currencies, with values chosen by the configured policy.
+func (r *Reconciler) Reconcile(ctx context.Context, sets []Set) models.Currencies {
+	if len(sets) == 0 {
+		return models.Currencies{}
+	}
+
+	base := sets[0]
+
+	result := base.Currencies
+	result.Currencies = make([]models.Currency, 0, len(base.Currencies.Currencies))
+
+	others := make([]map[string]models.Currency, 0, len(sets)-1)
+
+	for _, set := range sets[1:] {
+		others = append(others, currenciesByCharCode(set.Currencies))
+	}
+
+	for _, currency := range base.Currencies.Currencies {
+		result.Currencies = append(result.Currencies, r.reconcileCurrency(ctx, base.Source, currency, sets[1:], others))
+	}
+
+	return result
+}
+
+func (r *Reconciler) reconcileCurrency(
+	ctx context.Context,
+	baseSource string,
+	base models.Currency,
+	otherSets []Set,
+	others []map[string]models.Currency,
+) models.Currency {
+	baseUnitValue, ok := unitValue(base)
+	if !ok {
+		return base
+	}
+
+	sum := baseUnitValue
+	count := int64(1)
+
+	for i, other := range others {
+		currency, ok := other[base.CharCode]
+		if !ok {
+			continue
+		}
+
+		otherUnitValue, ok := unitValue(currency)
+		if !ok {
+			continue
+		}
+
+		discrepancy := otherUnitValue.Sub(baseUnitValue).Abs().Div(baseUnitValue).Mul(percents)
+
+		if discrepancy.GreaterThan(decimal.NewFromFloat(r.config.SourcesDiscrepancyTolerance)) {
+			zerolog.Ctx(ctx).Warn().
+				Str("charCode", base.CharCode).
+				Str("baseSource", baseSource).
+				Str("source", otherSets[i].Source).
+				Str("baseValue", baseUnitValue.String()).
+				Str("value", otherUnitValue.String()).
+				Str("discrepancyPercent", discrepancy.StringFixed(valuePrecision)).
+				Msg("currency values of sources differ")
+		}
+
+		sum = sum.Add(otherUnitValue)
+		count++
+	}
+
+	if (r.config.SourcesReconcilePolicy != PolicyAverage) || (count == 1) {
+		return base
+	}
+
+	base.Value = models.Value(sum.
+		Mul(decimal.NewFromInt(int64(base.Multiplier))).
+		DivRound(decimal.NewFromInt(count), valuePrecision).
+		StringFixed(valuePrecision))
+
+	base.UnitValue = ""
+	base.NormalizeUnitValue()
+
+	return base
+}
+
+func currenciesByCharCode(currencies models.Currencies) map[string]models.Currency {
+	result := make(map[string]models.Currency, len(currencies.Currencies))
+
+	for _, currency := range currencies.Currencies {
+		result[currency.CharCode] = currency
+	}
+
+	return result
+}
+
+// unitValue returns the value of a single unit of the currency.
+func unitValue(currency models.Currency) (decimal.Decimal, bool) {
+	value, err := decimal.NewFromString(string(currency.UnitValue))
+	if (err != nil) || !value.IsPositive() {
+		return decimal.Zero, false
+	}
+
+	return value, true
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/database"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+type AuditRepository struct {
+	config   *config.Config
+	database *database.Database
+}
+
+func NewAuditRepository(cfg *config.Config, db *database.Database) *AuditRepository {
+	return &AuditRepository{
+		config:   cfg,
+		database: db,
+	}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry models.AuditEntry) (models.AuditEntry, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO public.admin_audit (actor, action, payload, created_at)
+VALUES
+($1, $2, $3, $4)
+RETURNING id;
+	`
+
+	payload := sql.NullString{String: string(entry.Payload), Valid: entry.Payload != nil}
+
+	err := r.database.Executor(ctx).QueryRowContext(ctx, query, entry.Actor, entry.Action, payload, entry.CreatedAt).Scan(&entry.Id)
+	if err != nil {
+		return entry, errlib.Wrap(err, "could not insert audit entry")
+	}
+
+	return entry, nil
+}
+
+// GetByPeriod returns the entries, that are created on the dates of
+// UTC from the first date to the last one inclusive, in the order of
+// creation.
+func (r *AuditRepository) GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.AuditEntry, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT
+	id,
+	actor,
+	action,
+	payload,
+	TO_CHAR(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+FROM public.admin_audit
+WHERE (created_at AT TIME ZONE 'UTC')::DATE BETWEEN $1::DATE AND $2::DATE
+ORDER BY id;
+	`
+
+	rows, err := r.database.Executor(ctx).QueryContext(ctx, query, fromDate, toDate)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not perform select of audit entries")
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []models.AuditEntry
+
+	for rows.Next() {
+		var (
+			entry   models.AuditEntry
+			payload sql.NullString
+		)
+
+		if err = rows.Scan(&entry.Id, &entry.Actor, &entry.Action, &payload, &entry.CreatedAt); err != nil {
+			return nil, errlib.Wrap(err, "could not scan audit entry from a row")
+		}
+
+		if payload.Valid {
+			entry.Payload = []byte(payload.String)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
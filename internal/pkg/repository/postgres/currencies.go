@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
@@ -21,7 +22,10 @@ func NewCurrenciesRepository(cfg *config.Config, db *database.Database) *Currenc
 	}
 }
 
-func (r *CurrenciesRepository) Create(currencies models.Currencies, updateDatetimeId int) error {
+func (r *CurrenciesRepository) Create(ctx context.Context, currencies models.Currencies, updateDatetimeId int) error {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `INSERT INTO public.currency_values
 (currency_value, update_datetime_id, info_num_code)
 VALUES
@@ -37,9 +41,12 @@ VALUES
 		currenciesLength-1,
 	)
 
-	query += ";"
+	// The values of the same update are replaced, if they are
+	// inserted again.
+	query += `
+ON CONFLICT (update_datetime_id, info_num_code) DO UPDATE SET currency_value = EXCLUDED.currency_value;`
 
-	stmt, err := r.database.Prepare(query)
+	stmt, err := r.database.Executor(ctx).PrepareContext(ctx, query)
 	if err != nil {
 		return errlib.Wrap(err, "could not prepare statement for inserting currencies")
 	}
@@ -55,14 +62,17 @@ VALUES
 		)
 	}
 
-	if _, err = stmt.Exec(entries...); err != nil {
+	if _, err = stmt.ExecContext(ctx, entries...); err != nil {
 		return errlib.Wrap(err, "could not execute inserting of currencies")
 	}
 
 	return nil
 }
 
-func (r *CurrenciesRepository) GetLatest(updateDatetimeId int) (models.Currencies, error) {
+func (r *CurrenciesRepository) GetLatest(ctx context.Context, updateDatetimeId int) (models.Currencies, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT
 	public.info.num_code,
 	public.info.char_code,
@@ -86,12 +96,12 @@ ORDER BY public.info.name;
 		),
 	}
 
-	stmt, err := r.database.Prepare(query)
+	stmt, err := r.database.Executor(ctx).PrepareContext(ctx, query)
 	if err != nil {
 		return currencies, errlib.Wrap(err, "could not prepare statement for getting currencies")
 	}
 
-	rows, err := stmt.Query(updateDatetimeId)
+	rows, err := stmt.QueryContext(ctx, updateDatetimeId)
 	if err != nil {
 		return currencies, errlib.Wrap(err, "could not perform select of currencies")
 	}
@@ -111,6 +121,9 @@ ORDER BY public.info.name;
 			return currencies, errlib.Wrap(err, "could not scan currency entry from a row")
 		}
 
+		currency.UnitValue = ""
+		currency.NormalizeUnitValue()
+
 		currencies.Currencies = append(
 			currencies.Currencies,
 			currency,
@@ -126,3 +139,59 @@ func extendCurrenciesQuery(query *string, startPlaceholder int, startLine int, e
 		startPlaceholder += 3
 	}
 }
+
+func (r *CurrenciesRepository) GetHistory(ctx context.Context, fromDate string, toDate string) ([]models.HistoryCurrency, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT
+	public.update_datetimes.id,
+	TO_CHAR(COALESCE(public.update_datetimes.rate_date, public.update_datetimes.update_datetime::DATE), 'YYYY-MM-DD'),
+	public.info.num_code,
+	public.info.char_code,
+	public.multipliers.multiplier,
+	public.info.name,
+	public.currency_values.currency_value
+FROM public.currency_values
+JOIN public.update_datetimes
+	ON public.currency_values.update_datetime_id = public.update_datetimes.id
+JOIN public.info
+	ON public.currency_values.info_num_code = public.info.num_code
+JOIN public.multipliers
+	ON public.info.multiplier_id = public.multipliers.id
+WHERE COALESCE(public.update_datetimes.rate_date, public.update_datetimes.update_datetime::DATE) BETWEEN $1::DATE AND $2::DATE
+ORDER BY public.update_datetimes.id, public.info.name;
+	`
+
+	var history []models.HistoryCurrency
+
+	rows, err := r.database.Executor(ctx).QueryContext(ctx, query, fromDate, toDate)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not perform select of currency history")
+	}
+	defer func() { _ = rows.Close() }()
+
+	var historyCurrency models.HistoryCurrency
+
+	for rows.Next() {
+		err = rows.Scan(
+			&historyCurrency.UpdateDatetimeId,
+			&historyCurrency.RateDate,
+			&historyCurrency.NumCode,
+			&historyCurrency.CharCode,
+			&historyCurrency.Multiplier,
+			&historyCurrency.Name,
+			&historyCurrency.Value,
+		)
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not scan history currency entry from a row")
+		}
+
+		historyCurrency.UnitValue = ""
+		historyCurrency.NormalizeUnitValue()
+
+		history = append(history, historyCurrency)
+	}
+
+	return history, nil
+}
@@ -1,6 +1,8 @@
 package postgres
 
 import (
+	"context"
+
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/database"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
@@ -19,51 +21,64 @@ func NewUpdateDatetimeRepository(cfg *config.Config, db *database.Database) *Upd
 	}
 }
 
-func (r *UpdateDatetimeRepository) Create(datetime string) (models.UpdateDatetime, error) {
-	query := `INSERT INTO public.update_datetimes (update_datetime)
+func (r *UpdateDatetimeRepository) Create(ctx context.Context, datetime string, rateDate string) (models.UpdateDatetime, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	// The update of an already stored rate date is reused.
+	query := `INSERT INTO public.update_datetimes (update_datetime, rate_date)
 VALUES
-($1)
+($1, NULLIF($2, '')::DATE)
+ON CONFLICT (rate_date) DO UPDATE SET update_datetime = EXCLUDED.update_datetime
 RETURNING id;
 	`
 
 	updateDatetime := models.UpdateDatetime{
 		UpdateDatetime: datetime,
+		RateDate:       rateDate,
 	}
 
-	stmt, err := r.database.Prepare(query)
+	stmt, err := r.database.Executor(ctx).PrepareContext(ctx, query)
 	if err != nil {
 		return updateDatetime, errlib.Wrap(err, "could not prepare statement for inserting datetime")
 	}
 
-	row := stmt.QueryRow(datetime)
-	if err != nil {
+	if err = stmt.QueryRowContext(ctx, datetime, rateDate).Scan(&updateDatetime.Id); err != nil {
 		return updateDatetime, errlib.Wrap(err, "could not execute inserting state of datetime")
 	}
 
-	row.Scan(&updateDatetime.Id)
-
 	return updateDatetime, nil
 }
 
-func (r *UpdateDatetimeRepository) GetLatest() (models.UpdateDatetime, error) {
-	query := `SELECT id, update_datetime
+// GetLatest returns the update of the most recent rate date, so the
+// updates, that are imported afterwards for past dates, are skipped.
+func (r *UpdateDatetimeRepository) GetLatest(ctx context.Context) (models.UpdateDatetime, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT
+	id,
+	update_datetime,
+	COALESCE(TO_CHAR(rate_date, 'YYYY-MM-DD'), '')
 FROM public.update_datetimes
-WHERE id = (
-	SELECT MAX(ID)
-	FROM public.update_datetimes
-);
+ORDER BY COALESCE(rate_date, update_datetime::DATE) DESC, id DESC
+LIMIT 1;
 	`
 
 	var updateDatetime models.UpdateDatetime
 
-	rows, err := r.database.Query(query)
+	rows, err := r.database.Executor(ctx).QueryContext(ctx, query)
 	if err != nil {
 		return updateDatetime, errlib.Wrap(err, "could not perform select of update datetimes")
 	}
 	defer func() { _ = rows.Close() }()
 
 	for rows.Next() {
-		err = rows.Scan(&updateDatetime.Id, &updateDatetime.UpdateDatetime)
+		err = rows.Scan(
+			&updateDatetime.Id,
+			&updateDatetime.UpdateDatetime,
+			&updateDatetime.RateDate,
+		)
 		if err != nil {
 			return updateDatetime, errlib.Wrap(err, "could not scan from a row")
 		}
@@ -71,3 +86,81 @@ WHERE id = (
 
 	return updateDatetime, nil
 }
+
+func (r *UpdateDatetimeRepository) GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.UpdateDatetime, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT
+	public.update_datetimes.id,
+	public.update_datetimes.update_datetime,
+	COALESCE(TO_CHAR(public.update_datetimes.rate_date, 'YYYY-MM-DD'), '')
+FROM public.update_datetimes
+WHERE COALESCE(public.update_datetimes.rate_date, public.update_datetimes.update_datetime::DATE) BETWEEN $1::DATE AND $2::DATE
+ORDER BY public.update_datetimes.id;
+	`
+
+	var updateDatetimes []models.UpdateDatetime
+
+	rows, err := r.database.Executor(ctx).QueryContext(ctx, query, fromDate, toDate)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not perform select of update datetimes")
+	}
+	defer func() { _ = rows.Close() }()
+
+	var updateDatetime models.UpdateDatetime
+
+	for rows.Next() {
+		err = rows.Scan(
+			&updateDatetime.Id,
+			&updateDatetime.UpdateDatetime,
+			&updateDatetime.RateDate,
+		)
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not scan from a row")
+		}
+
+		updateDatetimes = append(updateDatetimes, updateDatetime)
+	}
+
+	return updateDatetimes, nil
+}
+
+// DeleteBefore deletes the updates of the rates, that are older than
+// the date, along with their currency values. The update with the
+// keepId is never deleted. It returns the number of deleted updates.
+func (r *UpdateDatetimeRepository) DeleteBefore(ctx context.Context, date string, keepId int) (int64, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	condition := `COALESCE(public.update_datetimes.rate_date, public.update_datetimes.update_datetime::DATE) < $1::DATE
+	AND public.update_datetimes.id <> $2`
+
+	query := `DELETE FROM public.currency_values
+WHERE public.currency_values.update_datetime_id IN (
+	SELECT public.update_datetimes.id
+	FROM public.update_datetimes
+	WHERE ` + condition + `
+);
+	`
+
+	if _, err := r.database.Executor(ctx).ExecContext(ctx, query, date, keepId); err != nil {
+		return 0, errlib.Wrap(err, "could not delete outdated currency values")
+	}
+
+	query = `DELETE FROM public.update_datetimes
+WHERE ` + condition + `;
+	`
+
+	result, err := r.database.Executor(ctx).ExecContext(ctx, query, date, keepId)
+	if err != nil {
+		return 0, errlib.Wrap(err, "could not delete outdated update datetimes")
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, errlib.Wrap(err, "could not get number of deleted update datetimes")
+	}
+
+	return deleted, nil
+}
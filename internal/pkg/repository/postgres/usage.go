@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/database"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+type UsageRepository struct {
+	config   *config.Config
+	database *database.Database
+}
+
+func NewUsageRepository(cfg *config.Config, db *database.Database) *UsageRepository {
+	return &UsageRepository{
+		config:   cfg,
+		database: db,
+	}
+}
+
+// Increment counts the request, that is made with the API key on the
+// date.
+func (r *UsageRepository) Increment(ctx context.Context, keyName string, date string) error {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO public.api_usage (key_name, usage_date, requests)
+VALUES
+($1, $2::DATE, 1)
+ON CONFLICT (key_name, usage_date) DO UPDATE SET requests = public.api_usage.requests + 1;
+	`
+
+	if _, err := r.database.Executor(ctx).ExecContext(ctx, query, keyName, date); err != nil {
+		return errlib.Wrap(err, "could not increment api usage")
+	}
+
+	return nil
+}
+
+// Count returns the number of the requests, that are made with the API
+// key on the date and over the period from the first date to it.
+func (r *UsageRepository) Count(ctx context.Context, keyName string, fromDate string, date string) (models.UsageCount, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT
+	COALESCE(SUM(CASE WHEN usage_date = $3::DATE THEN requests ELSE 0 END), 0),
+	COALESCE(SUM(requests), 0)
+FROM public.api_usage
+WHERE key_name = $1
+	AND usage_date BETWEEN $2::DATE AND $3::DATE;
+	`
+
+	var count models.UsageCount
+
+	err := r.database.Executor(ctx).QueryRowContext(ctx, query, keyName, fromDate, date).Scan(&count.Day, &count.Month)
+	if err != nil {
+		return count, errlib.Wrap(err, "could not perform select of api usage count")
+	}
+
+	return count, nil
+}
+
+func (r *UsageRepository) GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.Usage, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT
+	key_name,
+	TO_CHAR(usage_date, 'YYYY-MM-DD'),
+	requests
+FROM public.api_usage
+WHERE usage_date BETWEEN $1::DATE AND $2::DATE
+ORDER BY key_name, usage_date;
+	`
+
+	var usages []models.Usage
+
+	rows, err := r.database.Executor(ctx).QueryContext(ctx, query, fromDate, toDate)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not perform select of api usage")
+	}
+	defer func() { _ = rows.Close() }()
+
+	var usage models.Usage
+
+	for rows.Next() {
+		if err = rows.Scan(&usage.KeyName, &usage.Date, &usage.Requests); err != nil {
+			return nil, errlib.Wrap(err, "could not scan api usage from a row")
+		}
+
+		usages = append(usages, usage)
+	}
+
+	return usages, nil
+}
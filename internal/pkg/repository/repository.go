@@ -1,30 +1,70 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/database"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/repository/mysql"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/repository/postgres"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/repository/sqlite"
 )
 
 type UpdateDatetime interface {
-	Create(datetime string) (models.UpdateDatetime, error)
-	GetLatest() (models.UpdateDatetime, error)
+	Create(ctx context.Context, datetime string, rateDate string) (models.UpdateDatetime, error)
+	GetLatest(ctx context.Context) (models.UpdateDatetime, error)
+	GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.UpdateDatetime, error)
+	DeleteBefore(ctx context.Context, date string, keepId int) (int64, error)
 }
 
 type Currencies interface {
-	Create(currencies models.Currencies, updateDatetimeId int) error
-	GetLatest(updateDatetimeId int) (models.Currencies, error)
+	Create(ctx context.Context, currencies models.Currencies, updateDatetimeId int) error
+	GetLatest(ctx context.Context, updateDatetimeId int) (models.Currencies, error)
+	GetHistory(ctx context.Context, fromDate string, toDate string) ([]models.HistoryCurrency, error)
+}
+
+type Usage interface {
+	Increment(ctx context.Context, keyName string, date string) error
+	Count(ctx context.Context, keyName string, fromDate string, date string) (models.UsageCount, error)
+	GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.Usage, error)
+}
+
+type Audit interface {
+	Create(ctx context.Context, entry models.AuditEntry) (models.AuditEntry, error)
+	GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.AuditEntry, error)
 }
 
 type Repository struct {
 	UpdateDatetime UpdateDatetime
 	Currencies     Currencies
+	Usage          Usage
+	Audit          Audit
 }
 
+// New creates the repository of the configured database driver.
 func New(cfg *config.Config, db *database.Database) *Repository {
+	switch cfg.DbDriver {
+	case config.DbDriverSqlite:
+		return &Repository{
+			UpdateDatetime: sqlite.NewUpdateDatetimeRepository(cfg, db),
+			Currencies:     sqlite.NewCurrenciesRepository(cfg, db),
+			Usage:          sqlite.NewUsageRepository(cfg, db),
+			Audit:          sqlite.NewAuditRepository(cfg, db),
+		}
+	case config.DbDriverMysql:
+		return &Repository{
+			UpdateDatetime: mysql.NewUpdateDatetimeRepository(cfg, db),
+			Currencies:     mysql.NewCurrenciesRepository(cfg, db),
+			Usage:          mysql.NewUsageRepository(cfg, db),
+			Audit:          mysql.NewAuditRepository(cfg, db),
+		}
+	}
+
 	return &Repository{
 		UpdateDatetime: postgres.NewUpdateDatetimeRepository(cfg, db),
 		Currencies:     postgres.NewCurrenciesRepository(cfg, db),
+		Usage:          postgres.NewUsageRepository(cfg, db),
+		Audit:          postgres.NewAuditRepository(cfg, db),
 	}
 }
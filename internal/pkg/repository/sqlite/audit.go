@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/database"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+type AuditRepository struct {
+	config   *config.Config
+	database *database.Database
+}
+
+func NewAuditRepository(cfg *config.Config, db *database.Database) *AuditRepository {
+	return &AuditRepository{
+		config:   cfg,
+		database: db,
+	}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry models.AuditEntry) (models.AuditEntry, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO admin_audit (actor, action, payload, created_at)
+VALUES
+(?, ?, ?, ?)
+RETURNING id;
+	`
+
+	payload := sql.NullString{String: string(entry.Payload), Valid: entry.Payload != nil}
+
+	err := r.database.Executor(ctx).QueryRowContext(ctx, query, entry.Actor, entry.Action, payload, entry.CreatedAt).Scan(&entry.Id)
+	if err != nil {
+		return entry, errlib.Wrap(err, "could not insert audit entry")
+	}
+
+	return entry, nil
+}
+
+// GetByPeriod returns the entries, that are created on the dates of
+// UTC from the first date to the last one inclusive, in the order of
+// creation. The datetimes are stored in RFC 3339 in UTC, so the dates
+// are the prefixes of them.
+func (r *AuditRepository) GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.AuditEntry, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT
+	id,
+	actor,
+	action,
+	payload,
+	created_at
+FROM admin_audit
+WHERE SUBSTR(created_at, 1, 10) BETWEEN ? AND ?
+ORDER BY id;
+	`
+
+	rows, err := r.database.Executor(ctx).QueryContext(ctx, query, fromDate, toDate)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not perform select of audit entries")
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []models.AuditEntry
+
+	for rows.Next() {
+		var (
+			entry   models.AuditEntry
+			payload sql.NullString
+		)
+
+		if err = rows.Scan(&entry.Id, &entry.Actor, &entry.Action, &payload, &entry.CreatedAt); err != nil {
+			return nil, errlib.Wrap(err, "could not scan audit entry from a row")
+		}
+
+		if payload.Valid {
+			entry.Payload = []byte(payload.String)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,186 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/database"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+type CurrenciesRepository struct {
+	config   *config.Config
+	database *database.Database
+}
+
+func NewCurrenciesRepository(cfg *config.Config, db *database.Database) *CurrenciesRepository {
+	return &CurrenciesRepository{
+		config:   cfg,
+		database: db,
+	}
+}
+
+func (r *CurrenciesRepository) Create(ctx context.Context, currencies models.Currencies, updateDatetimeId int) error {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO currency_values
+(currency_value, update_datetime_id, info_num_code)
+VALUES
+(?,?,?)`
+
+	currenciesLength := len(currencies.Currencies)
+
+	if currenciesLength > 1 {
+		query += strings.Repeat(",(?,?,?)", currenciesLength-1)
+	}
+
+	// The values of the same update are replaced, if they are
+	// inserted again.
+	query += `
+ON CONFLICT (update_datetime_id, info_num_code) DO UPDATE SET currency_value = excluded.currency_value;`
+
+	stmt, err := r.database.Executor(ctx).PrepareContext(ctx, query)
+	if err != nil {
+		return errlib.Wrap(err, "could not prepare statement for inserting currencies")
+	}
+
+	entries := make([]any, 0, currenciesLength*3)
+
+	for _, currency := range currencies.Currencies {
+		entries = append(
+			entries,
+			string(currency.Value),
+			updateDatetimeId,
+			currency.NumCode,
+		)
+	}
+
+	if _, err = stmt.ExecContext(ctx, entries...); err != nil {
+		return errlib.Wrap(err, "could not execute inserting of currencies")
+	}
+
+	return nil
+}
+
+func (r *CurrenciesRepository) GetLatest(ctx context.Context, updateDatetimeId int) (models.Currencies, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT
+	info.num_code,
+	info.char_code,
+	multipliers.multiplier,
+	info.name,
+	currency_values.currency_value
+FROM multipliers
+JOIN info
+	ON multipliers.id = info.multiplier_id
+JOIN currency_values
+	ON info.num_code = currency_values.info_num_code
+WHERE currency_values.update_datetime_id = ?
+ORDER BY info.name;
+	`
+
+	currencies := models.Currencies{
+		Currencies: make(
+			[]models.Currency,
+			0,
+			r.config.InitialCurrenciesCapacity,
+		),
+	}
+
+	stmt, err := r.database.Executor(ctx).PrepareContext(ctx, query)
+	if err != nil {
+		return currencies, errlib.Wrap(err, "could not prepare statement for getting currencies")
+	}
+
+	rows, err := stmt.QueryContext(ctx, updateDatetimeId)
+	if err != nil {
+		return currencies, errlib.Wrap(err, "could not perform select of currencies")
+	}
+	defer func() { _ = rows.Close() }()
+
+	var currency models.Currency
+
+	for rows.Next() {
+		err = rows.Scan(
+			&currency.NumCode,
+			&currency.CharCode,
+			&currency.Multiplier,
+			&currency.Name,
+			&currency.Value,
+		)
+		if err != nil {
+			return currencies, errlib.Wrap(err, "could not scan currency entry from a row")
+		}
+
+		currency.UnitValue = ""
+		currency.NormalizeUnitValue()
+
+		currencies.Currencies = append(
+			currencies.Currencies,
+			currency,
+		)
+	}
+
+	return currencies, nil
+}
+
+func (r *CurrenciesRepository) GetHistory(ctx context.Context, fromDate string, toDate string) ([]models.HistoryCurrency, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT
+	update_datetimes.id,
+	COALESCE(update_datetimes.rate_date, SUBSTR(update_datetimes.update_datetime, 1, 10)),
+	info.num_code,
+	info.char_code,
+	multipliers.multiplier,
+	info.name,
+	currency_values.currency_value
+FROM currency_values
+JOIN update_datetimes
+	ON currency_values.update_datetime_id = update_datetimes.id
+JOIN info
+	ON currency_values.info_num_code = info.num_code
+JOIN multipliers
+	ON info.multiplier_id = multipliers.id
+WHERE COALESCE(update_datetimes.rate_date, SUBSTR(update_datetimes.update_datetime, 1, 10)) BETWEEN ? AND ?
+ORDER BY update_datetimes.id, info.name;
+	`
+
+	var history []models.HistoryCurrency
+
+	rows, err := r.database.Executor(ctx).QueryContext(ctx, query, fromDate, toDate)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not perform select of currency history")
+	}
+	defer func() { _ = rows.Close() }()
+
+	var historyCurrency models.HistoryCurrency
+
+	for rows.Next() {
+		err = rows.Scan(
+			&historyCurrency.UpdateDatetimeId,
+			&historyCurrency.RateDate,
+			&historyCurrency.NumCode,
+			&historyCurrency.CharCode,
+			&historyCurrency.Multiplier,
+			&historyCurrency.Name,
+			&historyCurrency.Value,
+		)
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not scan history currency entry from a row")
+		}
+
+		historyCurrency.UnitValue = ""
+		historyCurrency.NormalizeUnitValue()
+
+		history = append(history, historyCurrency)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,166 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/database"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+type UpdateDatetimeRepository struct {
+	config   *config.Config
+	database *database.Database
+}
+
+func NewUpdateDatetimeRepository(cfg *config.Config, db *database.Database) *UpdateDatetimeRepository {
+	return &UpdateDatetimeRepository{
+		config:   cfg,
+		database: db,
+	}
+}
+
+func (r *UpdateDatetimeRepository) Create(ctx context.Context, datetime string, rateDate string) (models.UpdateDatetime, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	// The update of an already stored rate date is reused.
+	query := `INSERT INTO update_datetimes (update_datetime, rate_date)
+VALUES
+(?, NULLIF(?, ''))
+ON CONFLICT (rate_date) DO UPDATE SET update_datetime = excluded.update_datetime
+RETURNING id;
+	`
+
+	updateDatetime := models.UpdateDatetime{
+		UpdateDatetime: datetime,
+		RateDate:       rateDate,
+	}
+
+	stmt, err := r.database.Executor(ctx).PrepareContext(ctx, query)
+	if err != nil {
+		return updateDatetime, errlib.Wrap(err, "could not prepare statement for inserting datetime")
+	}
+
+	if err = stmt.QueryRowContext(ctx, datetime, rateDate).Scan(&updateDatetime.Id); err != nil {
+		return updateDatetime, errlib.Wrap(err, "could not execute inserting state of datetime")
+	}
+
+	return updateDatetime, nil
+}
+
+// GetLatest returns the update of the most recent rate date, so the
+// updates, that are imported afterwards for past dates, are skipped.
+func (r *UpdateDatetimeRepository) GetLatest(ctx context.Context) (models.UpdateDatetime, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT
+	id,
+	update_datetime,
+	COALESCE(rate_date, '')
+FROM update_datetimes
+ORDER BY COALESCE(rate_date, SUBSTR(update_datetime, 1, 10)) DESC, id DESC
+LIMIT 1;
+	`
+
+	var updateDatetime models.UpdateDatetime
+
+	rows, err := r.database.Executor(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return updateDatetime, errlib.Wrap(err, "could not perform select of update datetimes")
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		err = rows.Scan(
+			&updateDatetime.Id,
+			&updateDatetime.UpdateDatetime,
+			&updateDatetime.RateDate,
+		)
+		if err != nil {
+			return updateDatetime, errlib.Wrap(err, "could not scan from a row")
+		}
+	}
+
+	return updateDatetime, nil
+}
+
+func (r *UpdateDatetimeRepository) GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.UpdateDatetime, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT
+	update_datetimes.id,
+	update_datetimes.update_datetime,
+	COALESCE(update_datetimes.rate_date, '')
+FROM update_datetimes
+WHERE COALESCE(update_datetimes.rate_date, SUBSTR(update_datetimes.update_datetime, 1, 10)) BETWEEN ? AND ?
+ORDER BY update_datetimes.id;
+	`
+
+	var updateDatetimes []models.UpdateDatetime
+
+	rows, err := r.database.Executor(ctx).QueryContext(ctx, query, fromDate, toDate)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not perform select of update datetimes")
+	}
+	defer func() { _ = rows.Close() }()
+
+	var updateDatetime models.UpdateDatetime
+
+	for rows.Next() {
+		err = rows.Scan(
+			&updateDatetime.Id,
+			&updateDatetime.UpdateDatetime,
+			&updateDatetime.RateDate,
+		)
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not scan from a row")
+		}
+
+		updateDatetimes = append(updateDatetimes, updateDatetime)
+	}
+
+	return updateDatetimes, nil
+}
+
+// DeleteBefore deletes the updates of the rates, that are older than
+// the date, along with their currency values. The update with the
+// keepId is never deleted. It returns the number of deleted updates.
+func (r *UpdateDatetimeRepository) DeleteBefore(ctx context.Context, date string, keepId int) (int64, error) {
+	ctx, cancel := r.database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	condition := `COALESCE(update_datetimes.rate_date, SUBSTR(update_datetimes.update_datetime, 1, 10)) < ?
+	AND update_datetimes.id <> ?`
+
+	query := `DELETE FROM currency_values
+WHERE currency_values.update_datetime_id IN (
+	SELECT update_datetimes.id
+	FROM update_datetimes
+	WHERE ` + condition + `
+);
+	`
+
+	if _, err := r.database.Executor(ctx).ExecContext(ctx, query, date, keepId); err != nil {
+		return 0, errlib.Wrap(err, "could not delete outdated currency values")
+	}
+
+	query = `DELETE FROM update_datetimes
+WHERE ` + condition + `;
+	`
+
+	result, err := r.database.Executor(ctx).ExecContext(ctx, query, date, keepId)
+	if err != nil {
+		return 0, errlib.Wrap(err, "could not delete outdated update datetimes")
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, errlib.Wrap(err, "could not get number of deleted update datetimes")
+	}
+
+	return deleted, nil
+}
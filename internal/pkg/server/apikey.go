@@ -0,0 +1,186 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	apimodels "github.com/mrumyantsev/currency-converter-app/internal/pkg/api-models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/audit"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/quota"
+	"github.com/rs/zerolog"
+)
+
+// HeaderApiKey is the header of the request, that has the API key.
+const HeaderApiKey = "X-Api-Key"
+
+// The headers of the response, that tell the client the quota of the
+// window with the fewest requests left, the number of them and the
+// seconds until the window is reset, so the client can slow down.
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+)
+
+// scopeContextKey is the key of the echo context, that has the scope of
+// the API key of the request.
+const scopeContextKey = "apiKeyScope"
+
+// ApiKeys lets through the requests with any of the API keys by the
+// names, as long as the quotas of the key are not exceeded. The request
+// is counted by the quota, that may be nil to count nothing. The usage,
+// that can not be counted, is logged, and the request is let through,
+// so the storage failures do not take down the API. It must follow the
+// request logger, as it adds the name of the key to the logged fields.
+// The name of the key is the actor of the request in the audit log.
+func ApiKeys(keys map[string]string, q *quota.Quota) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			keyName, ok := matchKey(keys, ctx.Request().Header.Get(HeaderApiKey))
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid or missing api key")
+			}
+
+			request := ctx.Request()
+			ctx.SetRequest(request.WithContext(audit.WithActor(request.Context(), keyName)))
+
+			logger := zerolog.Ctx(request.Context())
+
+			logger.UpdateContext(func(c zerolog.Context) zerolog.Context {
+				return c.Str("apiKey", keyName)
+			})
+
+			if q == nil {
+				return next(ctx)
+			}
+
+			status, err := q.Use(ctx.Request().Context(), keyName)
+			if err != nil {
+				logger.Error().Err(err).Msg("could not count api key usage")
+
+				return next(ctx)
+			}
+
+			limit, ok := status.Limit()
+			if !ok {
+				return next(ctx)
+			}
+
+			resetAfter := secondsUntil(limit.ResetAt, status.At)
+
+			header := ctx.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.FormatInt(limit.Quota, 10))
+			header.Set(HeaderRateLimitRemaining, strconv.FormatInt(limit.Remaining, 10))
+			header.Set(HeaderRateLimitReset, strconv.FormatInt(resetAfter, 10))
+
+			var message string
+
+			switch {
+			case status.IsDailyExceeded():
+				message = "daily quota of " + strconv.FormatInt(status.DailyQuota, 10) + " requests is exceeded"
+			case status.IsMonthlyExceeded():
+				message = "monthly quota of " + strconv.FormatInt(status.MonthlyQuota, 10) + " requests is exceeded"
+			default:
+				return next(ctx)
+			}
+
+			header.Set(echo.HeaderRetryAfter, strconv.FormatInt(resetAfter, 10))
+
+			return ctx.JSON(http.StatusTooManyRequests, apimodels.NewQuotaExceeded(message, limit, resetAfter))
+		}
+	}
+}
+
+// ActorAdmin is the actor of the requests with the admin key in the
+// audit log.
+const ActorAdmin = "admin"
+
+// AdminKey lets through the requests with the admin key, which may be
+// empty to have none, or with any of the API keys by the names. The
+// scope of the key is set for RequireScope, that must follow it: the
+// admin key has the admin scope, and the API keys have the scopes by
+// the names or the read one, if the scope of the key is not set. The
+// requests with the API keys are not counted by the quotas. The
+// requests, that are authenticated already, e.g. by Oidc, are let
+// through as they are.
+func AdminKey(key string, keys map[string]string, scopes map[string]string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if ctx.Get(scopeContextKey) != nil {
+				return next(ctx)
+			}
+
+			given := ctx.Request().Header.Get(HeaderApiKey)
+
+			actor, scope := ActorAdmin, config.ScopeAdmin
+
+			if (key == "") || (given == "") || (subtle.ConstantTimeCompare([]byte(key), []byte(given)) != 1) {
+				keyName, ok := matchKey(keys, given)
+				if !ok {
+					return echo.NewHTTPError(http.StatusUnauthorized, "invalid or missing admin key")
+				}
+
+				actor, scope = keyName, scopes[keyName]
+
+				if scope == "" {
+					scope = config.ScopeRead
+				}
+			}
+
+			request := ctx.Request()
+			ctx.SetRequest(request.WithContext(audit.WithActor(request.Context(), actor)))
+			ctx.Set(scopeContextKey, scope)
+
+			return next(ctx)
+		}
+	}
+}
+
+// RequireScope lets through the requests with the API key, that has the
+// scope. The admin scope includes the read one. It must follow the
+// middleware, that sets the scope of the key, e.g. AdminKey.
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			given, _ := ctx.Get(scopeContextKey).(string)
+
+			if (given != scope) && (given != config.ScopeAdmin) {
+				return echo.NewHTTPError(http.StatusForbidden, scope+" scope is required")
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+// secondsUntil returns the whole seconds from the time to the later one,
+// that are rounded up, so the client does not retry too early.
+func secondsUntil(later time.Time, t time.Time) int64 {
+	return int64((later.Sub(t) + time.Second - 1) / time.Second)
+}
+
+// matchKey returns the name of the key, that is the given one. The keys
+// are compared in constant time, so the time of the response does not
+// tell, how much of the key is guessed.
+func matchKey(keys map[string]string, given string) (string, bool) {
+	if given == "" {
+		return "", false
+	}
+
+	var (
+		matched string
+		isFound bool
+	)
+
+	for name, key := range keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(given)) == 1 {
+			matched, isFound = name, true
+		}
+	}
+
+	return matched, isFound
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+)
+
+const (
+	readKey      = "read-key-0123456789"
+	scopedKey    = "scoped-key-0123456789"
+	testAdminKey = "admin-key-0123456789"
+)
+
+// newScopedEcho returns the server with the routes of the alert rules in
+// the API and in the admin endpoints, that are guarded as by the app.
+func newScopedEcho() *echo.Echo {
+	keys := map[string]string{
+		"site": readKey,
+		"ops":  scopedKey,
+	}
+
+	scopes := map[string]string{
+		"ops": config.ScopeAdmin,
+	}
+
+	ok := func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	}
+
+	e := echo.New()
+
+	api := e.Group("", ApiKeys(keys, nil))
+	api.GET("/alerts/rules", ok)
+
+	admin := e.Group("/admin", AdminKey(testAdminKey, keys, scopes), RequireScope(config.ScopeAdmin))
+	admin.GET("/alerts/rules", ok)
+	admin.POST("/alerts/rules", ok)
+	admin.DELETE("/alerts/rules/:id", ok)
+
+	return e
+}
+
+func TestScopes(t *testing.T) {
+	e := newScopedEcho()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{"read key reads api", http.MethodGet, "/alerts/rules", readKey, http.StatusOK},
+		{"no key reads api", http.MethodGet, "/alerts/rules", "", http.StatusUnauthorized},
+		{"read key reads admin", http.MethodGet, "/admin/alerts/rules", readKey, http.StatusForbidden},
+		{"read key adds rule", http.MethodPost, "/admin/alerts/rules", readKey, http.StatusForbidden},
+		{"read key deletes rule", http.MethodDelete, "/admin/alerts/rules/1", readKey, http.StatusForbidden},
+		{"no key adds rule", http.MethodPost, "/admin/alerts/rules", "", http.StatusUnauthorized},
+		{"admin scoped key adds rule", http.MethodPost, "/admin/alerts/rules", scopedKey, http.StatusOK},
+		{"admin key adds rule", http.MethodPost, "/admin/alerts/rules", testAdminKey, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, tt.path, nil)
+
+			if tt.key != "" {
+				request.Header.Set(HeaderApiKey, tt.key)
+			}
+
+			recorder := httptest.NewRecorder()
+
+			e.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.want {
+				t.Errorf("got status %d, want %d", recorder.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/audit"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/idempotency"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// The headers of the request, that has the idempotency key, and of the
+// response, that tells, whether it is replayed.
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// Idempotency replays the outcomes of the write requests, that are
+// retried with the same idempotency key, instead of doing them again.
+// The keys are of the actors, so it must follow the authentication. The
+// key of the other request is refused, as well as the one of the
+// request in progress. The failures of the server are not kept, so the
+// requests may be retried after them. The body of the request is read
+// up to the max size to be fingerprinted, and the larger one is refused.
+func Idempotency(s *idempotency.Store, maxBodySize int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			request := ctx.Request()
+
+			key := request.Header.Get(HeaderIdempotencyKey)
+
+			if (key == "") || isSafeMethod(request.Method) {
+				return next(ctx)
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				return echo.NewHTTPError(http.StatusBadRequest, "idempotency key is too long")
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(ctx.Response(), request.Body, maxBodySize))
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "request body is too large")
+				}
+
+				return errlib.Wrap(err, "could not read request body")
+			}
+
+			request.Body = io.NopCloser(bytes.NewReader(body))
+
+			key = audit.Actor(request.Context()) + "\n" + key
+
+			response, err := s.Begin(key, fingerprint(request, body))
+			if errors.Is(err, idempotency.ErrInProgress) {
+				return echo.NewHTTPError(http.StatusConflict, "request with idempotency key is in progress")
+			}
+
+			if errors.Is(err, idempotency.ErrMismatch) {
+				return echo.NewHTTPError(http.StatusUnprocessableEntity, "idempotency key is used by another request")
+			}
+
+			if response != nil {
+				header := ctx.Response().Header()
+
+				header.Set(HeaderIdempotentReplayed, "true")
+
+				if response.ContentType != "" {
+					header.Set(echo.HeaderContentType, response.ContentType)
+				}
+
+				ctx.Response().WriteHeader(response.Status)
+
+				if _, err = ctx.Response().Write(response.Body); err != nil {
+					return errlib.Wrap(err, "could not write replayed response")
+				}
+
+				return nil
+			}
+
+			// The request is aborted, unless it is completed, e.g. on
+			// panic.
+			isCompleted := false
+
+			defer func() {
+				if !isCompleted {
+					s.Abort(key)
+				}
+			}()
+
+			writer := &recordingWriter{ResponseWriter: ctx.Response().Writer}
+
+			ctx.Response().Writer = writer
+
+			// The error is handled here, so the outcome of it is kept
+			// too.
+			if err = next(ctx); err != nil {
+				ctx.Error(err)
+			}
+
+			ctx.Response().Writer = writer.ResponseWriter
+
+			status := ctx.Response().Status
+
+			if status >= http.StatusInternalServerError {
+				return nil
+			}
+
+			s.Complete(key, idempotency.Response{
+				Status:      status,
+				ContentType: ctx.Response().Header().Get(echo.HeaderContentType),
+				Body:        writer.body.Bytes(),
+			})
+
+			isCompleted = true
+
+			return nil
+		}
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return (method == http.MethodGet) || (method == http.MethodHead) || (method == http.MethodOptions)
+}
+
+// fingerprint returns the hash of the method, the URI and the body of
+// the request.
+func fingerprint(request *http.Request, body []byte) string {
+	h := sha256.New()
+
+	_, _ = io.WriteString(h, request.Method+" "+request.URL.RequestURI()+"\n")
+	_, _ = h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// A recordingWriter writes the body of the response to the response
+// writer and keeps it.
+type recordingWriter struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/idempotency"
+)
+
+const testMaxBodySize = 16
+
+func TestIdempotencyBodySize(t *testing.T) {
+	store := idempotency.New(&config.Config{IdempotencyKeyTtl: time.Hour}, clock.System{})
+
+	e := echo.New()
+	e.Use(Idempotency(store, testMaxBodySize))
+
+	e.POST("/alerts/rules", func(ctx echo.Context) error {
+		body, err := io.ReadAll(ctx.Request().Body)
+		if err != nil {
+			return err
+		}
+
+		return ctx.Blob(http.StatusCreated, echo.MIMETextPlain, body)
+	})
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"body within limit", strings.Repeat("a", testMaxBodySize), http.StatusCreated},
+		{"body above limit", strings.Repeat("a", testMaxBodySize+1), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/alerts/rules", strings.NewReader(tt.body))
+			request.Header.Set(HeaderIdempotencyKey, tt.name)
+
+			recorder := httptest.NewRecorder()
+
+			e.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.want {
+				t.Errorf("got status %d, want %d", recorder.Code, tt.want)
+			}
+
+			if (tt.want == http.StatusCreated) && (recorder.Body.String() != tt.body) {
+				t.Errorf("got body %q, want %q", recorder.Body.String(), tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+
+	"github.com/labstack/echo/v4"
+	ipfilter "github.com/mrumyantsev/currency-converter-app/internal/pkg/ip-filter"
+)
+
+// IpFilter lets through the requests of the clients, that are allowed
+// by the filter. The address of the client is the one of the connection,
+// as the forwarded headers are set by the clients themselves without a
+// reverse proxy.
+func IpFilter(f *ipfilter.Filter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			host, _, err := net.SplitHostPort(ctx.Request().RemoteAddr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusForbidden, "client address is unknown")
+			}
+
+			addr, err := netip.ParseAddr(host)
+			if (err != nil) || !f.IsAllowed(addr) {
+				return echo.NewHTTPError(http.StatusForbidden, "client address is not allowed")
+			}
+
+			return next(ctx)
+		}
+	}
+}
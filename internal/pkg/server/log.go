@@ -0,0 +1,45 @@
+package server
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// RequestLogger puts the logger with the id of the request in the
+// context of the request, so the handlers log their messages with it,
+// and logs every handled request. The id is set by the request id
+// middleware, that must precede this one.
+func RequestLogger(logger zerolog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			start := time.Now()
+
+			requestLogger := logger.With().
+				Str("requestId", ctx.Response().Header().Get(echo.HeaderXRequestID)).
+				Logger()
+
+			request := ctx.Request()
+			request = request.WithContext(requestLogger.WithContext(request.Context()))
+			ctx.SetRequest(request)
+
+			// The error is handled here, so the status of the response
+			// is known, when the request is logged.
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+
+			// The logger of the context is the one, that the fields may
+			// be added to by the next handlers, e.g. the API key.
+			zerolog.Ctx(request.Context()).Debug().
+				Str("method", request.Method).
+				Str("path", request.URL.Path).
+				Int("status", ctx.Response().Status).
+				Dur("duration", time.Since(start)).
+				Msg("request handled")
+
+			return nil
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/maintenance"
+)
+
+// Maintenance responds with the status 503 to all requests, while the
+// maintenance mode is on, and tells the client to retry after the
+// period.
+func Maintenance(m *maintenance.Mode, retryAfter time.Duration) echo.MiddlewareFunc {
+	seconds := strconv.Itoa(int(retryAfter / time.Second))
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if !m.IsEnabled() {
+				return next(ctx)
+			}
+
+			ctx.Response().Header().Set(echo.HeaderRetryAfter, seconds)
+
+			return echo.NewHTTPError(http.StatusServiceUnavailable, "service is under maintenance")
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/audit"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/oidc"
+	"github.com/rs/zerolog"
+)
+
+const bearerPrefix = "Bearer "
+
+// Oidc lets through the requests with the ID token of the provider in
+// the authorization header and sets the scope of the groups of the
+// admin for RequireScope. The requests without the token are passed to
+// the next middleware, e.g. AdminKey, that must follow it. The name of
+// the admin is the actor of the request in the audit log.
+func Oidc(v *oidc.Verifier) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			header := ctx.Request().Header.Get(echo.HeaderAuthorization)
+
+			if !strings.HasPrefix(header, bearerPrefix) {
+				return next(ctx)
+			}
+
+			request := ctx.Request()
+			logger := zerolog.Ctx(request.Context())
+
+			identity, err := v.Verify(request.Context(), strings.TrimPrefix(header, bearerPrefix))
+			if errors.Is(err, oidc.ErrNoScope) {
+				return echo.NewHTTPError(http.StatusForbidden, "no group of id token has scope")
+			}
+
+			if err != nil {
+				logger.Warn().Err(err).Msg("id token is rejected")
+
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid id token")
+			}
+
+			logger.UpdateContext(func(c zerolog.Context) zerolog.Context {
+				return c.Str("oidcSubject", identity.Subject)
+			})
+
+			ctx.SetRequest(request.WithContext(audit.WithActor(request.Context(), identity.Name())))
+			ctx.Set(scopeContextKey, identity.Scope)
+
+			return next(ctx)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// Recover turns the panic of the handler into the response with the
+// status 500 and logs it along with the stack by the logger of the
+// request, so it must follow the request logger middleware.
+func Recover() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) (err error) {
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+
+				// The aborted handler is to be handled by net/http.
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				zerolog.Ctx(ctx.Request().Context()).Error().
+					Str("panic", fmt.Sprint(recovered)).
+					Str("stack", string(debug.Stack())).
+					Msg("handler panicked")
+
+				err = echo.NewHTTPError(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			}()
+
+			return next(ctx)
+		}
+	}
+}
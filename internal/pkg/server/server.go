@@ -2,6 +2,9 @@ package server
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
 
 	"github.com/labstack/echo/v4"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
@@ -9,9 +12,27 @@ import (
 	"github.com/mrumyantsev/go-errlib"
 )
 
+// The states of the server. The server goes from new to running once
+// and then to stopped, and never back.
+const (
+	stateNew int32 = iota
+	stateRunning
+	stateStopped
+)
+
+var (
+	// ErrAlreadyStarted is returned, when the server is started again.
+	ErrAlreadyStarted = errors.New("http server is already started")
+
+	// ErrStopped is returned, when the server is started after it is
+	// shut down.
+	ErrStopped = errors.New("http server is stopped")
+)
+
 type Server struct {
 	config *config.Config
 	echo   *echo.Echo
+	state  atomic.Int32
 }
 
 func New(cfg *config.Config, ep *endpoint.Endpoint, mw ...echo.MiddlewareFunc) *Server {
@@ -29,17 +50,56 @@ func New(cfg *config.Config, ep *endpoint.Endpoint, mw ...echo.MiddlewareFunc) *
 	}
 }
 
+// Start serves the requests until the server is shut down, and then
+// returns nil. The server can be started only once.
 func (s *Server) Start() error {
+	if !s.state.CompareAndSwap(stateNew, stateRunning) {
+		if s.state.Load() == stateStopped {
+			return ErrStopped
+		}
+
+		return ErrAlreadyStarted
+	}
+
 	listenAddr := s.config.HttpServerListenIp + ":" + s.config.HttpServerListenPort
 
-	if err := s.echo.Start(listenAddr); err != nil {
+	if err := s.start(listenAddr); (err != nil) && !errors.Is(err, http.ErrServerClosed) {
+		s.state.Store(stateStopped)
+
 		return errlib.Wrap(err, "could not start http server")
 	}
 
 	return nil
 }
 
+// start serves the requests over HTTPS, if the certificate of the
+// server is set, or over HTTP otherwise.
+func (s *Server) start(listenAddr string) error {
+	if !s.config.IsTls() {
+		return s.echo.Start(listenAddr)
+	}
+
+	tlsConfig, err := TlsConfig(s.config)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+
+	s.echo.TLSServer.Addr = listenAddr
+	s.echo.TLSServer.TLSConfig = tlsConfig
+
+	return s.echo.StartServer(s.echo.TLSServer)
+}
+
+// Shutdown stops the server gracefully, waiting for the requests in
+// progress until the context is done. The server, that is not started,
+// is not started afterwards. The repeated shutdown does nothing.
 func (s *Server) Shutdown(ctx context.Context) error {
+	if s.state.Swap(stateStopped) == stateStopped {
+		return nil
+	}
+
 	if err := s.echo.Shutdown(ctx); err != nil {
 		return errlib.Wrap(err, "could not shutdown http server")
 	}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/signer"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// The headers of the response, that have the signature of the body and
+// the id of the key, that has signed it.
+const (
+	HeaderSignature      = "X-Signature"
+	HeaderSignatureKeyId = "X-Signature-Key-Id"
+)
+
+// SignResponses signs the bodies of the responses, including the ones
+// of the errors, so the clients may verify, that the rates have not
+// been changed by the intermediaries. The body is signed as it is sent,
+// e.g. gzipped, so it is verified before it is decoded. The responses
+// are buffered, as the signature is sent before the body. The streamed
+// responses, that are flushed before they end, e.g. the NDJSON history,
+// are sent as they are written and are not signed, and the responses,
+// that are not written at all, e.g. as the client is gone, are left as
+// they are.
+func SignResponses(s *signer.Signer) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			response := ctx.Response()
+
+			writer := &bufferedWriter{
+				ResponseWriter: response.Writer,
+				status:         http.StatusOK,
+			}
+
+			response.Writer = writer
+
+			// The error is handled here, so the body of it is signed
+			// too.
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+
+			response.Writer = writer.ResponseWriter
+
+			if writer.isStreamed {
+				return writer.err
+			}
+
+			if !writer.isWritten {
+				return nil
+			}
+
+			header := response.Header()
+
+			header.Set(HeaderSignature, s.Sign(writer.body.Bytes()))
+			header.Set(HeaderSignatureKeyId, s.KeyId())
+
+			writer.ResponseWriter.WriteHeader(writer.status)
+
+			if _, err := writer.ResponseWriter.Write(writer.body.Bytes()); err != nil {
+				return errlib.Wrap(err, "could not write signed response")
+			}
+
+			return nil
+		}
+	}
+}
+
+// A bufferedWriter keeps the status and the body of the response, until
+// they are written to the response writer, unless the response is
+// streamed.
+type bufferedWriter struct {
+	http.ResponseWriter
+	status     int
+	body       bytes.Buffer
+	isWritten  bool
+	isStreamed bool
+
+	// err is the error of sending the buffered body, when the response
+	// has become streamed.
+	err error
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	if w.isStreamed {
+		w.ResponseWriter.WriteHeader(status)
+
+		return
+	}
+
+	w.status, w.isWritten = status, true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	if w.isStreamed {
+		if w.err != nil {
+			return 0, w.err
+		}
+
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.isWritten = true
+
+	return w.body.Write(data)
+}
+
+// Flush sends the status and the body, that are buffered, and makes the
+// response streamed, so the rest of the body is sent as it is written,
+// as the body can not be signed, until it ends.
+func (w *bufferedWriter) Flush() {
+	if !w.isStreamed {
+		w.isStreamed = true
+
+		w.ResponseWriter.WriteHeader(w.status)
+
+		if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+			w.err = errlib.Wrap(err, "could not write streamed response")
+		}
+
+		w.body.Reset()
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/signer"
+)
+
+func newSignedEcho(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	s := signer.New(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+
+	e := echo.New()
+	e.Use(SignResponses(s))
+
+	e.GET("/whole", func(ctx echo.Context) error {
+		return ctx.String(http.StatusOK, "whole")
+	})
+
+	e.GET("/stream", func(ctx echo.Context) error {
+		response := ctx.Response()
+		response.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+		response.WriteHeader(http.StatusOK)
+
+		for _, line := range []string{"{\"a\":1}\n", "{\"a\":2}\n"} {
+			if _, err := response.Write([]byte(line)); err != nil {
+				return err
+			}
+
+			response.Flush()
+		}
+
+		return nil
+	})
+
+	return e
+}
+
+func TestSignResponses(t *testing.T) {
+	e := newSignedEcho(t)
+
+	tests := []struct {
+		path       string
+		wantBody   string
+		wantSigned bool
+	}{
+		{"/whole", "whole", true},
+		{"/stream", "{\"a\":1}\n{\"a\":2}\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if recorder.Code != http.StatusOK {
+				t.Errorf("got status %d, want %d", recorder.Code, http.StatusOK)
+			}
+
+			if body := recorder.Body.String(); body != tt.wantBody {
+				t.Errorf("got body %q, want %q", body, tt.wantBody)
+			}
+
+			if isSigned := recorder.Header().Get(HeaderSignature) != ""; isSigned != tt.wantSigned {
+				t.Errorf("got signed %t, want %t", isSigned, tt.wantSigned)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+var (
+	ErrNoClientCa        = errors.New("no certificates in client ca file")
+	ErrClientCnForbidden = errors.New("client certificate common name is not allowed")
+)
+
+// TlsConfig returns the TLS config of the HTTPS listener by the
+// certificate and the key of the server. If the client CA is set, the
+// clients must present the certificates, that are signed by it, and
+// that have any of the allowed common names, when they are set.
+func TlsConfig(cfg *config.Config) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.TlsCertFile, cfg.TlsKeyFile)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not load server certificate")
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if cfg.TlsClientCaFile == "" {
+		return tlsConfig, nil
+	}
+
+	caData, err := os.ReadFile(cfg.TlsClientCaFile)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not read client ca file")
+	}
+
+	clientCas := x509.NewCertPool()
+
+	if !clientCas.AppendCertsFromPEM(caData) {
+		return nil, ErrNoClientCa
+	}
+
+	tlsConfig.ClientCAs = clientCas
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+
+	if len(cfg.TlsClientAllowedCns) == 0 {
+		return tlsConfig, nil
+	}
+
+	allowedCns := make(map[string]bool, len(cfg.TlsClientAllowedCns))
+
+	for _, cn := range cfg.TlsClientAllowedCns {
+		allowedCns[cn] = true
+	}
+
+	// The chain is verified already, so the leaf certificate is the one
+	// of the client.
+	tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+		if (len(state.PeerCertificates) == 0) || !allowedCns[state.PeerCertificates[0].Subject.CommonName] {
+			return ErrClientCnForbidden
+		}
+
+		return nil
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/tracing"
+	"github.com/rs/zerolog"
+)
+
+// TraceContext continues the trace of the client by the traceparent and
+// the tracestate headers, or starts the new one, with the span of the
+// request, so the spans of the handlers and of the storage are the
+// children of it. The ids of the trace and of the span are added to the
+// logged fields and are sent back in the traceresponse header. It must
+// follow the request logger.
+func TraceContext() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			request := ctx.Request()
+			requestCtx := request.Context()
+
+			if sc, ok := tracing.Extract(request.Header); ok {
+				requestCtx = tracing.WithSpanContext(requestCtx, sc)
+			}
+
+			// The route is the name of the span, so the spans of the
+			// requests of the same endpoint are grouped.
+			requestCtx, span := tracing.Start(requestCtx, request.Method+" "+ctx.Path())
+
+			zerolog.Ctx(requestCtx).UpdateContext(func(c zerolog.Context) zerolog.Context {
+				return c.Str("traceId", span.TraceIdString()).Str("spanId", span.SpanIdString())
+			})
+
+			ctx.SetRequest(request.WithContext(requestCtx))
+			ctx.Response().Header().Set(tracing.HeaderTraceresponse, span.Traceparent())
+
+			// The error is handled here, so the status of the response
+			// is known, when the span ends.
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+
+			var spanErr error
+
+			if ctx.Response().Status >= http.StatusInternalServerError {
+				spanErr = errors.New(http.StatusText(ctx.Response().Status))
+			}
+
+			span.End(requestCtx, spanErr)
+
+			return nil
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/repository"
+)
+
+type AuditService struct {
+	config     *config.Config
+	repository repository.Audit
+}
+
+func NewAuditService(cfg *config.Config, repo repository.Audit) *AuditService {
+	return &AuditService{
+		config:     cfg,
+		repository: repo,
+	}
+}
+
+func (s *AuditService) Create(ctx context.Context, entry models.AuditEntry) (models.AuditEntry, error) {
+	return s.repository.Create(ctx, entry)
+}
+
+func (s *AuditService) GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.AuditEntry, error) {
+	return s.repository.GetByPeriod(ctx, fromDate, toDate)
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/repository"
@@ -18,10 +20,14 @@ func NewCurrenciesService(cfg *config.Config, repo repository.Currencies) *Curre
 	}
 }
 
-func (s *CurrenciesService) Create(currencies models.Currencies, updateDatetimeId int) error {
-	return s.repository.Create(currencies, updateDatetimeId)
+func (s *CurrenciesService) Create(ctx context.Context, currencies models.Currencies, updateDatetimeId int) error {
+	return s.repository.Create(ctx, currencies, updateDatetimeId)
+}
+
+func (s *CurrenciesService) GetLatest(ctx context.Context, updateDatetimeId int) (models.Currencies, error) {
+	return s.repository.GetLatest(ctx, updateDatetimeId)
 }
 
-func (s *CurrenciesService) GetLatest(updateDatetimeId int) (models.Currencies, error) {
-	return s.repository.GetLatest(updateDatetimeId)
+func (s *CurrenciesService) GetHistory(ctx context.Context, fromDate string, toDate string) ([]models.HistoryCurrency, error) {
+	return s.repository.GetHistory(ctx, fromDate, toDate)
 }
@@ -1,29 +1,49 @@
 package service
 
 import (
+	"context"
+
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/repository"
 )
 
 type UpdateDatetime interface {
-	Create(datetime string) (models.UpdateDatetime, error)
-	GetLatest() (models.UpdateDatetime, error)
+	Create(ctx context.Context, datetime string, rateDate string) (models.UpdateDatetime, error)
+	GetLatest(ctx context.Context) (models.UpdateDatetime, error)
+	GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.UpdateDatetime, error)
+	DeleteBefore(ctx context.Context, date string, keepId int) (int64, error)
 }
 
 type Currencies interface {
-	Create(currencies models.Currencies, updateDatetimeId int) error
-	GetLatest(updateDatetimeId int) (models.Currencies, error)
+	Create(ctx context.Context, currencies models.Currencies, updateDatetimeId int) error
+	GetLatest(ctx context.Context, updateDatetimeId int) (models.Currencies, error)
+	GetHistory(ctx context.Context, fromDate string, toDate string) ([]models.HistoryCurrency, error)
+}
+
+type Usage interface {
+	Increment(ctx context.Context, keyName string, date string) error
+	Count(ctx context.Context, keyName string, fromDate string, date string) (models.UsageCount, error)
+	GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.Usage, error)
+}
+
+type Audit interface {
+	Create(ctx context.Context, entry models.AuditEntry) (models.AuditEntry, error)
+	GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.AuditEntry, error)
 }
 
 type Service struct {
 	UpdateDatetime UpdateDatetime
 	Currencies     Currencies
+	Usage          Usage
+	Audit          Audit
 }
 
 func New(cfg *config.Config, repo *repository.Repository) *Service {
 	return &Service{
 		UpdateDatetime: NewUpdateDatetimeService(cfg, repo.UpdateDatetime),
 		Currencies:     NewCurrenciesService(cfg, repo.Currencies),
+		Usage:          NewUsageService(cfg, repo.Usage),
+		Audit:          NewAuditService(cfg, repo.Audit),
 	}
 }
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/repository"
@@ -18,10 +20,18 @@ func NewUpdateDatetimeService(cfg *config.Config, repo repository.UpdateDatetime
 	}
 }
 
-func (s *UpdateDatetimeService) Create(datetime string) (models.UpdateDatetime, error) {
-	return s.repository.Create(datetime)
+func (s *UpdateDatetimeService) Create(ctx context.Context, datetime string, rateDate string) (models.UpdateDatetime, error) {
+	return s.repository.Create(ctx, datetime, rateDate)
+}
+
+func (s *UpdateDatetimeService) GetLatest(ctx context.Context) (models.UpdateDatetime, error) {
+	return s.repository.GetLatest(ctx)
+}
+
+func (s *UpdateDatetimeService) GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.UpdateDatetime, error) {
+	return s.repository.GetByPeriod(ctx, fromDate, toDate)
 }
 
-func (s *UpdateDatetimeService) GetLatest() (models.UpdateDatetime, error) {
-	return s.repository.GetLatest()
+func (s *UpdateDatetimeService) DeleteBefore(ctx context.Context, date string, keepId int) (int64, error) {
+	return s.repository.DeleteBefore(ctx, date, keepId)
 }
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/repository"
+)
+
+type UsageService struct {
+	config     *config.Config
+	repository repository.Usage
+}
+
+func NewUsageService(cfg *config.Config, repo repository.Usage) *UsageService {
+	return &UsageService{
+		config:     cfg,
+		repository: repo,
+	}
+}
+
+func (s *UsageService) Increment(ctx context.Context, keyName string, date string) error {
+	return s.repository.Increment(ctx, keyName, date)
+}
+
+func (s *UsageService) Count(ctx context.Context, keyName string, fromDate string, date string) (models.UsageCount, error) {
+	return s.repository.Count(ctx, keyName, fromDate, date)
+}
+
+func (s *UsageService) GetByPeriod(ctx context.Context, fromDate string, toDate string) ([]models.Usage, error) {
+	return s.repository.GetByPeriod(ctx, fromDate, toDate)
+}
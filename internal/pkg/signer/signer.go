@@ -0,0 +1,74 @@
+// Package signer signs the data, e.g. the bodies of the responses, so
+// the receivers may verify, that they have not been changed on the way.
+package signer
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// Algorithm is the algorithm of the signatures.
+const Algorithm = "Ed25519"
+
+// keyIdLength is the number of bytes of the hash of the public key,
+// that the id of the key is made of.
+const keyIdLength = 8
+
+// ErrInvalidKey is returned, when the signing key is neither an Ed25519
+// seed nor an Ed25519 private key.
+var ErrInvalidKey = errors.New("invalid signing key")
+
+// ParseKey parses the base64 encoded Ed25519 private key or the seed of
+// it.
+func ParseKey(text string) (ed25519.PrivateKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return nil, errlib.Wrap(ErrInvalidKey, "key must be base64 encoded")
+	}
+
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	default:
+		return nil, errlib.Wrap(ErrInvalidKey, "key must be 32 bytes seed or 64 bytes private key")
+	}
+}
+
+// A Signer signs the data with the private key. The id of the key tells
+// the receivers, which public key verifies the signatures, e.g. when
+// the key is rotated.
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyId string
+}
+
+func New(key ed25519.PrivateKey) *Signer {
+	hash := sha256.Sum256(key.Public().(ed25519.PublicKey))
+
+	return &Signer{
+		key:   key,
+		keyId: hex.EncodeToString(hash[:keyIdLength]),
+	}
+}
+
+// Sign returns the base64 encoded signature of the data.
+func (s *Signer) Sign(data []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, data))
+}
+
+// PublicKey returns the base64 encoded public key, that verifies the
+// signatures.
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+func (s *Signer) KeyId() string {
+	return s.keyId
+}
@@ -0,0 +1,478 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	boltFilePerm = 0600
+	boltDirPerm  = 0755
+)
+
+var (
+	bucketSnapshots = []byte("snapshots")
+	bucketRateDates = []byte("rate_dates")
+	bucketUsage     = []byte("api_usage")
+	bucketAudit     = []byte("admin_audit")
+)
+
+// usageKeySeparator separates the key name from the date in the keys of
+// the usage, so the keys of the same name are sorted by the dates.
+const usageKeySeparator = "\x00"
+
+// A BoltStorage is the storage, that keeps snapshots in an embedded
+// key-value database file, so no external database is needed. The
+// snapshots are keyed by the update datetime id, and the ids are
+// indexed by the rate date to keep the inserts idempotent.
+type BoltStorage struct {
+	config *config.Config
+	db     *bolt.DB
+}
+
+func NewBoltStorage(cfg *config.Config) *BoltStorage {
+	return &BoltStorage{config: cfg}
+}
+
+func (s *BoltStorage) Connect() error {
+	if err := os.MkdirAll(path.Dir(s.config.DbBoltFile), boltDirPerm); err != nil {
+		return errlib.Wrap(err, "could not make database directory")
+	}
+
+	db, err := bolt.Open(s.config.DbBoltFile, boltFilePerm, &bolt.Options{
+		Timeout: s.config.DbPingTimeout,
+	})
+	if err != nil {
+		return errlib.Wrap(err, "could not open database file")
+	}
+
+	s.db = db
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketSnapshots, bucketRateDates, bucketUsage, bucketAudit} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return errlib.Wrap(err, "could not create bucket "+string(name))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errlib.Wrap(err, "could not prepare database")
+	}
+
+	return nil
+}
+
+func (s *BoltStorage) Disconnect() error {
+	return s.db.Close()
+}
+
+func (s *BoltStorage) Ping(_ context.Context) error {
+	return s.db.View(func(_ *bolt.Tx) error {
+		return nil
+	})
+}
+
+// Migrate does nothing, as the buckets are created on connect.
+func (s *BoltStorage) Migrate(_ context.Context) error {
+	return nil
+}
+
+func (s *BoltStorage) GetLatestUpdateDatetime(_ context.Context) (models.UpdateDatetime, error) {
+	var updateDatetime models.UpdateDatetime
+
+	var latest *snapshot
+
+	err := s.forEachSnapshot(func(snapshot *snapshot) {
+		if isLaterSnapshot(snapshot, latest) {
+			latest = snapshot
+		}
+	})
+	if (err != nil) || (latest == nil) {
+		return updateDatetime, err
+	}
+
+	return latest.UpdateDatetime, nil
+}
+
+func (s *BoltStorage) InsertUpdateDatetime(_ context.Context, datetime string, rateDate string) (models.UpdateDatetime, error) {
+	var updateDatetime models.UpdateDatetime
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		snapshot, err := upsertBoltSnapshot(tx, datetime, rateDate)
+		if err != nil {
+			return err
+		}
+
+		updateDatetime = snapshot.UpdateDatetime
+
+		return putBoltSnapshot(tx, snapshot)
+	})
+
+	return updateDatetime, err
+}
+
+func (s *BoltStorage) GetUpdateDatetimes(_ context.Context, fromDate string, toDate string) ([]models.UpdateDatetime, error) {
+	var updateDatetimes []models.UpdateDatetime
+
+	err := s.forEachSnapshot(func(snapshot *snapshot) {
+		if date := snapshotDate(snapshot); (date >= fromDate) && (date <= toDate) {
+			updateDatetimes = append(updateDatetimes, snapshot.UpdateDatetime)
+		}
+	})
+
+	return updateDatetimes, err
+}
+
+// PruneBefore deletes the snapshots of the rates, that are older than
+// the date, except the latest one, and returns the number of them.
+func (s *BoltStorage) PruneBefore(_ context.Context, date string) (int64, error) {
+	var deleted int64
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		snapshots := tx.Bucket(bucketSnapshots)
+
+		var latest *snapshot
+
+		outdated := []*snapshot{}
+
+		err := snapshots.ForEach(func(_ []byte, data []byte) error {
+			snapshot, err := decodeSnapshot(data)
+			if err != nil {
+				return err
+			}
+
+			if isLaterSnapshot(snapshot, latest) {
+				latest = snapshot
+			}
+
+			if snapshotDate(snapshot) < date {
+				outdated = append(outdated, snapshot)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, snapshot := range outdated {
+			if snapshot == latest {
+				continue
+			}
+
+			if err = snapshots.Delete(boltKey(snapshot.UpdateDatetime.Id)); err != nil {
+				return errlib.Wrap(err, "could not delete snapshot")
+			}
+
+			if rateDate := snapshot.UpdateDatetime.RateDate; rateDate != "" {
+				if err = tx.Bucket(bucketRateDates).Delete([]byte(rateDate)); err != nil {
+					return errlib.Wrap(err, "could not delete rate date")
+				}
+			}
+
+			deleted++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+func (s *BoltStorage) InsertCurrencies(_ context.Context, currencies models.Currencies, updateDatetimeId int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		snapshot, err := getBoltSnapshot(tx, updateDatetimeId)
+		if err != nil {
+			return err
+		}
+
+		if snapshot == nil {
+			return errlib.Wrap(ErrNoUpdate, "could not insert currencies")
+		}
+
+		snapshot.Currencies = currencies
+
+		return putBoltSnapshot(tx, snapshot)
+	})
+}
+
+func (s *BoltStorage) InsertSnapshot(_ context.Context, datetime string, currencies models.Currencies) (models.UpdateDatetime, error) {
+	var updateDatetime models.UpdateDatetime
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		snapshot, err := upsertBoltSnapshot(tx, datetime, currencies.RateDateString())
+		if err != nil {
+			return err
+		}
+
+		snapshot.Currencies = currencies
+		updateDatetime = snapshot.UpdateDatetime
+
+		return putBoltSnapshot(tx, snapshot)
+	})
+
+	return updateDatetime, err
+}
+
+func (s *BoltStorage) GetLatestCurrencies(_ context.Context, updateDatetimeId int) (models.Currencies, error) {
+	var currencies models.Currencies
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		snapshot, err := getBoltSnapshot(tx, updateDatetimeId)
+		if (err != nil) || (snapshot == nil) {
+			return err
+		}
+
+		currencies = sortedCurrencies(snapshot.Currencies)
+
+		return nil
+	})
+
+	return currencies, err
+}
+
+func (s *BoltStorage) GetCurrencyHistory(_ context.Context, fromDate string, toDate string) ([]models.HistoryCurrency, error) {
+	var history []models.HistoryCurrency
+
+	err := s.forEachSnapshot(func(snapshot *snapshot) {
+		date := snapshotDate(snapshot)
+
+		if (date < fromDate) || (date > toDate) {
+			return
+		}
+
+		for _, currency := range sortedCurrencies(snapshot.Currencies).Currencies {
+			history = append(history, models.HistoryCurrency{
+				UpdateDatetimeId: snapshot.UpdateDatetime.Id,
+				RateDate:         date,
+				Currency:         currency,
+			})
+		}
+	})
+
+	return history, err
+}
+
+func (s *BoltStorage) IncrementUsage(_ context.Context, keyName string, date string) (models.UsageCount, error) {
+	var count models.UsageCount
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		usage := tx.Bucket(bucketUsage)
+		key := []byte(keyName + usageKeySeparator + date)
+
+		count.Day = 1
+
+		if value := usage.Get(key); value != nil {
+			count.Day += int64(binary.BigEndian.Uint64(value))
+		}
+
+		if err := usage.Put(key, boltCount(count.Day)); err != nil {
+			return errlib.Wrap(err, "could not put usage")
+		}
+
+		from := []byte(keyName + usageKeySeparator + monthStart(date))
+
+		cursor := usage.Cursor()
+
+		for k, v := cursor.Seek(from); (k != nil) && (string(k) <= string(key)); k, v = cursor.Next() {
+			count.Month += int64(binary.BigEndian.Uint64(v))
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+func (s *BoltStorage) GetUsage(_ context.Context, fromDate string, toDate string) ([]models.Usage, error) {
+	var usages []models.Usage
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketUsage).ForEach(func(k []byte, v []byte) error {
+			keyName, date, _ := strings.Cut(string(k), usageKeySeparator)
+
+			if (date >= fromDate) && (date <= toDate) {
+				usages = append(usages, models.Usage{
+					KeyName:  keyName,
+					Date:     date,
+					Requests: int64(binary.BigEndian.Uint64(v)),
+				})
+			}
+
+			return nil
+		})
+	})
+
+	return usages, err
+}
+
+// InsertAuditEntry records the entry keyed by the next id, so the
+// entries are kept in the order of creation.
+func (s *BoltStorage) InsertAuditEntry(_ context.Context, entry models.AuditEntry) (models.AuditEntry, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		audit := tx.Bucket(bucketAudit)
+
+		id, err := audit.NextSequence()
+		if err != nil {
+			return errlib.Wrap(err, "could not get next audit entry id")
+		}
+
+		entry.Id = int(id)
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return errlib.Wrap(err, "could not encode audit entry")
+		}
+
+		if err = audit.Put(boltKey(entry.Id), data); err != nil {
+			return errlib.Wrap(err, "could not put audit entry")
+		}
+
+		return nil
+	})
+
+	return entry, err
+}
+
+func (s *BoltStorage) GetAuditEntries(_ context.Context, fromDate string, toDate string) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketAudit).ForEach(func(_ []byte, data []byte) error {
+			var entry models.AuditEntry
+
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return errlib.Wrap(err, "could not decode audit entry")
+			}
+
+			if date := auditDate(entry); (date >= fromDate) && (date <= toDate) {
+				entries = append(entries, entry)
+			}
+
+			return nil
+		})
+	})
+
+	return entries, err
+}
+
+// forEachSnapshot calls the function for every snapshot in the order
+// of the update datetime ids.
+func (s *BoltStorage) forEachSnapshot(fn func(snapshot *snapshot)) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSnapshots).ForEach(func(_ []byte, data []byte) error {
+			snapshot, err := decodeSnapshot(data)
+			if err != nil {
+				return err
+			}
+
+			fn(snapshot)
+
+			return nil
+		})
+	})
+}
+
+// upsertBoltSnapshot returns the snapshot of the rate date with the
+// datetime updated, or a new one, if there is no such snapshot.
+func upsertBoltSnapshot(tx *bolt.Tx, datetime string, rateDate string) (*snapshot, error) {
+	if rateDate != "" {
+		if key := tx.Bucket(bucketRateDates).Get([]byte(rateDate)); key != nil {
+			snapshot, err := getBoltSnapshot(tx, int(binary.BigEndian.Uint64(key)))
+			if err != nil {
+				return nil, err
+			}
+
+			if snapshot != nil {
+				snapshot.UpdateDatetime.UpdateDatetime = datetime
+
+				return snapshot, nil
+			}
+		}
+	}
+
+	id, err := tx.Bucket(bucketSnapshots).NextSequence()
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not get next update datetime id")
+	}
+
+	return &snapshot{
+		UpdateDatetime: models.UpdateDatetime{
+			Id:             int(id),
+			UpdateDatetime: datetime,
+			RateDate:       rateDate,
+		},
+	}, nil
+}
+
+func getBoltSnapshot(tx *bolt.Tx, updateDatetimeId int) (*snapshot, error) {
+	data := tx.Bucket(bucketSnapshots).Get(boltKey(updateDatetimeId))
+	if data == nil {
+		return nil, nil
+	}
+
+	return decodeSnapshot(data)
+}
+
+func putBoltSnapshot(tx *bolt.Tx, snapshot *snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return errlib.Wrap(err, "could not encode snapshot")
+	}
+
+	key := boltKey(snapshot.UpdateDatetime.Id)
+
+	if err = tx.Bucket(bucketSnapshots).Put(key, data); err != nil {
+		return errlib.Wrap(err, "could not put snapshot")
+	}
+
+	if rateDate := snapshot.UpdateDatetime.RateDate; rateDate != "" {
+		if err = tx.Bucket(bucketRateDates).Put([]byte(rateDate), key); err != nil {
+			return errlib.Wrap(err, "could not put rate date")
+		}
+	}
+
+	return nil
+}
+
+func decodeSnapshot(data []byte) (*snapshot, error) {
+	snapshot := new(snapshot)
+
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, errlib.Wrap(err, "could not decode snapshot")
+	}
+
+	return snapshot, nil
+}
+
+func boltCount(count int64) []byte {
+	value := make([]byte, 8)
+
+	binary.BigEndian.PutUint64(value, uint64(count))
+
+	return value
+}
+
+// boltKey encodes the id in big-endian, so the keys are sorted the
+// same way as the ids.
+func boltKey(id int) []byte {
+	key := make([]byte, 8)
+
+	binary.BigEndian.PutUint64(key, uint64(id))
+
+	return key
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/database"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/migrator"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/repository"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/service"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// updatesChannel is the channel of the notifications about updates.
+const updatesChannel = "currency_updates"
+
+// A DbStorage is the storage, that keeps data in the configured
+// database.
+type DbStorage struct {
+	config   *config.Config
+	database *database.Database
+	migrator *migrator.Migrator
+	service  *service.Service
+}
+
+func NewDbStorage(cfg *config.Config) *DbStorage {
+	db := database.New(cfg)
+
+	return &DbStorage{
+		config:   cfg,
+		database: db,
+		migrator: migrator.New(cfg, db),
+		service:  service.New(cfg, repository.New(cfg, db)),
+	}
+}
+
+func (s *DbStorage) Connect() error {
+	return s.database.Connect()
+}
+
+func (s *DbStorage) Disconnect() error {
+	return s.database.Disconnect()
+}
+
+// Ping checks, whether the database is reachable.
+func (s *DbStorage) Ping(ctx context.Context) error {
+	return s.database.Ping(ctx)
+}
+
+// Migrate brings the database schema up to date.
+func (s *DbStorage) Migrate(ctx context.Context) error {
+	return s.migrator.Up(ctx)
+}
+
+func (s *DbStorage) GetLatestUpdateDatetime(ctx context.Context) (models.UpdateDatetime, error) {
+	return s.service.UpdateDatetime.GetLatest(ctx)
+}
+
+func (s *DbStorage) InsertUpdateDatetime(ctx context.Context, datetime string, rateDate string) (models.UpdateDatetime, error) {
+	return s.service.UpdateDatetime.Create(ctx, datetime, rateDate)
+}
+
+func (s *DbStorage) GetUpdateDatetimes(ctx context.Context, fromDate string, toDate string) ([]models.UpdateDatetime, error) {
+	return s.service.UpdateDatetime.GetByPeriod(ctx, fromDate, toDate)
+}
+
+// PruneBefore deletes the updates of the rates, that are older than
+// the date, except the latest one, and returns the number of them.
+func (s *DbStorage) PruneBefore(ctx context.Context, date string) (int64, error) {
+	var deleted int64
+
+	err := s.database.InTransaction(ctx, func(ctx context.Context) error {
+		latest, err := s.service.UpdateDatetime.GetLatest(ctx)
+		if err != nil {
+			return errlib.Wrap(err, "could not get latest update datetime")
+		}
+
+		deleted, err = s.service.UpdateDatetime.DeleteBefore(ctx, date, latest.Id)
+		if err != nil {
+			return errlib.Wrap(err, "could not delete update datetimes")
+		}
+
+		return nil
+	})
+
+	return deleted, err
+}
+
+func (s *DbStorage) InsertCurrencies(ctx context.Context, currencies models.Currencies, updateDatetimeId int) error {
+	return s.service.Currencies.Create(ctx, currencies, updateDatetimeId)
+}
+
+// InsertSnapshot inserts the update datetime and the currencies of
+// the update in a single transaction, so no update is stored without
+// its currencies.
+func (s *DbStorage) InsertSnapshot(ctx context.Context, datetime string, currencies models.Currencies) (models.UpdateDatetime, error) {
+	var updateDatetime models.UpdateDatetime
+
+	err := s.database.InTransaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		updateDatetime, err = s.service.UpdateDatetime.Create(
+			ctx,
+			datetime,
+			currencies.RateDateString(),
+		)
+		if err != nil {
+			return errlib.Wrap(err, "could not insert datetime")
+		}
+
+		if err = s.service.Currencies.Create(ctx, currencies, updateDatetime.Id); err != nil {
+			return errlib.Wrap(err, "could not insert currencies")
+		}
+
+		return nil
+	})
+
+	return updateDatetime, err
+}
+
+func (s *DbStorage) GetLatestCurrencies(ctx context.Context, updateDatetimeId int) (models.Currencies, error) {
+	return s.service.Currencies.GetLatest(ctx, updateDatetimeId)
+}
+
+func (s *DbStorage) GetCurrencyHistory(ctx context.Context, fromDate string, toDate string) ([]models.HistoryCurrency, error) {
+	return s.service.Currencies.GetHistory(ctx, fromDate, toDate)
+}
+
+func (s *DbStorage) IncrementUsage(ctx context.Context, keyName string, date string) (models.UsageCount, error) {
+	var count models.UsageCount
+
+	err := s.database.InTransaction(ctx, func(ctx context.Context) error {
+		if err := s.service.Usage.Increment(ctx, keyName, date); err != nil {
+			return errlib.Wrap(err, "could not increment usage")
+		}
+
+		var err error
+
+		if count, err = s.service.Usage.Count(ctx, keyName, monthStart(date), date); err != nil {
+			return errlib.Wrap(err, "could not count usage")
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+func (s *DbStorage) GetUsage(ctx context.Context, fromDate string, toDate string) ([]models.Usage, error) {
+	return s.service.Usage.GetByPeriod(ctx, fromDate, toDate)
+}
+
+func (s *DbStorage) InsertAuditEntry(ctx context.Context, entry models.AuditEntry) (models.AuditEntry, error) {
+	return s.service.Audit.Create(ctx, entry)
+}
+
+func (s *DbStorage) GetAuditEntries(ctx context.Context, fromDate string, toDate string) ([]models.AuditEntry, error) {
+	return s.service.Audit.GetByPeriod(ctx, fromDate, toDate)
+}
+
+// NotifyUpdate notifies the other instances about the update. It is
+// supported by Postgres only.
+func (s *DbStorage) NotifyUpdate(ctx context.Context, sender string) error {
+	if s.config.DbDriver != config.DbDriverPostgres {
+		return ErrNoNotifications
+	}
+
+	return s.database.Notify(ctx, updatesChannel, sender)
+}
+
+// ListenUpdates calls the function on every update, until the context
+// is canceled. The sender is empty, when it is unknown. It is supported
+// by Postgres only.
+func (s *DbStorage) ListenUpdates(ctx context.Context, fn func(sender string)) error {
+	if s.config.DbDriver != config.DbDriverPostgres {
+		return ErrNoNotifications
+	}
+
+	return s.database.Listen(ctx, updatesChannel, fn)
+}
@@ -0,0 +1,609 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	fsops "github.com/mrumyantsev/currency-converter-app/internal/pkg/fs-ops"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/rs/zerolog"
+)
+
+// A snapshot is the update datetime along with the currencies of the
+// update, as it is saved in the file.
+type snapshot struct {
+	UpdateDatetime models.UpdateDatetime `json:"updateDatetime"`
+	Currencies     models.Currencies     `json:"currencies"`
+}
+
+// A FileStorage is the storage, that is used, when there is no
+// database. It serves all data from memory and saves every snapshot in
+// a file, so the data are loaded back on the next start. The usage of
+// the API keys is counted in memory and saved in the file once in the
+// flush interval and on disconnect, so the requests do not wait for the
+// disk.
+type FileStorage struct {
+	config    *config.Config
+	fsOps     *fsops.FsOps
+	mu        sync.RWMutex
+	snapshots []*snapshot
+	usageMu   sync.Mutex
+	usage     []models.Usage
+	auditMu   sync.Mutex
+	audit     []models.AuditEntry
+
+	// usageDate is the latest date, that the usage is counted by, and
+	// the versions tell, whether the counted usage is saved already.
+	usageDate         string
+	usageVersion      int64
+	savedUsageVersion int64
+
+	// flushMu orders the saves of the usage, and stopFlush and
+	// flushDone stop the periodic flush of the connection.
+	flushMu   sync.Mutex
+	stopFlush chan struct{}
+	flushDone chan struct{}
+}
+
+func NewFileStorage(cfg *config.Config, fo *fsops.FsOps) *FileStorage {
+	return &FileStorage{
+		config: cfg,
+		fsOps:  fo,
+	}
+}
+
+// Connect loads the saved snapshots into memory.
+func (s *FileStorage) Connect() error {
+	files, err := s.fsOps.Snapshots()
+	if err != nil {
+		return errlib.Wrap(err, "could not read saved snapshots")
+	}
+
+	snapshots := make([]*snapshot, 0, len(files))
+
+	for _, data := range files {
+		snapshot := new(snapshot)
+
+		if err = json.Unmarshal(data, snapshot); err != nil {
+			return errlib.Wrap(err, "could not decode saved snapshot")
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].UpdateDatetime.Id < snapshots[j].UpdateDatetime.Id
+	})
+
+	s.mu.Lock()
+	s.snapshots = snapshots
+	s.mu.Unlock()
+
+	data, err := s.fsOps.Usage()
+	if err != nil {
+		return errlib.Wrap(err, "could not read saved usage")
+	}
+
+	var usage []models.Usage
+
+	if data != nil {
+		if err = json.Unmarshal(data, &usage); err != nil {
+			return errlib.Wrap(err, "could not decode saved usage")
+		}
+	}
+
+	s.usageMu.Lock()
+	s.usage = usage
+	s.usageVersion, s.savedUsageVersion = 0, 0
+	s.usageMu.Unlock()
+
+	if data, err = s.fsOps.Audit(); err != nil {
+		return errlib.Wrap(err, "could not read saved audit")
+	}
+
+	var audit []models.AuditEntry
+
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var entry models.AuditEntry
+
+		if err = json.Unmarshal(line, &entry); err != nil {
+			return errlib.Wrap(err, "could not decode saved audit entry")
+		}
+
+		audit = append(audit, entry)
+	}
+
+	s.auditMu.Lock()
+	s.audit = audit
+	s.auditMu.Unlock()
+
+	s.stopFlush, s.flushDone = make(chan struct{}), make(chan struct{})
+
+	go s.flushUsagePeriodically(s.stopFlush, s.flushDone)
+
+	return nil
+}
+
+// Disconnect stops the periodic flush and saves the usage, that is not
+// saved yet.
+func (s *FileStorage) Disconnect() error {
+	if s.stopFlush != nil {
+		close(s.stopFlush)
+		<-s.flushDone
+
+		s.stopFlush, s.flushDone = nil, nil
+	}
+
+	return s.flushUsage()
+}
+
+func (s *FileStorage) Ping(_ context.Context) error {
+	return nil
+}
+
+func (s *FileStorage) Migrate(_ context.Context) error {
+	return nil
+}
+
+func (s *FileStorage) GetLatestUpdateDatetime(_ context.Context) (models.UpdateDatetime, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	latest := s.latest()
+	if latest == nil {
+		return models.UpdateDatetime{}, nil
+	}
+
+	return latest.UpdateDatetime, nil
+}
+
+func (s *FileStorage) InsertUpdateDatetime(_ context.Context, datetime string, rateDate string) (models.UpdateDatetime, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.upsert(datetime, rateDate)
+
+	return snapshot.UpdateDatetime, s.save(snapshot)
+}
+
+func (s *FileStorage) GetUpdateDatetimes(_ context.Context, fromDate string, toDate string) ([]models.UpdateDatetime, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var updateDatetimes []models.UpdateDatetime
+
+	for _, snapshot := range s.snapshots {
+		if date := snapshotDate(snapshot); (date >= fromDate) && (date <= toDate) {
+			updateDatetimes = append(updateDatetimes, snapshot.UpdateDatetime)
+		}
+	}
+
+	return updateDatetimes, nil
+}
+
+// PruneBefore deletes the snapshots of the rates, that are older than
+// the date, except the latest one, and returns the number of them.
+func (s *FileStorage) PruneBefore(_ context.Context, date string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		kept    = make([]*snapshot, 0, len(s.snapshots))
+		deleted int64
+	)
+
+	latest := s.latest()
+
+	for i, snapshot := range s.snapshots {
+		if (snapshot == latest) || (snapshotDate(snapshot) >= date) {
+			kept = append(kept, snapshot)
+			continue
+		}
+
+		if err := s.fsOps.DeleteSnapshot(snapshot.UpdateDatetime.Id); err != nil {
+			s.snapshots = append(kept, s.snapshots[i:]...)
+
+			return deleted, errlib.Wrap(err, "could not delete snapshot")
+		}
+
+		deleted++
+	}
+
+	s.snapshots = kept
+
+	return deleted, nil
+}
+
+func (s *FileStorage) InsertCurrencies(_ context.Context, currencies models.Currencies, updateDatetimeId int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.find(updateDatetimeId)
+	if snapshot == nil {
+		return errlib.Wrap(ErrNoUpdate, "could not insert currencies")
+	}
+
+	snapshot.Currencies = currencies
+
+	return s.save(snapshot)
+}
+
+func (s *FileStorage) InsertSnapshot(_ context.Context, datetime string, currencies models.Currencies) (models.UpdateDatetime, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.upsert(datetime, currencies.RateDateString())
+	snapshot.Currencies = currencies
+
+	return snapshot.UpdateDatetime, s.save(snapshot)
+}
+
+func (s *FileStorage) GetLatestCurrencies(_ context.Context, updateDatetimeId int) (models.Currencies, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := s.find(updateDatetimeId)
+	if snapshot == nil {
+		return models.Currencies{}, nil
+	}
+
+	return sortedCurrencies(snapshot.Currencies), nil
+}
+
+func (s *FileStorage) GetCurrencyHistory(_ context.Context, fromDate string, toDate string) ([]models.HistoryCurrency, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var history []models.HistoryCurrency
+
+	for _, snapshot := range s.snapshots {
+		date := snapshotDate(snapshot)
+
+		if (date < fromDate) || (date > toDate) {
+			continue
+		}
+
+		for _, currency := range sortedCurrencies(snapshot.Currencies).Currencies {
+			history = append(history, models.HistoryCurrency{
+				UpdateDatetimeId: snapshot.UpdateDatetime.Id,
+				RateDate:         date,
+				Currency:         currency,
+			})
+		}
+	}
+
+	return history, nil
+}
+
+// IncrementUsage counts the request in memory. The usage is saved in the
+// file by the periodic flush.
+func (s *FileStorage) IncrementUsage(_ context.Context, keyName string, date string) (models.UsageCount, error) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	if date > s.usageDate {
+		s.usageDate = date
+
+		s.pruneUsage()
+	}
+
+	var (
+		count     models.UsageCount
+		isCounted bool
+		fromDate  = monthStart(date)
+	)
+
+	for i := range s.usage {
+		usage := &s.usage[i]
+
+		if usage.KeyName != keyName {
+			continue
+		}
+
+		if usage.Date == date {
+			usage.Requests++
+			isCounted = true
+		}
+
+		if (usage.Date >= fromDate) && (usage.Date <= date) {
+			count.Month += usage.Requests
+		}
+
+		if usage.Date == date {
+			count.Day = usage.Requests
+		}
+	}
+
+	if !isCounted {
+		s.usage = append(s.usage, models.Usage{KeyName: keyName, Date: date, Requests: 1})
+
+		count.Day = 1
+		count.Month++
+	}
+
+	s.usageVersion++
+
+	return count, nil
+}
+
+// pruneUsage drops the usage, that is older than the retention days
+// before the latest date, that the usage is counted by. The usage of
+// the month of the date is kept for the monthly quotas. Nothing is
+// dropped, unless the retention days are set.
+func (s *FileStorage) pruneUsage() {
+	if s.config.RetentionDays <= 0 {
+		return
+	}
+
+	date, err := time.Parse(models.RateDateLayout, s.usageDate)
+	if err != nil {
+		return
+	}
+
+	before := date.AddDate(0, 0, -s.config.RetentionDays).Format(models.RateDateLayout)
+
+	if month := monthStart(s.usageDate); before > month {
+		before = month
+	}
+
+	kept := s.usage[:0]
+
+	for _, usage := range s.usage {
+		if usage.Date >= before {
+			kept = append(kept, usage)
+		}
+	}
+
+	if len(kept) < len(s.usage) {
+		s.usageVersion++
+	}
+
+	s.usage = kept
+}
+
+// flushUsagePeriodically saves the usage once in the flush interval,
+// until the flush is stopped. The failed saves are logged and retried
+// by the next flush.
+func (s *FileStorage) flushUsagePeriodically(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.config.UsageFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := s.flushUsage(); err != nil {
+				zerolog.Ctx(context.Background()).Error().Err(err).Msg("could not flush usage")
+			}
+		}
+	}
+}
+
+// flushUsage saves the usage of all keys in the file, unless it is saved
+// already. The usage is marked as saved only after the save succeeds, so
+// the failed one is repeated.
+func (s *FileStorage) flushUsage() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.usageMu.Lock()
+
+	if s.usageVersion == s.savedUsageVersion {
+		s.usageMu.Unlock()
+
+		return nil
+	}
+
+	version := s.usageVersion
+	data, err := json.Marshal(s.usage)
+
+	s.usageMu.Unlock()
+
+	if err != nil {
+		return errlib.Wrap(err, "could not encode usage")
+	}
+
+	if err = s.fsOps.SaveUsage(data); err != nil {
+		return errlib.Wrap(err, "could not save usage")
+	}
+
+	s.usageMu.Lock()
+	s.savedUsageVersion = version
+	s.usageMu.Unlock()
+
+	return nil
+}
+
+func (s *FileStorage) GetUsage(_ context.Context, fromDate string, toDate string) ([]models.Usage, error) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	var usages []models.Usage
+
+	for _, usage := range s.usage {
+		if (usage.Date >= fromDate) && (usage.Date <= toDate) {
+			usages = append(usages, usage)
+		}
+	}
+
+	sortUsages(usages)
+
+	return usages, nil
+}
+
+// InsertAuditEntry records the entry and appends it to the file.
+func (s *FileStorage) InsertAuditEntry(_ context.Context, entry models.AuditEntry) (models.AuditEntry, error) {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	entry.Id = 1
+
+	if len(s.audit) > 0 {
+		entry.Id = s.audit[len(s.audit)-1].Id + 1
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return entry, errlib.Wrap(err, "could not encode audit entry")
+	}
+
+	if err = s.fsOps.AppendAudit(data); err != nil {
+		return entry, errlib.Wrap(err, "could not save audit entry")
+	}
+
+	s.audit = append(s.audit, entry)
+
+	return entry, nil
+}
+
+func (s *FileStorage) GetAuditEntries(_ context.Context, fromDate string, toDate string) ([]models.AuditEntry, error) {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	var entries []models.AuditEntry
+
+	for _, entry := range s.audit {
+		if date := auditDate(entry); (date >= fromDate) && (date <= toDate) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// upsert returns the snapshot of the rate date with the datetime
+// updated, or appends a new one, if there is no such snapshot.
+func (s *FileStorage) upsert(datetime string, rateDate string) *snapshot {
+	if rateDate != "" {
+		for _, snapshot := range s.snapshots {
+			if snapshot.UpdateDatetime.RateDate == rateDate {
+				snapshot.UpdateDatetime.UpdateDatetime = datetime
+
+				return snapshot
+			}
+		}
+	}
+
+	id := 1
+
+	if len(s.snapshots) > 0 {
+		id = s.snapshots[len(s.snapshots)-1].UpdateDatetime.Id + 1
+	}
+
+	snapshot := &snapshot{
+		UpdateDatetime: models.UpdateDatetime{
+			Id:             id,
+			UpdateDatetime: datetime,
+			RateDate:       rateDate,
+		},
+	}
+
+	s.snapshots = append(s.snapshots, snapshot)
+
+	return snapshot
+}
+
+func (s *FileStorage) latest() *snapshot {
+	var latest *snapshot
+
+	for _, snapshot := range s.snapshots {
+		if isLaterSnapshot(snapshot, latest) {
+			latest = snapshot
+		}
+	}
+
+	return latest
+}
+
+func (s *FileStorage) find(updateDatetimeId int) *snapshot {
+	for _, snapshot := range s.snapshots {
+		if snapshot.UpdateDatetime.Id == updateDatetimeId {
+			return snapshot
+		}
+	}
+
+	return nil
+}
+
+func (s *FileStorage) save(snapshot *snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return errlib.Wrap(err, "could not encode snapshot")
+	}
+
+	if err = s.fsOps.SaveSnapshot(snapshot.UpdateDatetime.Id, data); err != nil {
+		return errlib.Wrap(err, "could not save snapshot")
+	}
+
+	return nil
+}
+
+// snapshotDate returns the date, the rates of the snapshot are
+// effective on, or the date of the update, if it is unknown.
+func snapshotDate(snapshot *snapshot) string {
+	if snapshot.UpdateDatetime.RateDate != "" {
+		return snapshot.UpdateDatetime.RateDate
+	}
+
+	if len(snapshot.UpdateDatetime.UpdateDatetime) < len(models.RateDateLayout) {
+		return snapshot.UpdateDatetime.UpdateDatetime
+	}
+
+	return snapshot.UpdateDatetime.UpdateDatetime[:len(models.RateDateLayout)]
+}
+
+// isLaterSnapshot reports, whether the snapshot is of a later rate
+// date than the other one, or of the same date but inserted later.
+func isLaterSnapshot(snapshot *snapshot, other *snapshot) bool {
+	if other == nil {
+		return true
+	}
+
+	date, otherDate := snapshotDate(snapshot), snapshotDate(other)
+
+	if date != otherDate {
+		return date > otherDate
+	}
+
+	return snapshot.UpdateDatetime.Id > other.UpdateDatetime.Id
+}
+
+// sortedCurrencies returns a copy of the currencies, ordered by name,
+// as the database storage returns them.
+func sortedCurrencies(currencies models.Currencies) models.Currencies {
+	sorted := currencies
+	sorted.Currencies = make([]models.Currency, len(currencies.Currencies))
+
+	copy(sorted.Currencies, currencies.Currencies)
+
+	sort.Slice(sorted.Currencies, func(i, j int) bool {
+		return sorted.Currencies[i].Name < sorted.Currencies[j].Name
+	})
+
+	return sorted
+}
+
+// sortUsages orders the usages by the key names and the dates, as the
+// database storage returns them.
+func sortUsages(usages []models.Usage) {
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].KeyName != usages[j].KeyName {
+			return usages[i].KeyName < usages[j].KeyName
+		}
+
+		return usages[i].Date < usages[j].Date
+	})
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/metrics"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/tracing"
+	"github.com/rs/zerolog"
+)
+
+const (
+	resultOk    = "ok"
+	resultError = "error"
+)
+
+// An InstrumentedStorage observes the durations of the operations of
+// the underlying storage and logs the ones, that are slower than the
+// configured threshold. The operations are the spans of the trace of the
+// context, if it has any.
+type InstrumentedStorage struct {
+	Storage
+	config *config.Config
+}
+
+func NewInstrumentedStorage(cfg *config.Config, st Storage) *InstrumentedStorage {
+	return &InstrumentedStorage{
+		Storage: st,
+		config:  cfg,
+	}
+}
+
+func (s *InstrumentedStorage) Ping(ctx context.Context) (err error) {
+	defer s.observe(ctx, "ping", time.Now(), &err)
+
+	return s.Storage.Ping(ctx)
+}
+
+func (s *InstrumentedStorage) Migrate(ctx context.Context) (err error) {
+	defer s.observe(ctx, "migrate", time.Now(), &err)
+
+	return s.Storage.Migrate(ctx)
+}
+
+func (s *InstrumentedStorage) GetLatestUpdateDatetime(ctx context.Context) (_ models.UpdateDatetime, err error) {
+	defer s.observe(ctx, "get_latest_update_datetime", time.Now(), &err)
+
+	return s.Storage.GetLatestUpdateDatetime(ctx)
+}
+
+func (s *InstrumentedStorage) InsertUpdateDatetime(ctx context.Context, datetime string, rateDate string) (_ models.UpdateDatetime, err error) {
+	defer s.observe(ctx, "insert_update_datetime", time.Now(), &err)
+
+	return s.Storage.InsertUpdateDatetime(ctx, datetime, rateDate)
+}
+
+func (s *InstrumentedStorage) GetUpdateDatetimes(ctx context.Context, fromDate string, toDate string) (_ []models.UpdateDatetime, err error) {
+	defer s.observe(ctx, "get_update_datetimes", time.Now(), &err)
+
+	return s.Storage.GetUpdateDatetimes(ctx, fromDate, toDate)
+}
+
+func (s *InstrumentedStorage) PruneBefore(ctx context.Context, date string) (_ int64, err error) {
+	defer s.observe(ctx, "prune_before", time.Now(), &err)
+
+	return s.Storage.PruneBefore(ctx, date)
+}
+
+func (s *InstrumentedStorage) InsertCurrencies(ctx context.Context, currencies models.Currencies, updateDatetimeId int) (err error) {
+	defer s.observe(ctx, "insert_currencies", time.Now(), &err)
+
+	return s.Storage.InsertCurrencies(ctx, currencies, updateDatetimeId)
+}
+
+func (s *InstrumentedStorage) InsertSnapshot(ctx context.Context, datetime string, currencies models.Currencies) (_ models.UpdateDatetime, err error) {
+	defer s.observe(ctx, "insert_snapshot", time.Now(), &err)
+
+	return s.Storage.InsertSnapshot(ctx, datetime, currencies)
+}
+
+func (s *InstrumentedStorage) GetLatestCurrencies(ctx context.Context, updateDatetimeId int) (_ models.Currencies, err error) {
+	defer s.observe(ctx, "get_latest_currencies", time.Now(), &err)
+
+	return s.Storage.GetLatestCurrencies(ctx, updateDatetimeId)
+}
+
+func (s *InstrumentedStorage) GetCurrencyHistory(ctx context.Context, fromDate string, toDate string) (_ []models.HistoryCurrency, err error) {
+	defer s.observe(ctx, "get_currency_history", time.Now(), &err)
+
+	return s.Storage.GetCurrencyHistory(ctx, fromDate, toDate)
+}
+
+func (s *InstrumentedStorage) NotifyUpdate(ctx context.Context, sender string) error {
+	notifier, ok := s.Storage.(Notifier)
+	if !ok {
+		return ErrNoNotifications
+	}
+
+	return notifier.NotifyUpdate(ctx, sender)
+}
+
+func (s *InstrumentedStorage) ListenUpdates(ctx context.Context, fn func(sender string)) error {
+	notifier, ok := s.Storage.(Notifier)
+	if !ok {
+		return ErrNoNotifications
+	}
+
+	return notifier.ListenUpdates(ctx, fn)
+}
+
+func (s *InstrumentedStorage) IncrementUsage(ctx context.Context, keyName string, date string) (_ models.UsageCount, err error) {
+	defer s.observe(ctx, "increment_usage", time.Now(), &err)
+
+	tracker, ok := s.Storage.(UsageTracker)
+	if !ok {
+		return models.UsageCount{}, ErrNoUsage
+	}
+
+	return tracker.IncrementUsage(ctx, keyName, date)
+}
+
+func (s *InstrumentedStorage) GetUsage(ctx context.Context, fromDate string, toDate string) (_ []models.Usage, err error) {
+	defer s.observe(ctx, "get_usage", time.Now(), &err)
+
+	tracker, ok := s.Storage.(UsageTracker)
+	if !ok {
+		return nil, ErrNoUsage
+	}
+
+	return tracker.GetUsage(ctx, fromDate, toDate)
+}
+
+func (s *InstrumentedStorage) InsertAuditEntry(ctx context.Context, entry models.AuditEntry) (_ models.AuditEntry, err error) {
+	defer s.observe(ctx, "insert_audit_entry", time.Now(), &err)
+
+	auditor, ok := s.Storage.(Auditor)
+	if !ok {
+		return entry, ErrNoAudit
+	}
+
+	return auditor.InsertAuditEntry(ctx, entry)
+}
+
+func (s *InstrumentedStorage) GetAuditEntries(ctx context.Context, fromDate string, toDate string) (_ []models.AuditEntry, err error) {
+	defer s.observe(ctx, "get_audit_entries", time.Now(), &err)
+
+	auditor, ok := s.Storage.(Auditor)
+	if !ok {
+		return nil, ErrNoAudit
+	}
+
+	return auditor.GetAuditEntries(ctx, fromDate, toDate)
+}
+
+// observe records the duration of the operation, that has started at
+// the time, and logs it, if it is slow. The error is read through the
+// pointer, as it is known only after the operation returns.
+func (s *InstrumentedStorage) observe(ctx context.Context, operation string, start time.Time, err *error) {
+	duration := time.Since(start)
+
+	tracing.Record(ctx, "storage "+operation, start, *err)
+
+	result := resultOk
+
+	if *err != nil {
+		result = resultError
+	}
+
+	metrics.StorageOperationDuration.WithLabelValues(operation, result).Observe(duration.Seconds())
+
+	if (s.config.DbSlowQueryThreshold > 0) && (duration >= s.config.DbSlowQueryThreshold) {
+		zerolog.Ctx(ctx).Warn().
+			Str("operation", operation).
+			Dur("duration", duration).
+			Msg("slow storage operation")
+	}
+}
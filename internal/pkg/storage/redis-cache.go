@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+const (
+	keyGeneration           = "generation"
+	keyLatestUpdateDatetime = "latest-update-datetime"
+	keyUpdateDatetimes      = "update-datetimes"
+	keyCurrencies           = "currencies"
+	keyCurrencyHistory      = "currency-history"
+)
+
+// A RedisCache is the storage, that keeps the results of the reads of
+// the underlying storage in Redis, so they are shared by all instances
+// of the application. The cached results are invalidated on each write
+// by incrementing the generation, which is a part of every cache key.
+// The errors of Redis are logged and do not fail the operations.
+type RedisCache struct {
+	Storage
+	config *config.Config
+	client *redis.Client
+}
+
+func NewRedisCache(cfg *config.Config, st Storage) *RedisCache {
+	return &RedisCache{
+		Storage: st,
+		config:  cfg,
+		client: redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddress,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDb,
+		}),
+	}
+}
+
+func (c *RedisCache) Connect() error {
+	if err := c.Storage.Connect(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("could not connect to redis cache")
+	}
+
+	return nil
+}
+
+func (c *RedisCache) Disconnect() error {
+	if err := c.client.Close(); err != nil {
+		zerolog.Ctx(context.Background()).Error().Err(err).Msg("could not close redis cache connection")
+	}
+
+	return c.Storage.Disconnect()
+}
+
+func (c *RedisCache) GetLatestUpdateDatetime(ctx context.Context) (models.UpdateDatetime, error) {
+	return cached(ctx, c, keyLatestUpdateDatetime, func() (models.UpdateDatetime, error) {
+		return c.Storage.GetLatestUpdateDatetime(ctx)
+	})
+}
+
+func (c *RedisCache) GetUpdateDatetimes(ctx context.Context, fromDate string, toDate string) ([]models.UpdateDatetime, error) {
+	return cached(ctx, c, keyUpdateDatetimes+":"+fromDate+":"+toDate, func() ([]models.UpdateDatetime, error) {
+		return c.Storage.GetUpdateDatetimes(ctx, fromDate, toDate)
+	})
+}
+
+func (c *RedisCache) GetLatestCurrencies(ctx context.Context, updateDatetimeId int) (models.Currencies, error) {
+	return cached(ctx, c, keyCurrencies+":"+strconv.Itoa(updateDatetimeId), func() (models.Currencies, error) {
+		return c.Storage.GetLatestCurrencies(ctx, updateDatetimeId)
+	})
+}
+
+func (c *RedisCache) GetCurrencyHistory(ctx context.Context, fromDate string, toDate string) ([]models.HistoryCurrency, error) {
+	return cached(ctx, c, keyCurrencyHistory+":"+fromDate+":"+toDate, func() ([]models.HistoryCurrency, error) {
+		return c.Storage.GetCurrencyHistory(ctx, fromDate, toDate)
+	})
+}
+
+func (c *RedisCache) InsertUpdateDatetime(ctx context.Context, datetime string, rateDate string) (models.UpdateDatetime, error) {
+	defer c.invalidate(ctx)
+
+	return c.Storage.InsertUpdateDatetime(ctx, datetime, rateDate)
+}
+
+func (c *RedisCache) InsertCurrencies(ctx context.Context, currencies models.Currencies, updateDatetimeId int) error {
+	defer c.invalidate(ctx)
+
+	return c.Storage.InsertCurrencies(ctx, currencies, updateDatetimeId)
+}
+
+func (c *RedisCache) InsertSnapshot(ctx context.Context, datetime string, currencies models.Currencies) (models.UpdateDatetime, error) {
+	defer c.invalidate(ctx)
+
+	return c.Storage.InsertSnapshot(ctx, datetime, currencies)
+}
+
+func (c *RedisCache) PruneBefore(ctx context.Context, date string) (int64, error) {
+	defer c.invalidate(ctx)
+
+	return c.Storage.PruneBefore(ctx, date)
+}
+
+func (c *RedisCache) NotifyUpdate(ctx context.Context, sender string) error {
+	notifier, ok := c.Storage.(Notifier)
+	if !ok {
+		return ErrNoNotifications
+	}
+
+	return notifier.NotifyUpdate(ctx, sender)
+}
+
+func (c *RedisCache) ListenUpdates(ctx context.Context, fn func(sender string)) error {
+	notifier, ok := c.Storage.(Notifier)
+	if !ok {
+		return ErrNoNotifications
+	}
+
+	return notifier.ListenUpdates(ctx, fn)
+}
+
+// IncrementUsage counts the request in the underlying storage, as the
+// counts must be exact.
+func (c *RedisCache) IncrementUsage(ctx context.Context, keyName string, date string) (models.UsageCount, error) {
+	tracker, ok := c.Storage.(UsageTracker)
+	if !ok {
+		return models.UsageCount{}, ErrNoUsage
+	}
+
+	return tracker.IncrementUsage(ctx, keyName, date)
+}
+
+func (c *RedisCache) GetUsage(ctx context.Context, fromDate string, toDate string) ([]models.Usage, error) {
+	tracker, ok := c.Storage.(UsageTracker)
+	if !ok {
+		return nil, ErrNoUsage
+	}
+
+	return tracker.GetUsage(ctx, fromDate, toDate)
+}
+
+// InsertAuditEntry records the entry in the underlying storage, as the
+// entries are not cached.
+func (c *RedisCache) InsertAuditEntry(ctx context.Context, entry models.AuditEntry) (models.AuditEntry, error) {
+	auditor, ok := c.Storage.(Auditor)
+	if !ok {
+		return entry, ErrNoAudit
+	}
+
+	return auditor.InsertAuditEntry(ctx, entry)
+}
+
+func (c *RedisCache) GetAuditEntries(ctx context.Context, fromDate string, toDate string) ([]models.AuditEntry, error) {
+	auditor, ok := c.Storage.(Auditor)
+	if !ok {
+		return nil, ErrNoAudit
+	}
+
+	return auditor.GetAuditEntries(ctx, fromDate, toDate)
+}
+
+// invalidate makes all cached results stale for all instances.
+func (c *RedisCache) invalidate(ctx context.Context) {
+	if err := c.client.Incr(ctx, c.key(keyGeneration)).Err(); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("could not invalidate redis cache")
+	}
+}
+
+func (c *RedisCache) key(name string) string {
+	return c.config.RedisKeyPrefix + ":" + name
+}
+
+func (c *RedisCache) generation(ctx context.Context) (string, error) {
+	generation, err := c.client.Get(ctx, c.key(keyGeneration)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "0", nil
+	}
+
+	return generation, err
+}
+
+// cached returns the cached result of the key, or loads it with the
+// function and caches it, if there is no such result.
+func cached[T any](ctx context.Context, c *RedisCache, name string, load func() (T, error)) (T, error) {
+	generation, err := c.generation(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("could not get redis cache generation")
+
+		return load()
+	}
+
+	key := c.key(generation + ":" + name)
+
+	var result T
+
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err == nil {
+		if err = json.Unmarshal(data, &result); err == nil {
+			return result, nil
+		}
+	}
+
+	if !errors.Is(err, redis.Nil) {
+		zerolog.Ctx(ctx).Error().Err(errlib.Wrap(err, "key "+key)).Msg("could not get redis cache value")
+	}
+
+	if result, err = load(); err != nil {
+		return result, err
+	}
+
+	if data, err = json.Marshal(result); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("could not encode redis cache value")
+
+		return result, nil
+	}
+
+	if err = c.client.Set(ctx, key, data, c.config.RedisCacheTtl).Err(); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("could not set redis cache value")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,14 @@
+//go:build integration && sqlite
+
+package storage_test
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// The SQLite database is created in the temporary directory, so the
+// test needs no database to be given.
+func TestSqliteStorage(t *testing.T) {
+	testDbStorage(t, "sqlite://"+filepath.Join(t.TempDir(), "currency_storage.db"))
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+)
+
+// ErrNoNotifications is returned, when the storage can not notify
+// about updates.
+var ErrNoNotifications = errors.New("storage does not support notifications")
+
+// ErrNoUpdate is returned, when there is no update with the requested
+// id in the storage.
+var ErrNoUpdate = errors.New("no such update")
+
+// ErrNoUsage is returned, when the storage can not track the usage of
+// the API keys.
+var ErrNoUsage = errors.New("storage does not support usage tracking")
+
+// ErrNoAudit is returned, when the storage can not record the actions
+// of the admins.
+var ErrNoAudit = errors.New("storage does not support audit")
+
+// A Storage keeps currency snapshots along with the datetimes of their
+// updates. The application depends on the interface only, so storage
+// backends may be swapped or mocked. Operations are limited by the
+// context and by the timeouts of the backend.
+type Storage interface {
+	Connect() error
+	Disconnect() error
+	Ping(ctx context.Context) error
+	Migrate(ctx context.Context) error
+
+	GetLatestUpdateDatetime(ctx context.Context) (models.UpdateDatetime, error)
+	InsertUpdateDatetime(ctx context.Context, datetime string, rateDate string) (models.UpdateDatetime, error)
+	GetUpdateDatetimes(ctx context.Context, fromDate string, toDate string) ([]models.UpdateDatetime, error)
+	PruneBefore(ctx context.Context, date string) (int64, error)
+
+	InsertCurrencies(ctx context.Context, currencies models.Currencies, updateDatetimeId int) error
+	InsertSnapshot(ctx context.Context, datetime string, currencies models.Currencies) (models.UpdateDatetime, error)
+	GetLatestCurrencies(ctx context.Context, updateDatetimeId int) (models.Currencies, error)
+	GetCurrencyHistory(ctx context.Context, fromDate string, toDate string) ([]models.HistoryCurrency, error)
+}
+
+// A Notifier tells the instances of the application, that share the
+// storage, about updates of it. The sender identifies the instance,
+// that made the update.
+type Notifier interface {
+	NotifyUpdate(ctx context.Context, sender string) error
+	ListenUpdates(ctx context.Context, fn func(sender string)) error
+}
+
+// A UsageTracker counts the requests, that are made with the API keys,
+// by the dates.
+type UsageTracker interface {
+	// IncrementUsage counts the request, that is made with the key on
+	// the date, and returns the numbers of the requests of the key on
+	// the date and in the month of it up to the date.
+	IncrementUsage(ctx context.Context, keyName string, date string) (models.UsageCount, error)
+	GetUsage(ctx context.Context, fromDate string, toDate string) ([]models.Usage, error)
+}
+
+// An Auditor records the actions of the admins. The entries are never
+// changed or deleted.
+type Auditor interface {
+	// InsertAuditEntry records the entry and returns it with the id.
+	InsertAuditEntry(ctx context.Context, entry models.AuditEntry) (models.AuditEntry, error)
+	// GetAuditEntries returns the entries, that are created on the
+	// dates of UTC from the first date to the last one inclusive, in
+	// the order of creation.
+	GetAuditEntries(ctx context.Context, fromDate string, toDate string) ([]models.AuditEntry, error)
+}
+
+// auditDate returns the date of UTC, that the entry is created on.
+func auditDate(entry models.AuditEntry) string {
+	if len(entry.CreatedAt) < len(models.RateDateLayout) {
+		return entry.CreatedAt
+	}
+
+	return entry.CreatedAt[:len(models.RateDateLayout)]
+}
+
+// monthStart returns the first date of the month of the date.
+func monthStart(date string) string {
+	if len(date) < len(models.RateDateLayout) {
+		return date
+	}
+
+	return date[:len("2006-01-")] + "01"
+}
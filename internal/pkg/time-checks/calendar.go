@@ -0,0 +1,119 @@
+package timechecks
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+)
+
+// IsPublishingDay reports, whether the source publishes new rates on
+// the day of the datetime, that is neither a non-publishing weekday,
+// nor a holiday.
+func (t *TimeChecks) IsPublishingDay(datetime time.Time) bool {
+	weekday := datetime.Weekday().String()
+
+	for _, nonPublishingWeekday := range t.config.NonPublishingWeekdays {
+		if strings.EqualFold(strings.TrimSpace(nonPublishingWeekday), weekday) {
+			return false
+		}
+	}
+
+	date := datetime.Format(models.RateDateLayout)
+
+	for _, holiday := range t.config.Holidays {
+		if strings.TrimSpace(holiday) == date {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PublishingAge returns the time passed from the datetime till now
+// without the non-publishing days, so the rates, that are got before
+// the weekend, do not look outdated on Sunday.
+func (t *TimeChecks) PublishingAge(datetime time.Time, now time.Time) (time.Duration, error) {
+	location, err := t.location()
+	if err != nil {
+		return 0, err
+	}
+
+	datetime, now = datetime.In(location), now.In(location)
+
+	age := now.Sub(datetime)
+
+	year, month, day := datetime.Date()
+
+	for dayStart := time.Date(year, month, day, 0, 0, 0, 0, location); dayStart.Before(now); {
+		dayEnd := dayStart.AddDate(0, 0, 1)
+
+		if !t.IsPublishingDay(dayStart) {
+			from, to := dayStart, dayEnd
+
+			if from.Before(datetime) {
+				from = datetime
+			}
+
+			if to.After(now) {
+				to = now
+			}
+
+			age -= to.Sub(from)
+		}
+
+		dayStart = dayEnd
+	}
+
+	return age, nil
+}
+
+// MissingRateDates returns the rate dates from the first date to the
+// last one inclusive, that the rates are expected on, but are not among
+// the stored ones, in ascending order. The rates, that are published on
+// a publishing day, are expected on the next day, and are effective up
+// to the next publishing day, so the rates of a later date within that
+// period are stored in place of them, e.g. before the holidays.
+func (t *TimeChecks) MissingRateDates(from time.Time, to time.Time, storedRateDates []string) []string {
+	stored := make(map[string]struct{}, len(storedRateDates))
+
+	for _, rateDate := range storedRateDates {
+		stored[rateDate] = struct{}{}
+	}
+
+	var missing []string
+
+	for day := from.AddDate(0, 0, -1); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !t.IsPublishingDay(day) {
+			continue
+		}
+
+		expected := day.AddDate(0, 0, 1)
+
+		if !t.isAnyStored(stored, expected) {
+			missing = append(missing, expected.Format(models.RateDateLayout))
+		}
+	}
+
+	return missing
+}
+
+// isAnyStored reports, whether any of the dates from the one, the rates
+// are expected on, up to the next publishing day inclusive is stored.
+func (t *TimeChecks) isAnyStored(stored map[string]struct{}, expected time.Time) bool {
+	day := expected
+
+	for i := 0; i < calendarSearchDays; i++ {
+		if _, ok := stored[day.Format(models.RateDateLayout)]; ok {
+			return true
+		}
+
+		if t.IsPublishingDay(day) {
+			return false
+		}
+
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return false
+}
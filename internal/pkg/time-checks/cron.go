@@ -0,0 +1,100 @@
+package timechecks
+
+import (
+	"time"
+
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	cronSearchMinWindow = time.Hour
+	cronSearchMaxWindow = 366 * 24 * time.Hour
+)
+
+// ParseCron parses the cron expression of the update schedule in the
+// standard five-field format, e.g. "30 12 * * MON-FRI".
+func ParseCron(expression string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(expression)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not parse update cron expression")
+	}
+
+	return schedule, nil
+}
+
+// isNeedForUpdateByCron reports, whether the latest update has occured
+// before the latest time of the cron schedule.
+func (t *TimeChecks) isNeedForUpdateByCron(latestUpdateDatetime time.Time) (bool, error) {
+	schedule, err := ParseCron(t.config.UpdateCron)
+	if err != nil {
+		return false, err
+	}
+
+	currentDatetime, err := t.now()
+	if err != nil {
+		return false, err
+	}
+
+	previous, ok := previousActivation(schedule, currentDatetime)
+
+	// The activations on the non-publishing days are skipped.
+	for i := 0; ok && !t.IsPublishingDay(previous) && (i < calendarSearchDays); i++ {
+		previous, ok = previousActivation(schedule, previous.Add(-time.Second))
+	}
+
+	if !ok || !t.IsPublishingDay(previous) {
+		return false, nil
+	}
+
+	return latestUpdateDatetime.Before(previous), nil
+}
+
+func (t *TimeChecks) nextUpdateByCron() (time.Time, error) {
+	schedule, err := ParseCron(t.config.UpdateCron)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// The schedule is evaluated in the timezone of the time given.
+	currentDatetime, err := t.now()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := schedule.Next(currentDatetime)
+
+	// The activations on the non-publishing days are skipped.
+	for i := 0; !next.IsZero() && (i < calendarSearchDays); i++ {
+		if t.IsPublishingDay(next) {
+			return next, nil
+		}
+
+		next = schedule.Next(next)
+	}
+
+	return time.Time{}, ErrNoUpdateTimes
+}
+
+// previousActivation returns the latest time of the schedule, that is
+// not after the time. The schedule can only tell the next times, so the
+// search window before the time is doubled until it contains one.
+func previousActivation(schedule cron.Schedule, now time.Time) (time.Time, bool) {
+	for window := cronSearchMinWindow; window <= cronSearchMaxWindow; window *= 2 {
+		activation := schedule.Next(now.Add(-window))
+		if activation.IsZero() || activation.After(now) {
+			continue
+		}
+
+		for {
+			next := schedule.Next(activation)
+			if next.IsZero() || next.After(now) {
+				return activation, true
+			}
+
+			activation = next
+		}
+	}
+
+	return time.Time{}, false
+}
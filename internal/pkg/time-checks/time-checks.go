@@ -1,27 +1,44 @@
 package timechecks
 
 import (
+	"errors"
+	"sort"
+	"strings"
 	"time"
 
+	// The image has no timezone database, so it is embedded.
+	_ "time/tzdata"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/clock"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
 	"github.com/mrumyantsev/go-errlib"
 )
 
 const (
-	dayYesterday = -1
-	dayToday     = 0
-	dayTomorrow  = 1
+	dayToday = 0
+
+	// calendarSearchDays is how far the update datetimes are searched
+	// for across the non-publishing days.
+	calendarSearchDays = 366
 )
 
+var ErrNoUpdateTimes = errors.New("no update times specified")
+
 type TimeChecks struct {
 	config *config.Config
+	clock  clock.Clock
 }
 
-func New(cfg *config.Config) *TimeChecks {
-	return &TimeChecks{config: cfg}
+func New(cfg *config.Config, cl clock.Clock) *TimeChecks {
+	return &TimeChecks{
+		config: cfg,
+		clock:  cl,
+	}
 }
 
+// IsNeedForUpdateDb reports, whether the latest update has occured
+// before the latest of the update times, that has already come.
 func (t *TimeChecks) IsNeedForUpdateDb(updateDatetime *models.UpdateDatetime) (bool, error) {
 	latestUpdateDatetime, err := time.Parse(
 		time.RFC3339,
@@ -31,76 +48,150 @@ func (t *TimeChecks) IsNeedForUpdateDb(updateDatetime *models.UpdateDatetime) (b
 		return false, errlib.Wrap(err, "could not parse update time from db")
 	}
 
-	todayUpdateDatetime, err := t.DayUpdateDatetime(dayToday)
+	if t.config.UpdateCron != "" {
+		return t.isNeedForUpdateByCron(latestUpdateDatetime)
+	}
+
+	previousUpdateDatetime, err := t.PreviousUpdateDatetime()
 	if err != nil {
-		return false, errlib.Wrap(err, "could not get today update datetime")
+		return false, errlib.Wrap(err, "could not get previous update datetime")
 	}
 
-	yesterdayUpdateDatetime, err := t.DayUpdateDatetime(dayYesterday)
+	return latestUpdateDatetime.Before(previousUpdateDatetime), nil
+}
+
+func (t *TimeChecks) TimeToNextUpdate() (time.Duration, error) {
+	nextUpdate, err := t.NextUpdate()
 	if err != nil {
-		return false, errlib.Wrap(err, "could not get yesterday update datetime")
+		return 0, err
 	}
 
-	currentDatetime := time.Now()
+	return nextUpdate.Sub(t.clock.Now()), nil
+}
+
+// NextUpdate returns the datetime of the next update by the cron
+// schedule or by the update times.
+func (t *TimeChecks) NextUpdate() (time.Time, error) {
+	if t.config.UpdateCron != "" {
+		return t.nextUpdateByCron()
+	}
 
-	isNeedUpdate := !(latestUpdateDatetime.After(todayUpdateDatetime) ||
-		((latestUpdateDatetime.After(yesterdayUpdateDatetime) &&
-			latestUpdateDatetime.Before(todayUpdateDatetime)) &&
-			currentDatetime.Before(todayUpdateDatetime)))
+	nextUpdateDatetime, err := t.NextUpdateDatetime()
+	if err != nil {
+		return time.Time{}, errlib.Wrap(err, "could not get next update datetime")
+	}
 
-	return isNeedUpdate, nil
+	return nextUpdateDatetime, nil
 }
 
-func (t *TimeChecks) TimeToNextUpdate() (time.Duration, error) {
-	currentDatetime := time.Now()
-	day := dayToday
-
-	var (
-		todayUpdateDatetime time.Time
-		nextUpdateDatetime  time.Time
-		timeToNextUpdate    time.Duration
-		err                 error
-	)
+// NextUpdateDatetime returns the earliest of the update datetimes,
+// that is after now, skipping the non-publishing days.
+func (t *TimeChecks) NextUpdateDatetime() (time.Time, error) {
+	currentDatetime, err := t.now()
+	if err != nil {
+		return time.Time{}, err
+	}
 
-	todayUpdateDatetime, err = t.DayUpdateDatetime(dayToday)
+	for day := dayToday; day <= calendarSearchDays; day++ {
+		updateDatetimes, err := t.DayUpdateDatetimes(day)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		for _, updateDatetime := range updateDatetimes {
+			if updateDatetime.After(currentDatetime) {
+				return updateDatetime, nil
+			}
+		}
+	}
+
+	return time.Time{}, ErrNoUpdateTimes
+}
+
+// PreviousUpdateDatetime returns the latest of the update datetimes,
+// that is not after now, skipping the non-publishing days.
+func (t *TimeChecks) PreviousUpdateDatetime() (time.Time, error) {
+	currentDatetime, err := t.now()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for day := dayToday; day >= -calendarSearchDays; day-- {
+		updateDatetimes, err := t.DayUpdateDatetimes(day)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		for i := len(updateDatetimes) - 1; i >= 0; i-- {
+			if !updateDatetimes[i].After(currentDatetime) {
+				return updateDatetimes[i], nil
+			}
+		}
+	}
+
+	return time.Time{}, ErrNoUpdateTimes
+}
+
+// DayUpdateDatetimes returns the update datetimes of the day, that is
+// the offset from today, in ascending order, or none, if the day is
+// a non-publishing one. The days and the times are of the update
+// timezone.
+func (t *TimeChecks) DayUpdateDatetimes(todayOffset int) ([]time.Time, error) {
+	currentDatetime, err := t.now()
 	if err != nil {
-		return timeToNextUpdate, errlib.Wrap(err, "could not get today update datetime")
+		return nil, err
 	}
 
-	if currentDatetime.After(todayUpdateDatetime) {
-		day = dayTomorrow
+	todayYear, todayMonth, todayDay := currentDatetime.Date()
+
+	day := time.Date(todayYear, todayMonth, todayDay+todayOffset, 0, 0, 0, 0, currentDatetime.Location())
+
+	if !t.IsPublishingDay(day) {
+		return nil, nil
 	}
 
-	if nextUpdateDatetime, err = t.DayUpdateDatetime(day); err != nil {
-		return timeToNextUpdate, errlib.Wrap(err, "could not get next update datetime")
+	updateDatetimes := make([]time.Time, 0, len(t.config.TimesWhenNeedToUpdateCurrency))
+
+	for _, rawUpdateTime := range t.config.TimesWhenNeedToUpdateCurrency {
+		updateTime, err := time.Parse(time.TimeOnly, strings.TrimSpace(rawUpdateTime))
+		if err != nil {
+			return nil, errlib.Wrap(err, "could not parse update time from config")
+		}
+
+		updateDatetimes = append(updateDatetimes, time.Date(
+			todayYear,
+			todayMonth,
+			todayDay+todayOffset,
+			updateTime.Hour(),
+			updateTime.Minute(),
+			updateTime.Second(),
+			0, // drop nanoseconds
+			currentDatetime.Location(),
+		))
 	}
 
-	timeToNextUpdate = time.Since(nextUpdateDatetime).Abs()
+	sort.Slice(updateDatetimes, func(i, j int) bool {
+		return updateDatetimes[i].Before(updateDatetimes[j])
+	})
 
-	return timeToNextUpdate, nil
+	return updateDatetimes, nil
 }
 
-func (t *TimeChecks) DayUpdateDatetime(todayOffset int) (time.Time, error) {
-	updateTime, err := time.Parse(
-		time.TimeOnly,
-		t.config.TimeWhenNeedToUpdateCurrency,
-	)
+// now returns the current time in the update timezone.
+func (t *TimeChecks) now() (time.Time, error) {
+	location, err := t.location()
 	if err != nil {
-		return updateTime, errlib.Wrap(err, "could not parse update time from config")
+		return time.Time{}, err
 	}
 
-	todayYear, todayMonth, todayDay := time.Now().Date()
+	return t.clock.Now().In(location), nil
+}
 
-	todayUpdateDatetime := time.Date(
-		todayYear,
-		todayMonth,
-		todayDay+todayOffset,
-		updateTime.Hour(),
-		updateTime.Minute(),
-		updateTime.Second(),
-		0, // drop nanoseconds
-		time.Now().Location(),
-	)
+func (t *TimeChecks) location() (*time.Location, error) {
+	location, err := time.LoadLocation(t.config.UpdateTimezone)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not load update timezone")
+	}
 
-	return todayUpdateDatetime, nil
+	return location, nil
 }
@@ -0,0 +1,246 @@
+// Package tracing continues the traces of the calling services by the
+// W3C Trace Context, so the requests to the service and the operations
+// of the storage, that they make, show up in the distributed traces.
+// The spans of the sampled traces are logged, as the logs are collected
+// anyway.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// The headers of the trace context of the requests and of the
+// responses.
+const (
+	HeaderTraceparent   = "traceparent"
+	HeaderTracestate    = "tracestate"
+	HeaderTraceresponse = "traceresponse"
+)
+
+const (
+	traceparentLength = 55
+
+	// maxTracestateLength is the length of the trace state, that the
+	// vendors must propagate at least. The longer ones are dropped.
+	maxTracestateLength = 512
+
+	flagSampled = 0x01
+)
+
+// A SpanContext is the trace along with the span in it, that is the
+// parent of the next spans.
+type SpanContext struct {
+	TraceId [16]byte
+	SpanId  [8]byte
+	Flags   byte
+	State   string
+}
+
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&flagSampled != 0
+}
+
+// Traceparent returns the value of the traceparent header of the span.
+func (sc SpanContext) Traceparent() string {
+	return "00-" + hex.EncodeToString(sc.TraceId[:]) + "-" + hex.EncodeToString(sc.SpanId[:]) + "-" +
+		hex.EncodeToString([]byte{sc.Flags})
+}
+
+func (sc SpanContext) TraceIdString() string {
+	return hex.EncodeToString(sc.TraceId[:])
+}
+
+func (sc SpanContext) SpanIdString() string {
+	return hex.EncodeToString(sc.SpanId[:])
+}
+
+// Extract returns the trace context of the headers. It returns false, if
+// there is none or the traceparent header is invalid, in which case the
+// trace state is ignored too.
+func Extract(header http.Header) (SpanContext, bool) {
+	sc, ok := ParseTraceparent(header.Get(HeaderTraceparent))
+	if !ok {
+		return SpanContext{}, false
+	}
+
+	// The trace state may be split into several headers.
+	state := strings.Join(header.Values(HeaderTracestate), ",")
+
+	if len(state) <= maxTracestateLength {
+		sc.State = state
+	}
+
+	return sc, true
+}
+
+// Inject sets the headers of the trace context of the span in the
+// context, e.g. of the request to the other service. It does nothing, if
+// there is no span.
+func Inject(ctx context.Context, header http.Header) {
+	sc, ok := FromContext(ctx)
+	if !ok {
+		return
+	}
+
+	header.Set(HeaderTraceparent, sc.Traceparent())
+
+	if sc.State != "" {
+		header.Set(HeaderTracestate, sc.State)
+	}
+}
+
+// ParseTraceparent parses the value of the traceparent header. The
+// values of the future versions are parsed by the fields of the current
+// one.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	if (len(value) < traceparentLength) || ((len(value) > traceparentLength) && (value[traceparentLength] != '-')) {
+		return SpanContext{}, false
+	}
+
+	parts := strings.Split(value[:traceparentLength], "-")
+	if len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	version, ok := decodeHex(parts[0], 1)
+	if !ok || (version[0] == 0xff) || ((version[0] == 0) && (len(value) != traceparentLength)) {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+
+	traceId, ok := decodeHex(parts[1], len(sc.TraceId))
+	if !ok || isZero(traceId) {
+		return SpanContext{}, false
+	}
+
+	spanId, ok := decodeHex(parts[2], len(sc.SpanId))
+	if !ok || isZero(spanId) {
+		return SpanContext{}, false
+	}
+
+	flags, ok := decodeHex(parts[3], 1)
+	if !ok {
+		return SpanContext{}, false
+	}
+
+	copy(sc.TraceId[:], traceId)
+	copy(sc.SpanId[:], spanId)
+	sc.Flags = flags[0]
+
+	return sc, true
+}
+
+type spanContextKey struct{}
+
+// WithSpanContext returns the context of the span, that is the parent of
+// the next spans.
+func WithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// FromContext returns the span of the context, if there is any.
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+
+	return sc, ok
+}
+
+// A Span is the operation in the trace.
+type Span struct {
+	SpanContext
+	Name     string
+	ParentId [8]byte
+	Start    time.Time
+}
+
+// Start starts the span of the operation as the child of the span of the
+// context or as the root of the new trace, that is not sampled, if the
+// context has none. The returned context has the started span.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	span := newSpan(ctx, name, time.Now())
+
+	return WithSpanContext(ctx, span.SpanContext), span
+}
+
+// Record logs the span of the operation, that has already ended, as the
+// child of the span of the context. It does nothing, if there is no
+// span, e.g. in the background.
+func Record(ctx context.Context, name string, start time.Time, err error) {
+	if _, ok := FromContext(ctx); !ok {
+		return
+	}
+
+	newSpan(ctx, name, start).End(ctx, err)
+}
+
+// End logs the span, if the trace is sampled, along with the error of
+// the operation, if any.
+func (s *Span) End(ctx context.Context, err error) {
+	if !s.IsSampled() {
+		return
+	}
+
+	// The span is nested, as the logger of the context may have the ids
+	// of the span of the request already.
+	span := zerolog.Dict().
+		Str("traceId", s.TraceIdString()).
+		Str("id", s.SpanIdString()).
+		Str("name", s.Name).
+		Time("start", s.Start).
+		Dur("duration", time.Since(s.Start))
+
+	if s.ParentId != [8]byte{} {
+		span = span.Str("parentId", hex.EncodeToString(s.ParentId[:]))
+	}
+
+	if err != nil {
+		span = span.Str("error", err.Error())
+	}
+
+	zerolog.Ctx(ctx).Info().Dict("span", span).Msg("span ended")
+}
+
+func newSpan(ctx context.Context, name string, start time.Time) *Span {
+	span := &Span{Name: name, Start: start}
+
+	if parent, ok := FromContext(ctx); ok {
+		span.SpanContext = parent
+		span.ParentId = parent.SpanId
+	} else {
+		_, _ = rand.Read(span.TraceId[:])
+	}
+
+	_, _ = rand.Read(span.SpanId[:])
+
+	return span
+}
+
+// decodeHex decodes the field of the length in bytes, that must be in
+// lower case.
+func decodeHex(field string, length int) ([]byte, bool) {
+	if (len(field) != length*2) || (strings.ToLower(field) != field) {
+		return nil, false
+	}
+
+	data, err := hex.DecodeString(field)
+
+	return data, err == nil
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
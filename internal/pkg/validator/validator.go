@@ -0,0 +1,176 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/iso4217"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
+)
+
+const (
+	reasonNoCharCode       = "missing char code"
+	reasonNonPositiveValue = "non-positive value"
+	reasonInvalidValue     = "invalid value"
+	reasonZeroMultiplier   = "non-positive multiplier"
+	reasonDuplicateChar    = "duplicate char code"
+	reasonDuplicateNum     = "duplicate num code"
+	reasonUnknownCharCode  = "char code is not in ISO 4217"
+	reasonMismatchNumCode  = "num code does not match ISO 4217 one %03d"
+)
+
+// A RecordError describes a currency record, that did not pass the
+// validation.
+type RecordError struct {
+	Index    int
+	NumCode  int
+	CharCode string
+	Reasons  []string
+}
+
+func (e RecordError) String() string {
+	return fmt.Sprintf(
+		"record %d (num code %d, char code %q): %s",
+		e.Index,
+		e.NumCode,
+		e.CharCode,
+		strings.Join(e.Reasons, ", "),
+	)
+}
+
+// A ValidationError lists every currency record, that did not pass the
+// validation.
+type ValidationError struct {
+	Records []RecordError
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Records))
+
+	for _, record := range e.Records {
+		lines = append(lines, record.String())
+	}
+
+	return fmt.Sprintf(
+		"%d invalid currency records: %s",
+		len(e.Records),
+		strings.Join(lines, "; "),
+	)
+}
+
+// Validate checks every currency of the snapshot. It returns a
+// ValidationError, if any of them has a missing char code, a
+// non-positive value or multiplier, or a code, that is duplicated.
+func Validate(currencies models.Currencies) error {
+	var (
+		records   []RecordError
+		charCodes = make(map[string]bool, len(currencies.Currencies))
+		numCodes  = make(map[int]bool, len(currencies.Currencies))
+	)
+
+	for i, currency := range currencies.Currencies {
+		reasons := validateCurrency(currency)
+
+		if currency.CharCode != "" {
+			if charCodes[currency.CharCode] {
+				reasons = append(reasons, reasonDuplicateChar)
+			}
+
+			charCodes[currency.CharCode] = true
+		}
+
+		if numCodes[currency.NumCode] {
+			reasons = append(reasons, reasonDuplicateNum)
+		}
+
+		numCodes[currency.NumCode] = true
+
+		if len(reasons) > 0 {
+			records = append(records, RecordError{
+				Index:    i,
+				NumCode:  currency.NumCode,
+				CharCode: currency.CharCode,
+				Reasons:  reasons,
+			})
+		}
+	}
+
+	if len(records) > 0 {
+		return &ValidationError{Records: records}
+	}
+
+	return nil
+}
+
+// ValidateIso4217 checks the codes of every currency of the snapshot
+// against the ISO 4217 table. It returns a ValidationError, if the num
+// code of any known char code differs from the standard one, which is
+// likely a change of the format of the source. The char codes, that are
+// not in the table, are not checked, as the table has only the
+// currencies, that the source is known to publish.
+func ValidateIso4217(currencies models.Currencies) error {
+	var records []RecordError
+
+	for i, currency := range currencies.Currencies {
+		info, ok := iso4217.Lookup(currency.CharCode)
+		if !ok || (currency.NumCode == info.NumCode) {
+			continue
+		}
+
+		records = append(records, RecordError{
+			Index:    i,
+			NumCode:  currency.NumCode,
+			CharCode: currency.CharCode,
+			Reasons:  []string{fmt.Sprintf(reasonMismatchNumCode, info.NumCode)},
+		})
+	}
+
+	if len(records) > 0 {
+		return &ValidationError{Records: records}
+	}
+
+	return nil
+}
+
+// UnknownIso4217 returns the records of the currencies of the snapshot,
+// which char codes are not in the ISO 4217 table, e.g. as the source
+// has started to publish the new currency.
+func UnknownIso4217(currencies models.Currencies) []RecordError {
+	var records []RecordError
+
+	for i, currency := range currencies.Currencies {
+		if _, ok := iso4217.Lookup(currency.CharCode); ok {
+			continue
+		}
+
+		records = append(records, RecordError{
+			Index:    i,
+			NumCode:  currency.NumCode,
+			CharCode: currency.CharCode,
+			Reasons:  []string{reasonUnknownCharCode},
+		})
+	}
+
+	return records
+}
+
+func validateCurrency(currency models.Currency) []string {
+	var reasons []string
+
+	if strings.TrimSpace(currency.CharCode) == "" {
+		reasons = append(reasons, reasonNoCharCode)
+	}
+
+	value, err := currency.Value.Decimal()
+	if err != nil {
+		reasons = append(reasons, reasonInvalidValue)
+	} else if !value.IsPositive() {
+		reasons = append(reasons, reasonNonPositiveValue)
+	}
+
+	if currency.Multiplier <= 0 {
+		reasons = append(reasons, reasonZeroMultiplier)
+	}
+
+	return reasons
+}
@@ -0,0 +1,32 @@
+package version
+
+// The build metadata is set by the linker, e.g.
+//
+//	go build -ldflags "-X github.com/mrumyantsev/currency-converter-app/internal/pkg/version.Version=v1.0.0"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+// An Info is the build metadata of the binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+}
+
+// Get returns the build metadata of the binary.
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+	}
+}
+
+// String returns the build metadata in a line, e.g.
+// "v1.0.0 (commit 1a2b3c4, built 2024-01-01T00:00:00Z)".
+func (i Info) String() string {
+	return i.Version + " (commit " + i.Commit + ", built " + i.BuildDate + ")"
+}
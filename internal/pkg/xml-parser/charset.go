@@ -0,0 +1,78 @@
+package xmlparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	charsetWindows1251 = "windows-1251"
+	xmlEncodingAttr    = "encoding="
+	xmlDeclarationEnd  = "?>"
+	peekSize           = 512
+)
+
+// newDecoder creates an XML decoder, that converts the input to UTF-8.
+// The input without the encoding declaration is expected to be UTF-8,
+// but if its beginning is not, the input is considered as windows-1251
+// one.
+func newDecoder(r io.Reader) *xml.Decoder {
+	buffered := bufio.NewReaderSize(r, peekSize)
+
+	head, _ := buffered.Peek(peekSize)
+
+	var input io.Reader = buffered
+
+	if !hasEncodingDeclaration(head) && !isValidUtf8Head(head) {
+		input = charmap.Windows1251.NewDecoder().Reader(buffered)
+	}
+
+	decoder := xml.NewDecoder(input)
+
+	decoder.CharsetReader = charsetReader
+
+	return decoder
+}
+
+// charsetReader converts the input of the charset to UTF-8. The
+// windows-1251 charset, used by CBR, is decoded directly.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(label) {
+	case charsetWindows1251, "cp1251":
+		return charmap.Windows1251.NewDecoder().Reader(input), nil
+	}
+
+	return charset.NewReaderLabel(label, input)
+}
+
+func hasEncodingDeclaration(head []byte) bool {
+	end := bytes.Index(head, []byte(xmlDeclarationEnd))
+	if end < 0 {
+		return false
+	}
+
+	return bytes.Contains(head[:end], []byte(xmlEncodingAttr))
+}
+
+// isValidUtf8Head checks the beginning of the input, which may end
+// with an incomplete rune, cut while peeking.
+func isValidUtf8Head(head []byte) bool {
+	i := len(head) - 1
+
+	for (i > 0) && !utf8.RuneStart(head[i]) {
+		i--
+	}
+
+	if (i >= 0) && !utf8.FullRune(head[i:]) {
+		head = head[:i]
+	}
+
+	return utf8.Valid(head)
+}
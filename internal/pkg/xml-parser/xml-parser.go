@@ -11,12 +11,14 @@ import (
 	"github.com/mrumyantsev/currency-converter-app/internal/pkg/models"
 	"github.com/mrumyantsev/go-errlib"
 	"github.com/rs/zerolog/log"
-
-	"golang.org/x/net/html/charset"
 )
 
 const (
+	rootXmlElement  = "ValCurs"
 	firstXmlElement = "Valute"
+	dateXmlAttr     = "Date"
+	nameXmlAttr     = "name"
+	rateDateLayout  = "02.01.2006"
 )
 
 type XmlParser struct {
@@ -28,45 +30,20 @@ func New(cfg *config.Config) *XmlParser {
 }
 
 func (p *XmlParser) Parse(data []byte) (models.Currencies, error) {
-	startTime := time.Now()
-	buffer := bytes.NewBuffer(data)
-	decoder := xml.NewDecoder(buffer)
-
-	var (
-		currencies models.Currencies
-		err        error
-	)
-
-	decoder.CharsetReader = charset.NewReaderLabel
-
-	if p.config.IsUseMultithreadedParsing {
-		log.Debug().Msg("using multithreaded parsing")
-
-		currencies, err = p.parsedDataMultiThreaded(decoder)
-		if err != nil {
-			return currencies, errlib.Wrap(err, "could not do multithreaded parsing")
-		}
-	} else {
-		log.Debug().Msg("using singlethreaded parsing")
-
-		currencies, err = p.parsedDataSingleThreaded(decoder)
-		if err != nil {
-			return currencies, errlib.Wrap(err, "could not do singlethreaded parsing")
-		}
-	}
-
-	elapsedTime := time.Since(startTime)
-
-	log.Debug().Msg(fmt.Sprintf("parsing time overall: %s", elapsedTime))
-
-	return currencies, nil
+	return p.ParseReader(bytes.NewReader(data))
 }
 
-func (p *XmlParser) parsedDataMultiThreaded(decoder *xml.Decoder) (models.Currencies, error) {
+// ParseReader parses currency data, while reading it from the reader
+// token by token, so the whole document is never held in memory.
+func (p *XmlParser) ParseReader(r io.Reader) (models.Currencies, error) {
+	startTime := time.Now()
+
 	currencies := models.Currencies{
 		Currencies: make([]models.Currency, 0, p.config.InitialCurrenciesCapacity),
 	}
 
+	decoder := newDecoder(r)
+
 	var (
 		currency     models.Currency
 		token        xml.Token
@@ -83,32 +60,64 @@ func (p *XmlParser) parsedDataMultiThreaded(decoder *xml.Decoder) (models.Curren
 
 			return currencies, errlib.Wrap(err, "could not decode xml element")
 		}
-		if token == nil {
-			break
-		}
 
 		if startElement, ok = token.(xml.StartElement); !ok {
 			continue
 		}
 
-		if startElement.Name.Local == firstXmlElement {
-			decoder.DecodeElement(&currency, &startElement)
+		switch startElement.Name.Local {
+		case rootXmlElement:
+			currencies.XMLName = startElement.Name
+
+			if err = setRootAttrs(&currencies, startElement.Attr); err != nil {
+				return currencies, errlib.Wrap(err, "could not read root element attributes")
+			}
+		case firstXmlElement:
+			currency = models.Currency{}
+
+			if err = decoder.DecodeElement(&currency, &startElement); err != nil {
+				return currencies, errlib.Wrap(err, "could not decode currency element")
+			}
 
 			currencies.Currencies = append(currencies.Currencies, currency)
 		}
 	}
 
+	currencies.NormalizeUnitValues()
+
+	elapsedTime := time.Since(startTime)
+
+	log.Debug().Msg(fmt.Sprintf("parsing time overall: %s", elapsedTime))
+
 	return currencies, nil
 }
 
-func (p *XmlParser) parsedDataSingleThreaded(decoder *xml.Decoder) (models.Currencies, error) {
-	currencies := models.Currencies{
-		Currencies: make([]models.Currency, 0, p.config.InitialCurrenciesCapacity),
+// ParseMetals parses precious metals quotations data.
+func (p *XmlParser) ParseMetals(data []byte) (models.Metals, error) {
+	var metals models.Metals
+
+	if err := newDecoder(bytes.NewReader(data)).Decode(&metals); err != nil {
+		return metals, errlib.Wrap(err, "could not decode xml data")
 	}
 
-	if err := decoder.Decode(&currencies); err != nil {
-		return currencies, errlib.Wrap(err, "could not decode xml data")
+	return metals, nil
+}
+
+func setRootAttrs(currencies *models.Currencies, attrs []xml.Attr) error {
+	for _, attr := range attrs {
+		switch attr.Name.Local {
+		case dateXmlAttr:
+			rateDate, err := time.Parse(rateDateLayout, attr.Value)
+			if err != nil {
+				return errlib.Wrap(err, "could not parse rate date")
+			}
+
+			currencies.Date = attr.Value
+			currencies.RateDate = rateDate
+		case nameXmlAttr:
+			currencies.Name = attr.Value
+		}
 	}
 
-	return currencies, nil
+	return nil
 }
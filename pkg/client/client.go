@@ -0,0 +1,472 @@
+// Package client is the client of the HTTP API of the currency
+// converter server:
+//
+//	c, err := client.New("http://localhost:8080", client.Options{})
+//	if err != nil {
+//		return err
+//	}
+//
+//	conversion, err := c.Convert(ctx, decimal.NewFromInt(100), "USD", "EUR")
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+// The defaults of the options.
+const (
+	DefaultTimeout    = 10 * time.Second
+	DefaultMaxRetries = 2
+	DefaultRetryDelay = 500 * time.Millisecond
+)
+
+// ErrInvalidSignature is returned, when the response is not signed by
+// the signing key of the options.
+var ErrInvalidSignature = errors.New("invalid response signature")
+
+// A Currency is a currency with its current rate and the metadata by
+// ISO 4217. The minor units are nil, if the currency has none.
+type Currency struct {
+	Name       string   `json:"name"`
+	CharCode   string   `json:"char_code"`
+	UnitValue  string   `json:"unit_value"`
+	Ratio      string   `json:"ratio"`
+	Symbol     string   `json:"symbol"`
+	MinorUnits *int     `json:"minor_units"`
+	Countries  []string `json:"countries"`
+}
+
+// A Conversion is the result of the conversion of the amount between
+// the currencies. The rate is the official number of units of the
+// currency to per a unit of the currency from, and the applied rate is
+// the one less the markup, which the result is converted by.
+type Conversion struct {
+	Amount         decimal.Decimal `json:"amount"`
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	Rate           decimal.Decimal `json:"rate"`
+	MarkupPercent  decimal.Decimal `json:"markup_percent"`
+	AppliedRate    decimal.Decimal `json:"applied_rate"`
+	Result         decimal.Decimal `json:"result"`
+	OfficialResult decimal.Decimal `json:"official_result"`
+	Precision      int             `json:"precision"`
+	Rounding       string          `json:"rounding"`
+	RateDate       string          `json:"rate_date"`
+}
+
+// A HistoryRecord is the rate of a currency on a date in the past.
+type HistoryRecord struct {
+	RateDate  string `json:"rate_date"`
+	CharCode  string `json:"char_code"`
+	Name      string `json:"name"`
+	UnitValue string `json:"unit_value"`
+}
+
+// A Change is the change of the rate of a currency since the previous
+// snapshot.
+type Change struct {
+	CharCode      string          `json:"char_code"`
+	Name          string          `json:"name"`
+	Previous      decimal.Decimal `json:"previous"`
+	Current       decimal.Decimal `json:"current"`
+	Change        decimal.Decimal `json:"change"`
+	ChangePercent decimal.Decimal `json:"change_percent"`
+}
+
+// Changes are the changes of the rates of the currencies between the
+// rate dates, from the largest percentage change to the smallest one.
+type Changes struct {
+	RateDate         string   `json:"rate_date"`
+	PreviousRateDate string   `json:"previous_rate_date"`
+	Changes          []Change `json:"changes"`
+}
+
+// A Comparison is the rates of the currencies on the two dates side by
+// side, from the largest percentage change to the smallest one. The
+// rate dates are the ones of the rates, that are effective on the dates.
+type Comparison struct {
+	Date1      string             `json:"date1"`
+	Date2      string             `json:"date2"`
+	RateDate1  string             `json:"rate_date1"`
+	RateDate2  string             `json:"rate_date2"`
+	Currencies []ComparedCurrency `json:"currencies"`
+}
+
+// A ComparedCurrency is the rate of a currency on the two dates along
+// with the change from the first date to the second one.
+type ComparedCurrency struct {
+	CharCode      string          `json:"char_code"`
+	Name          string          `json:"name"`
+	Value1        decimal.Decimal `json:"value1"`
+	Value2        decimal.Decimal `json:"value2"`
+	Change        decimal.Decimal `json:"change"`
+	ChangePercent decimal.Decimal `json:"change_percent"`
+}
+
+// A Delta is the currencies, which values have changed since the
+// update, and the char codes of the ones, that are not served anymore.
+// The update id is the one to sync with next time.
+type Delta struct {
+	UpdateId   int        `json:"update_id"`
+	Since      int        `json:"since"`
+	RateDate   string     `json:"rate_date"`
+	Currencies []Currency `json:"currencies"`
+	Removed    []string   `json:"removed"`
+}
+
+// Stats are the statistics of the rates of a currency over the period
+// from the first date to the last one. The volatility is the standard
+// deviation of the percentage changes between the consecutive rates.
+type Stats struct {
+	CharCode   string          `json:"char_code"`
+	Name       string          `json:"name"`
+	From       string          `json:"from"`
+	To         string          `json:"to"`
+	Count      int             `json:"count"`
+	Min        decimal.Decimal `json:"min"`
+	Max        decimal.Decimal `json:"max"`
+	Mean       decimal.Decimal `json:"mean"`
+	Volatility decimal.Decimal `json:"volatility"`
+}
+
+// An Error is the error response of the server.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return "server responded with status " + strconv.Itoa(e.StatusCode)
+	}
+
+	return "server responded with status " + strconv.Itoa(e.StatusCode) + ": " + e.Message
+}
+
+// Options are the options of the client. The zero values are replaced
+// with the defaults, and the retries are disabled with a negative
+// MaxRetries. The signatures of the responses are verified by the
+// signing key, e.g. the one from /signing-key, if it is set.
+type Options struct {
+	HttpClient *http.Client
+	MaxRetries int
+	RetryDelay time.Duration
+	SigningKey ed25519.PublicKey
+}
+
+type Client struct {
+	baseUrl    *url.URL
+	httpClient *http.Client
+	maxRetries int
+	retryDelay time.Duration
+	signingKey ed25519.PublicKey
+}
+
+// New creates the client of the server by the base url.
+func New(baseUrl string, opts Options) (*Client, error) {
+	parsedUrl, err := url.Parse(strings.TrimSuffix(baseUrl, "/"))
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not parse base url")
+	}
+
+	if (parsedUrl.Scheme == "") || (parsedUrl.Host == "") {
+		return nil, errors.New("base url must be absolute")
+	}
+
+	c := &Client{
+		baseUrl:    parsedUrl,
+		httpClient: opts.HttpClient,
+		maxRetries: opts.MaxRetries,
+		retryDelay: opts.RetryDelay,
+		signingKey: opts.SigningKey,
+	}
+
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+
+	if c.maxRetries == 0 {
+		c.maxRetries = DefaultMaxRetries
+	}
+
+	if c.maxRetries < 0 {
+		c.maxRetries = 0
+	}
+
+	if c.retryDelay <= 0 {
+		c.retryDelay = DefaultRetryDelay
+	}
+
+	return c, nil
+}
+
+// Currencies returns the current rates of all currencies.
+func (c *Client) Currencies(ctx context.Context) ([]Currency, error) {
+	var currencies []Currency
+
+	if err := c.get(ctx, "/currencies", nil, &currencies); err != nil {
+		return nil, err
+	}
+
+	return currencies, nil
+}
+
+// Currency returns the current rate of the currency by the char code or
+// the numeric code.
+func (c *Client) Currency(ctx context.Context, code string) (*Currency, error) {
+	currency := new(Currency)
+
+	if err := c.get(ctx, "/currencies/"+url.PathEscape(code), nil, currency); err != nil {
+		return nil, err
+	}
+
+	return currency, nil
+}
+
+// Changes returns the changes of the rates of the currencies since the
+// previous snapshot. The number of them is not limited, if the limit is
+// zero.
+func (c *Client) Changes(ctx context.Context, limit int) (*Changes, error) {
+	query := url.Values{}
+
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	changes := new(Changes)
+
+	if err := c.get(ctx, "/currencies/changes", query, changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// Compare returns the stored rates of the currencies, that are effective
+// on the two dates in the YYYY-MM-DD format, side by side.
+func (c *Client) Compare(ctx context.Context, date1 string, date2 string) (*Comparison, error) {
+	query := url.Values{
+		"date1": {date1},
+		"date2": {date2},
+	}
+
+	comparison := new(Comparison)
+
+	if err := c.get(ctx, "/currencies/compare", query, comparison); err != nil {
+		return nil, err
+	}
+
+	return comparison, nil
+}
+
+// Delta returns the currencies, which values have changed since the
+// update by the id of it, or nil, if none of them have changed.
+func (c *Client) Delta(ctx context.Context, since int) (*Delta, error) {
+	query := url.Values{
+		"since": {strconv.Itoa(since)},
+	}
+
+	delta := new(Delta)
+
+	if err := c.get(ctx, "/currencies/delta", query, delta); err != nil {
+		return nil, err
+	}
+
+	if delta.UpdateId == 0 {
+		// There is no content.
+		return nil, nil
+	}
+
+	return delta, nil
+}
+
+// Stats returns the statistics of the rates of the currency by the char
+// code or the numeric code over the period, that ends today, e.g. 30d,
+// 2w, 6m or 1y. The empty period is left to the default of the server:
+// 30 days.
+func (c *Client) Stats(ctx context.Context, code string, period string) (*Stats, error) {
+	query := url.Values{}
+
+	if period != "" {
+		query.Set("period", period)
+	}
+
+	stats := new(Stats)
+
+	if err := c.get(ctx, "/currencies/"+url.PathEscape(code)+"/stats", query, stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// Convert converts the amount between the currencies with the char
+// codes by the current rates.
+func (c *Client) Convert(ctx context.Context, amount decimal.Decimal, from string, to string) (*Conversion, error) {
+	return c.ConvertOnDate(ctx, amount, from, to, "")
+}
+
+// ConvertOnDate converts the amount between the currencies with the
+// char codes by the stored rates, that are effective on the date in the
+// YYYY-MM-DD format. The empty date is of the current rates.
+func (c *Client) ConvertOnDate(ctx context.Context, amount decimal.Decimal, from string, to string, date string) (*Conversion, error) {
+	query := url.Values{
+		"amount": {amount.String()},
+		"from":   {from},
+		"to":     {to},
+	}
+
+	if date != "" {
+		query.Set("date", date)
+	}
+
+	conversion := new(Conversion)
+
+	if err := c.get(ctx, "/convert", query, conversion); err != nil {
+		return nil, err
+	}
+
+	return conversion, nil
+}
+
+// History returns the rates of the period from the first date to the
+// last one inclusive, in the YYYY-MM-DD format. The empty dates and
+// code are left to the defaults of the server: the last 30 days and
+// all currencies.
+func (c *Client) History(ctx context.Context, fromDate string, toDate string, code string) ([]HistoryRecord, error) {
+	query := url.Values{}
+
+	for name, value := range map[string]string{"from": fromDate, "to": toDate, "code": code} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+
+	var history []HistoryRecord
+
+	if err := c.get(ctx, "/history", query, &history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// get requests the path and decodes the response into v. The request
+// is retried with a growing delay, if the server is unreachable or
+// responds with a status, that may change on retry.
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	reqUrl := *c.baseUrl
+	reqUrl.Path += path
+	reqUrl.RawQuery = query.Encode()
+
+	delay := c.retryDelay
+
+	for attempt := 0; ; attempt++ {
+		err := c.do(ctx, reqUrl.String(), v)
+		if (err == nil) || (attempt >= c.maxRetries) || (ctx.Err() != nil) || !isRetryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+	}
+}
+
+func (c *Client) do(ctx context.Context, reqUrl string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqUrl, nil)
+	if err != nil {
+		return errlib.Wrap(err, "could not create request")
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if c.signingKey != nil {
+		// The body is signed as it is sent, so it must not be decoded
+		// before it is verified.
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errlib.Wrap(err, "could not send request")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var reader io.Reader = resp.Body
+
+	if c.signingKey != nil {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return errlib.Wrap(err, "could not read response")
+		}
+
+		signature, err := base64.StdEncoding.DecodeString(resp.Header.Get("X-Signature"))
+		if (err != nil) || !ed25519.Verify(c.signingKey, data, signature) {
+			return ErrInvalidSignature
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Message string `json:"message"`
+		}
+
+		_ = json.NewDecoder(reader).Decode(&body)
+
+		return &Error{
+			StatusCode: resp.StatusCode,
+			Message:    body.Message,
+		}
+	}
+
+	if err = json.NewDecoder(reader).Decode(v); err != nil {
+		return errlib.Wrap(err, "could not decode response")
+	}
+
+	return nil
+}
+
+// isRetryable reports, whether the request, that has failed with the
+// error, may succeed on retry.
+func isRetryable(err error) bool {
+	if errors.Is(err, ErrInvalidSignature) {
+		return false
+	}
+
+	var respErr *Error
+
+	if !errors.As(err, &respErr) {
+		// The server is unreachable.
+		return true
+	}
+
+	return (respErr.StatusCode == http.StatusTooManyRequests) ||
+		(respErr.StatusCode == http.StatusBadGateway) ||
+		(respErr.StatusCode == http.StatusServiceUnavailable) ||
+		(respErr.StatusCode == http.StatusGatewayTimeout)
+}
@@ -0,0 +1,97 @@
+// Package converter converts amounts between currencies by the rates of
+// the Central Bank of Russia or the other sources, that the server
+// supports, so the conversion is available without the HTTP API:
+//
+//	source, err := converter.NewSource(converter.SourceOptions{})
+//	if err != nil {
+//		return err
+//	}
+//
+//	conv := converter.New(source, converter.NewMemoryStorage())
+//
+//	if _, err = conv.Update(ctx); err != nil {
+//		return err
+//	}
+//
+//	amount, err := conv.Convert(ctx, decimal.NewFromInt(100), "USD", "EUR")
+package converter
+
+import (
+	"context"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/go-errlib"
+	"github.com/shopspring/decimal"
+)
+
+// BaseCharCode is the char code of the currency, which the values of
+// the rates are in.
+const BaseCharCode = rates.BaseCharCode
+
+var (
+	ErrUnknownCurrency = rates.ErrUnknownCurrency
+	ErrNoRates         = rates.ErrNoRates
+)
+
+// A Rate is the value of a single unit of a currency in the base
+// currency.
+type Rate = rates.Rate
+
+// Rates are the rates of the currencies, that are effective on the
+// date. Rates.Convert converts amounts between the currencies.
+type Rates = rates.Rates
+
+// A Converter converts amounts by the latest rates of the source, that
+// are kept in the storage.
+type Converter struct {
+	source  Source
+	storage Storage
+}
+
+func New(source Source, storage Storage) *Converter {
+	return &Converter{
+		source:  source,
+		storage: storage,
+	}
+}
+
+// Update gets the latest rates from the source, saves them in the
+// storage and returns them.
+func (c *Converter) Update(ctx context.Context) (*Rates, error) {
+	rates, err := c.source.Rates(ctx)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not get rates")
+	}
+
+	if err = c.storage.SaveRates(ctx, rates); err != nil {
+		return nil, errlib.Wrap(err, "could not save rates")
+	}
+
+	return rates, nil
+}
+
+// Rates returns the latest rates of the storage or ErrNoRates, if there
+// are none yet.
+func (c *Converter) Rates(ctx context.Context) (*Rates, error) {
+	rates, err := c.storage.LatestRates(ctx)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not get latest rates")
+	}
+
+	if rates == nil {
+		return nil, ErrNoRates
+	}
+
+	return rates, nil
+}
+
+// Convert converts the amount between the currencies with the char
+// codes by the latest rates of the storage.
+func (c *Converter) Convert(ctx context.Context, amount decimal.Decimal, from string, to string) (decimal.Decimal, error) {
+	rates, err := c.Rates(ctx)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return rates.Convert(amount, from, to)
+}
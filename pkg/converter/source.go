@@ -0,0 +1,136 @@
+package converter
+
+import (
+	"context"
+	"time"
+
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/config"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/endpoint"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/parser"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/rates"
+	"github.com/mrumyantsev/currency-converter-app/internal/pkg/validator"
+	"github.com/mrumyantsev/go-errlib"
+)
+
+// The formats of the source data.
+const (
+	FormatCbrXml  = config.SourceFormatCbrXml
+	FormatCbrJson = config.SourceFormatCbrJson
+	FormatEcbXml  = config.SourceFormatEcbXml
+)
+
+// The defaults of the source options.
+const (
+	DefaultSourceUrl       = "https://www.cbr.ru/scripts/XML_daily.asp"
+	DefaultMaxResponseSize = 1 << 20
+	DefaultUserAgent       = "Mozilla/5.0 (X11; Linux x86_64)"
+	DefaultTimeout         = 30 * time.Second
+
+	initialRatesCapacity = 50
+	requestProtocol      = "HTTP/1.1"
+)
+
+// A Source gets the latest rates.
+type Source interface {
+	Rates(ctx context.Context) (*Rates, error)
+}
+
+// SourceOptions are the options of the web source. The zero values are
+// replaced with the defaults.
+type SourceOptions struct {
+	Url             string
+	Format          string
+	MaxResponseSize int64
+	UserAgent       string
+	Timeout         time.Duration
+}
+
+// A webSource gets the rates from the source by the url, the same way
+// the server does.
+type webSource struct {
+	fetcher *endpoint.CurrenciesFromSourceEndpoint
+	config  *config.Config
+}
+
+// NewSource creates the source, that gets the rates from the web.
+func NewSource(opts SourceOptions) (Source, error) {
+	cfg := sourceConfig(opts)
+
+	if _, err := parser.New(cfg); err != nil {
+		return nil, err
+	}
+
+	return &webSource{
+		fetcher: endpoint.NewCurrenciesFromSourceEndpoint(cfg),
+		config:  cfg,
+	}, nil
+}
+
+func (s *webSource) Rates(ctx context.Context) (*Rates, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := s.fetcher.CurrenciesFromSource(ctx)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not get rates from source")
+	}
+
+	return Parse(data, s.config.CurrencySourceFormat)
+}
+
+// Parse parses the rates from the data of the format, which is one of
+// the Format constants, and validates them.
+func Parse(data []byte, format string) (*Rates, error) {
+	p, err := parser.New(sourceConfig(SourceOptions{Format: format}))
+	if err != nil {
+		return nil, err
+	}
+
+	currencies, err := p.Parse(data)
+	if err != nil {
+		return nil, errlib.Wrap(err, "could not parse rates")
+	}
+
+	if err = validator.Validate(currencies); err != nil {
+		return nil, errlib.Wrap(err, "parsed rates are invalid")
+	}
+
+	return rates.Of(currencies)
+}
+
+// sourceConfig returns the configuration of the source and the parser
+// of the options, with the defaults set.
+func sourceConfig(opts SourceOptions) *config.Config {
+	cfg := &config.Config{
+		CurrencySourceUrl:         opts.Url,
+		CurrencySourceFormat:      opts.Format,
+		SourceMaxResponseSize:     opts.MaxResponseSize,
+		HttpRequestProtocol:       requestProtocol,
+		FakeUserAgentHeaderValue:  opts.UserAgent,
+		SourceTimeout:             opts.Timeout,
+		InitialCurrenciesCapacity: initialRatesCapacity,
+	}
+
+	if cfg.CurrencySourceUrl == "" {
+		cfg.CurrencySourceUrl = DefaultSourceUrl
+	}
+
+	if cfg.CurrencySourceFormat == "" {
+		cfg.CurrencySourceFormat = FormatCbrXml
+	}
+
+	if cfg.SourceMaxResponseSize <= 0 {
+		cfg.SourceMaxResponseSize = DefaultMaxResponseSize
+	}
+
+	if cfg.FakeUserAgentHeaderValue == "" {
+		cfg.FakeUserAgentHeaderValue = DefaultUserAgent
+	}
+
+	if cfg.SourceTimeout <= 0 {
+		cfg.SourceTimeout = DefaultTimeout
+	}
+
+	return cfg
+}
@@ -0,0 +1,38 @@
+package converter
+
+import (
+	"context"
+	"sync"
+)
+
+// A Storage keeps the latest rates between the updates.
+type Storage interface {
+	SaveRates(ctx context.Context, rates *Rates) error
+	// LatestRates returns nil, if there are no rates saved.
+	LatestRates(ctx context.Context) (*Rates, error)
+}
+
+// A MemoryStorage keeps the latest rates in memory.
+type MemoryStorage struct {
+	mu    sync.RWMutex
+	rates *Rates
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{}
+}
+
+func (s *MemoryStorage) SaveRates(_ context.Context, rates *Rates) error {
+	s.mu.Lock()
+	s.rates = rates
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *MemoryStorage) LatestRates(_ context.Context) (*Rates, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.rates, nil
+}
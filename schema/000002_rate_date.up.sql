@@ -0,0 +1,2 @@
+ALTER TABLE public.update_datetimes
+	ADD COLUMN IF NOT EXISTS rate_date DATE;
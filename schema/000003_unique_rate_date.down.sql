@@ -0,0 +1,5 @@
+ALTER TABLE public.currency_values
+	DROP CONSTRAINT IF EXISTS uq_currency_values_update_info;
+
+ALTER TABLE public.update_datetimes
+	DROP CONSTRAINT IF EXISTS uq_update_datetimes_rate_date;
@@ -0,0 +1,10 @@
+DELETE FROM public.update_datetimes AS older
+USING public.update_datetimes AS newer
+WHERE older.rate_date = newer.rate_date
+	AND older.id < newer.id;
+
+ALTER TABLE public.update_datetimes
+	ADD CONSTRAINT uq_update_datetimes_rate_date UNIQUE (rate_date);
+
+ALTER TABLE public.currency_values
+	ADD CONSTRAINT uq_currency_values_update_info UNIQUE (update_datetime_id, info_num_code);
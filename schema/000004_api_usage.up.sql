@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS public.api_usage (
+	key_name   VARCHAR(64) NOT NULL,
+	usage_date DATE        NOT NULL,
+	requests   BIGINT      NOT NULL,
+		CONSTRAINT pk_api_usage PRIMARY KEY (key_name, usage_date)
+);
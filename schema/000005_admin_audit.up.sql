@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS public.admin_audit (
+	id         SERIAL                   NOT NULL UNIQUE,
+	actor      VARCHAR(64)              NOT NULL,
+	action     VARCHAR(64)              NOT NULL,
+	payload    TEXT,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		CONSTRAINT pk_admin_audit PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS ix_admin_audit_created_at ON public.admin_audit (created_at);
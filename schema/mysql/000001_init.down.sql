@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS currency_values;
+DROP TABLE IF EXISTS update_datetimes;
+DROP TABLE IF EXISTS info;
+DROP TABLE IF EXISTS multipliers;
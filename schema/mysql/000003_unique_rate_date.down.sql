@@ -0,0 +1,2 @@
+ALTER TABLE update_datetimes
+	DROP INDEX uq_update_datetimes_rate_date;
@@ -0,0 +1,7 @@
+DELETE older FROM update_datetimes AS older
+JOIN update_datetimes AS newer
+	ON older.rate_date = newer.rate_date
+	AND older.id < newer.id;
+
+ALTER TABLE update_datetimes
+	ADD CONSTRAINT uq_update_datetimes_rate_date UNIQUE (rate_date);
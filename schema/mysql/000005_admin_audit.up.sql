@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS admin_audit (
+	id         INTEGER     NOT NULL AUTO_INCREMENT,
+	actor      VARCHAR(64) NOT NULL,
+	action     VARCHAR(64) NOT NULL,
+	payload    TEXT,
+	created_at DATETIME    NOT NULL,
+		CONSTRAINT pk_admin_audit PRIMARY KEY (id),
+		INDEX ix_admin_audit_created_at (created_at)
+) DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,9 @@
+// Package schema embeds the SQL migrations of the database schema.
+// The migrations of Postgres are in the root of the package, the ones
+// of other databases are in the directories named after their drivers.
+package schema
+
+import "embed"
+
+//go:embed *.sql sqlite/*.sql mysql/*.sql
+var Migrations embed.FS
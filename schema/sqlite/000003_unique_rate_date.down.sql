@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_currency_values_update_info;
+DROP INDEX IF EXISTS uq_update_datetimes_rate_date;
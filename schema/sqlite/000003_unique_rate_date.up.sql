@@ -0,0 +1,13 @@
+DELETE FROM update_datetimes
+WHERE rate_date IS NOT NULL
+	AND id < (
+		SELECT MAX(newer.id)
+		FROM update_datetimes AS newer
+		WHERE newer.rate_date = update_datetimes.rate_date
+	);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_update_datetimes_rate_date
+	ON update_datetimes (rate_date);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_currency_values_update_info
+	ON currency_values (update_datetime_id, info_num_code);
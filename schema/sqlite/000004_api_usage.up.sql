@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS api_usage (
+	key_name   TEXT    NOT NULL,
+	usage_date TEXT    NOT NULL,
+	requests   INTEGER NOT NULL,
+		CONSTRAINT pk_api_usage PRIMARY KEY (key_name, usage_date)
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS admin_audit (
+	id         INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	actor      TEXT    NOT NULL,
+	action     TEXT    NOT NULL,
+	payload    TEXT,
+	created_at TEXT    NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ix_admin_audit_created_at ON admin_audit (created_at);
@@ -13,7 +13,7 @@ const rightCurrencySide = 2;
 
 const rubleCurrency = {
     name: "Российский рубль",
-    charCode: "RUB",
+    char_code: "RUB",
     ratio: "1.0"
 }
 
@@ -49,9 +49,9 @@ const updateDataFromServer = () => {
         return;
     }
 
-    const dolIdx = data.findIndex(curr => curr.charCode === "USD");
+    const dolIdx = data.findIndex(curr => curr.char_code === "USD");
     const dolCurr = data.splice(dolIdx, 1)[0];
-    const eurIdx = data.findIndex(curr => curr.charCode === "EUR");
+    const eurIdx = data.findIndex(curr => curr.char_code === "EUR");
     const eurCurr = data.splice(eurIdx, 1)[0];
 
     data.unshift(eurCurr);
@@ -89,8 +89,8 @@ const getDataFromServer = (url) => {
 }
 
 const initPageElements = (data) => {
-    const firstCurrency = data.find(curr => curr.charCode === "USD");
-    const secondCurrency = data.find(curr => curr.charCode === "RUB");
+    const firstCurrency = data.find(curr => curr.char_code === "USD");
+    const secondCurrency = data.find(curr => curr.char_code === "RUB");
 
     leftCurrencyButton.text(
         getExtendedCurrencyName(firstCurrency));
@@ -158,7 +158,7 @@ const calculateResult = () => {
 }
 
 const getExtendedCurrencyName = (currency) => {
-    return currency.name + " (" + currency.charCode + ")";
+    return currency.name + " (" + currency.char_code + ")";
 }
 
 main();